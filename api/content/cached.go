@@ -0,0 +1,49 @@
+package content
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/samluiz/blog/api/types"
+	"github.com/samluiz/blog/common/cache"
+)
+
+const CACHE_PREFIX = "content:"
+
+type cachedSource struct {
+	source ContentSource
+	cache  *cache.Cache
+}
+
+// wraps a source so its responses are served from memory
+func NewCachedSource(source ContentSource, c *cache.Cache) ContentSource {
+	return &cachedSource{source, c}
+}
+
+func (s *cachedSource) Name() string {
+	return s.source.Name()
+}
+
+func (s *cachedSource) ListArticles(page, perPage int) ([]types.ArticleResponse, error) {
+	key := fmt.Sprintf("%s%s:list:%d:%d", CACHE_PREFIX, s.source.Name(), page, perPage)
+
+	return cache.GetAs(s.cache, key, func() ([]types.ArticleResponse, error) {
+		return s.source.ListArticles(page, perPage)
+	})
+}
+
+func (s *cachedSource) ListArticlesByTag(tag string, page, perPage int) ([]types.ArticleResponse, error) {
+	key := fmt.Sprintf("%s%s:tag:%s:%d:%d", CACHE_PREFIX, s.source.Name(), strings.ToLower(tag), page, perPage)
+
+	return cache.GetAs(s.cache, key, func() ([]types.ArticleResponse, error) {
+		return s.source.ListArticlesByTag(tag, page, perPage)
+	})
+}
+
+func (s *cachedSource) GetArticle(slug string) (*types.ArticleResponse, error) {
+	key := fmt.Sprintf("%s%s:article:%s", CACHE_PREFIX, s.source.Name(), slug)
+
+	return cache.GetAs(s.cache, key, func() (*types.ArticleResponse, error) {
+		return s.source.GetArticle(slug)
+	})
+}
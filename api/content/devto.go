@@ -0,0 +1,77 @@
+package content
+
+import (
+	"errors"
+
+	"github.com/samluiz/blog/api/integrations"
+	"github.com/samluiz/blog/api/types"
+	pkgTypes "github.com/samluiz/blog/pkg/types"
+)
+
+const (
+	// size of the pages fetched from dev.to when the articles have to be filtered locally
+	DEVTO_SCAN_SIZE = 100
+	// the scan stops after this many pages, so one lookup can't turn into an unbounded number of requests
+	DEVTO_SCAN_MAX_PAGES = 10
+)
+
+type devToSource struct{}
+
+func NewDevToSource() ContentSource {
+	return &devToSource{}
+}
+
+func (s *devToSource) Name() string {
+	return types.SOURCE_DEVTO
+}
+
+func (s *devToSource) ListArticles(page, perPage int) ([]types.ArticleResponse, error) {
+	return integrations.GetArticlesFromDevTo(page, perPage)
+}
+
+// the dev.to api can't filter the user's articles by tag, so they are fetched in batches and filtered here
+func (s *devToSource) ListArticlesByTag(tag string, page, perPage int) ([]types.ArticleResponse, error) {
+	var tagged []types.ArticleResponse
+
+	for p := 1; p <= DEVTO_SCAN_MAX_PAGES; p++ {
+		batch, err := integrations.GetArticlesFromDevTo(p, DEVTO_SCAN_SIZE)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tagged = append(tagged, FilterByTag(batch, tag)...)
+
+		if len(batch) < DEVTO_SCAN_SIZE {
+			break
+		}
+
+		if p == DEVTO_SCAN_MAX_PAGES {
+			LOGGER.Warning("stopped looking for dev.to articles tagged %s after %d pages", tag, p)
+		}
+	}
+
+	start := (page - 1) * perPage
+
+	if page < 1 || start >= len(tagged) {
+		return []types.ArticleResponse{}, nil
+	}
+
+	end := start + perPage
+
+	if end > len(tagged) {
+		end = len(tagged)
+	}
+
+	return tagged[start:end], nil
+}
+
+func (s *devToSource) GetArticle(slug string) (*types.ArticleResponse, error) {
+	article, err := integrations.GetArticleBySlugDevTo(slug)
+
+	if errors.Is(err, integrations.ErrDevToArticleNotFound) {
+		return nil, pkgTypes.ErrArticleNotFound
+	}
+
+	return article, err
+}
@@ -0,0 +1,115 @@
+package content
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/samluiz/blog/api/types"
+	articleUtils "github.com/samluiz/blog/common/article"
+	"github.com/samluiz/blog/common/date"
+	"github.com/samluiz/blog/common/pagination"
+	"github.com/samluiz/blog/pkg/article"
+	pkgTypes "github.com/samluiz/blog/pkg/types"
+)
+
+const DESCRIPTION_LENGTH = 160
+
+type localSource struct {
+	articleService article.Service
+}
+
+func NewLocalSource(articleService article.Service) ContentSource {
+	return &localSource{articleService}
+}
+
+func (s *localSource) Name() string {
+	return types.SOURCE_LOCAL
+}
+
+func (s *localSource) ListArticles(page, perPage int) ([]types.ArticleResponse, error) {
+	return toArticlesResponse(s.articleService.FindPublishedArticles(newestFirst(page, perPage)))
+}
+
+func (s *localSource) ListArticlesByTag(tag string, page, perPage int) ([]types.ArticleResponse, error) {
+	return toArticlesResponse(s.articleService.FindPublishedArticlesByTag(tag, newestFirst(page, perPage)))
+}
+
+func newestFirst(page, perPage int) pagination.Pagination {
+	return pagination.Pagination{
+		Page:    page,
+		Size:    perPage,
+		OrderBy: "published_at",
+		SortBy:  "DESC",
+	}
+}
+
+// pages after the last one are empty instead of an error, like the other sources
+func toArticlesResponse(articles []*pkgTypes.GetArticleOutput, _ int, err error) ([]types.ArticleResponse, error) {
+	if err != nil {
+		if errors.Is(err, pagination.ErrPageOutOfRange) {
+			return []types.ArticleResponse{}, nil
+		}
+		return nil, err
+	}
+
+	response := make([]types.ArticleResponse, 0, len(articles))
+
+	for _, a := range articles {
+		response = append(response, ToArticleResponse(a))
+	}
+
+	return response, nil
+}
+
+func (s *localSource) GetArticle(slug string) (*types.ArticleResponse, error) {
+	a, err := s.articleService.FindArticleBySlug(slug)
+
+	if err != nil {
+		if errors.Is(err, pkgTypes.ErrArticleNotFound) {
+			return nil, pkgTypes.ErrArticleNotFound
+		}
+		return nil, err
+	}
+
+	if !a.IsPublished || a.DeletedAt != nil {
+		return nil, pkgTypes.ErrArticleNotFound
+	}
+
+	response := ToArticleResponse(a)
+
+	return &response, nil
+}
+
+// the article as the pages show it, whether it's published or not
+func ToArticleResponse(a *pkgTypes.GetArticleOutput) types.ArticleResponse {
+	response := types.ArticleResponse{
+		ID:                 a.ID,
+		Title:              a.Title,
+		Description:        a.Description,
+		Slug:               a.Slug,
+		TagList:            []string{},
+		UpdatedAtTime:      a.UpdatedAt,
+		ReadingTimeMinutes: articleUtils.ReadTime(a.Content),
+		BodyMarkdown:       a.Content,
+		Source:             types.SOURCE_LOCAL,
+	}
+
+	if response.Description == "" {
+		response.Description = articleUtils.Excerpt(a.Content, DESCRIPTION_LENGTH)
+	}
+
+	if a.Tags != "" {
+		response.TagList = strings.Split(a.Tags, ",")
+	}
+
+	if a.DevToID != nil {
+		response.DevToID = *a.DevToID
+	}
+
+	if a.PublishedAt != nil {
+		response.PublishedAtTime = *a.PublishedAt
+		response.PublishedAt = date.FormatTime(*a.PublishedAt)
+	}
+
+	return response
+}
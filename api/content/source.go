@@ -0,0 +1,218 @@
+package content
+
+import (
+	"errors"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/samluiz/blog/api/types"
+	"github.com/samluiz/blog/common/cache"
+	"github.com/samluiz/blog/common/logger"
+	"github.com/samluiz/blog/common/pagination"
+	"github.com/samluiz/blog/pkg/article"
+	"github.com/samluiz/blog/pkg/config"
+	pkgTypes "github.com/samluiz/blog/pkg/types"
+)
+
+var LOGGER = logger.New(os.Stdout, logger.DebugLevel, "[CONTENT]")
+
+var ErrNoSourcesEnabled = errors.New("no content sources are enabled")
+
+// a place where published articles come from, like dev.to or the local database
+type ContentSource interface {
+	Name() string
+	ListArticles(page, perPage int) ([]types.ArticleResponse, error)
+	ListArticlesByTag(tag string, page, perPage int) ([]types.ArticleResponse, error)
+	GetArticle(slug string) (*types.ArticleResponse, error)
+}
+
+// builds the aggregator with the sources enabled in the config. local articles are looked up first.
+// each source is cached on its own, so one of them failing doesn't throw away what the others returned
+func NewFromConfig(cfg config.ContentConfig, articleService article.Service, c *cache.Cache) ContentSource {
+	var sources []ContentSource
+
+	if cfg.LocalEnabled {
+		sources = append(sources, NewCachedSource(NewLocalSource(articleService), c))
+	}
+
+	if cfg.DevToEnabled {
+		sources = append(sources, NewCachedSource(NewDevToSource(), c))
+	}
+
+	return NewAggregator(sources...)
+}
+
+type aggregator struct {
+	sources []ContentSource
+}
+
+// merges the articles of every source, newest first. sources are queried in the given order when looking for a slug
+func NewAggregator(sources ...ContentSource) ContentSource {
+	return &aggregator{sources}
+}
+
+func (a *aggregator) Name() string {
+	return "aggregator"
+}
+
+func (a *aggregator) ListArticles(page, perPage int) ([]types.ArticleResponse, error) {
+	return a.merge(page, perPage, func(source ContentSource, page, perPage int) ([]types.ArticleResponse, error) {
+		return source.ListArticles(page, perPage)
+	})
+}
+
+func (a *aggregator) ListArticlesByTag(tag string, page, perPage int) ([]types.ArticleResponse, error) {
+	return a.merge(page, perPage, func(source ContentSource, page, perPage int) ([]types.ArticleResponse, error) {
+		return source.ListArticlesByTag(tag, page, perPage)
+	})
+}
+
+type listFunc func(source ContentSource, page, perPage int) ([]types.ArticleResponse, error)
+
+// every article of the source, newest first. an aggregator reads each of its sources once,
+// instead of merging them again for every page
+func ListAll(source ContentSource) ([]types.ArticleResponse, error) {
+	return listAll(source, func(source ContentSource, page, perPage int) ([]types.ArticleResponse, error) {
+		return source.ListArticles(page, perPage)
+	})
+}
+
+func listAll(source ContentSource, list listFunc) ([]types.ArticleResponse, error) {
+	if a, ok := source.(*aggregator); ok {
+		return a.all(list)
+	}
+
+	return readAll(source, list)
+}
+
+func (a *aggregator) merge(page, perPage int, list listFunc) ([]types.ArticleResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+
+	merged, err := a.all(list)
+
+	if err != nil {
+		return nil, err
+	}
+
+	start := (page - 1) * perPage
+
+	if start >= len(merged) {
+		return []types.ArticleResponse{}, nil
+	}
+
+	end := start + perPage
+
+	if end > len(merged) {
+		end = len(merged)
+	}
+
+	return merged[start:end], nil
+}
+
+// we don't know how the sources interleave, so each one is read to the end once and the result sorted
+func (a *aggregator) all(list listFunc) ([]types.ArticleResponse, error) {
+	if len(a.sources) == 0 {
+		return nil, ErrNoSourcesEnabled
+	}
+
+	var merged []types.ArticleResponse
+	var lastErr error
+	failed := 0
+	seen := map[string]bool{}
+	seenDevTo := map[int]bool{}
+
+	for _, source := range a.sources {
+		articles, err := readAll(source, list)
+
+		if err != nil {
+			LOGGER.Error("error listing articles from %s: %v", source.Name(), err)
+			lastErr = err
+			failed++
+			continue
+		}
+
+		// the same article can come from more than one source, like dev.to posts imported into the database
+		// or native articles cross posted to dev.to
+		for _, article := range articles {
+			if seen[article.Slug] || (article.DevToID != 0 && seenDevTo[article.DevToID]) {
+				continue
+			}
+			seen[article.Slug] = true
+			if article.DevToID != 0 {
+				seenDevTo[article.DevToID] = true
+			}
+			merged = append(merged, article)
+		}
+	}
+
+	if failed == len(a.sources) {
+		return nil, lastErr
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].PublishedAtTime.After(merged[j].PublishedAtTime)
+	})
+
+	return merged, nil
+}
+
+// the source is asked page by page, so no request is bigger than the page size the sources accept
+func readAll(source ContentSource, list listFunc) ([]types.ArticleResponse, error) {
+	var articles []types.ArticleResponse
+
+	for p := 1; ; p++ {
+		batch, err := list(source, p, pagination.MAX_SIZE)
+
+		if err != nil {
+			return nil, err
+		}
+
+		articles = append(articles, batch...)
+
+		if len(batch) < pagination.MAX_SIZE {
+			return articles, nil
+		}
+	}
+}
+
+// keeps the articles tagged with the tag, ignoring case
+func FilterByTag(articles []types.ArticleResponse, tag string) []types.ArticleResponse {
+	filtered := []types.ArticleResponse{}
+
+	for _, a := range articles {
+		for _, t := range a.TagList {
+			if strings.EqualFold(strings.TrimSpace(t), strings.TrimSpace(tag)) {
+				filtered = append(filtered, a)
+				break
+			}
+		}
+	}
+
+	return filtered
+}
+
+func (a *aggregator) GetArticle(slug string) (*types.ArticleResponse, error) {
+	if len(a.sources) == 0 {
+		return nil, ErrNoSourcesEnabled
+	}
+
+	var lastErr error = pkgTypes.ErrArticleNotFound
+
+	for _, source := range a.sources {
+		article, err := source.GetArticle(slug)
+
+		if err == nil {
+			return article, nil
+		}
+
+		if !errors.Is(err, pkgTypes.ErrArticleNotFound) {
+			LOGGER.Error("error getting article %s from %s: %v", slug, source.Name(), err)
+			lastErr = err
+		}
+	}
+
+	return nil, lastErr
+}
@@ -0,0 +1,369 @@
+package content
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/samluiz/blog/api/types"
+	"github.com/samluiz/blog/common/pagination"
+	pkgTypes "github.com/samluiz/blog/pkg/types"
+)
+
+var errUpstream = errors.New("upstream is down")
+
+// source returning fixed articles, already sorted newest first like the real ones
+type stubSource struct {
+	name     string
+	articles []types.ArticleResponse
+	err      error
+	// refuses bigger pages, like the local source does above pagination.MAX_SIZE
+	maxPerPage int
+	calls      int
+}
+
+func (s *stubSource) Name() string {
+	return s.name
+}
+
+func (s *stubSource) ListArticles(page, perPage int) ([]types.ArticleResponse, error) {
+	return s.page(s.articles, page, perPage)
+}
+
+func (s *stubSource) ListArticlesByTag(tag string, page, perPage int) ([]types.ArticleResponse, error) {
+	return s.page(FilterByTag(s.articles, tag), page, perPage)
+}
+
+func (s *stubSource) page(articles []types.ArticleResponse, page, perPage int) ([]types.ArticleResponse, error) {
+	s.calls++
+
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	if s.maxPerPage > 0 && perPage > s.maxPerPage {
+		return nil, errUpstream
+	}
+
+	start := (page - 1) * perPage
+
+	if start >= len(articles) {
+		return []types.ArticleResponse{}, nil
+	}
+
+	end := start + perPage
+
+	if end > len(articles) {
+		end = len(articles)
+	}
+
+	return articles[start:end], nil
+}
+
+func (s *stubSource) GetArticle(slug string) (*types.ArticleResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	for _, a := range s.articles {
+		if a.Slug == slug {
+			return &a, nil
+		}
+	}
+
+	return nil, pkgTypes.ErrArticleNotFound
+}
+
+func stubArticle(slug string, day int, source string, devToId int, tags ...string) types.ArticleResponse {
+	return types.ArticleResponse{
+		Slug:            slug,
+		Source:          source,
+		DevToID:         devToId,
+		TagList:         tags,
+		PublishedAtTime: time.Date(2024, time.January, day, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func slugs(articles []types.ArticleResponse) []string {
+	result := make([]string, 0, len(articles))
+
+	for _, a := range articles {
+		result = append(result, a.Slug)
+	}
+
+	return result
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestAggregatorListArticles(t *testing.T) {
+	local := &stubSource{name: types.SOURCE_LOCAL, articles: []types.ArticleResponse{
+		stubArticle("local-9", 9, types.SOURCE_LOCAL, 0),
+		stubArticle("cross-posted", 6, types.SOURCE_LOCAL, 100),
+		stubArticle("imported", 4, types.SOURCE_LOCAL, 200),
+		stubArticle("local-1", 1, types.SOURCE_LOCAL, 0),
+	}}
+
+	devTo := &stubSource{name: types.SOURCE_DEVTO, articles: []types.ArticleResponse{
+		stubArticle("devto-8", 8, types.SOURCE_DEVTO, 300),
+		// same dev.to post as the cross posted native article, with the slug dev.to gave it
+		stubArticle("cross-posted-4k2j", 6, types.SOURCE_DEVTO, 100),
+		stubArticle("devto-5", 5, types.SOURCE_DEVTO, 400),
+		stubArticle("imported", 4, types.SOURCE_DEVTO, 200),
+		stubArticle("devto-2", 2, types.SOURCE_DEVTO, 500),
+	}}
+
+	failing := &stubSource{name: "failing", err: errUpstream}
+
+	tests := []struct {
+		name    string
+		sources []ContentSource
+		page    int
+		perPage int
+		want    []string
+		err     error
+	}{
+		{
+			name:    "merges newest first and drops duplicates by slug and dev.to id",
+			sources: []ContentSource{local, devTo},
+			page:    1,
+			perPage: 10,
+			want:    []string{"local-9", "devto-8", "cross-posted", "devto-5", "imported", "devto-2", "local-1"},
+		},
+		{
+			name:    "first page",
+			sources: []ContentSource{local, devTo},
+			page:    1,
+			perPage: 3,
+			want:    []string{"local-9", "devto-8", "cross-posted"},
+		},
+		{
+			name:    "second page",
+			sources: []ContentSource{local, devTo},
+			page:    2,
+			perPage: 3,
+			want:    []string{"devto-5", "imported", "devto-2"},
+		},
+		{
+			name:    "last partial page",
+			sources: []ContentSource{local, devTo},
+			page:    3,
+			perPage: 3,
+			want:    []string{"local-1"},
+		},
+		{
+			name:    "page after the last one",
+			sources: []ContentSource{local, devTo},
+			page:    4,
+			perPage: 3,
+			want:    []string{},
+		},
+		{
+			name:    "page zero is the first page",
+			sources: []ContentSource{local, devTo},
+			page:    0,
+			perPage: 2,
+			want:    []string{"local-9", "devto-8"},
+		},
+		{
+			name:    "the first source wins duplicates",
+			sources: []ContentSource{devTo, local},
+			page:    1,
+			perPage: 3,
+			want:    []string{"local-9", "devto-8", "cross-posted-4k2j"},
+		},
+		{
+			name:    "keeps the articles of the sources that worked",
+			sources: []ContentSource{failing, local},
+			page:    1,
+			perPage: 10,
+			want:    []string{"local-9", "cross-posted", "imported", "local-1"},
+		},
+		{
+			name:    "fails when every source fails",
+			sources: []ContentSource{failing, failing},
+			page:    1,
+			perPage: 10,
+			err:     errUpstream,
+		},
+		{
+			name:    "fails without sources",
+			page:    1,
+			perPage: 10,
+			err:     ErrNoSourcesEnabled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			articles, err := NewAggregator(tt.sources...).ListArticles(tt.page, tt.perPage)
+
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ListArticles() error = %v, want %v", err, tt.err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if got := slugs(articles); !equal(got, tt.want) {
+				t.Errorf("ListArticles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAggregatorListsSourcesPageByPage(t *testing.T) {
+	source := &stubSource{name: "local", maxPerPage: pagination.MAX_SIZE, articles: []types.ArticleResponse{
+		stubArticle("e", 5, "local", 0),
+		stubArticle("d", 4, "local", 0),
+		stubArticle("c", 3, "local", 0),
+		stubArticle("b", 2, "local", 0),
+		stubArticle("a", 1, "local", 0),
+	}}
+
+	got, err := NewAggregator(source).ListArticles(3, 2)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !equal(slugs(got), []string{"a"}) {
+		t.Errorf("expected the last article, got %v", slugs(got))
+	}
+}
+
+func TestListAllReadsEachSourceOnce(t *testing.T) {
+	var articles []types.ArticleResponse
+
+	for i := pagination.MAX_SIZE + 5; i > 0; i-- {
+		articles = append(articles, stubArticle(fmt.Sprintf("article-%d", i), i, "local", 0))
+	}
+
+	local := &stubSource{name: "local", maxPerPage: pagination.MAX_SIZE, articles: articles}
+	devTo := &stubSource{name: "devto", maxPerPage: pagination.MAX_SIZE}
+
+	got, err := ListAll(NewAggregator(local, devTo))
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(got) != len(articles) {
+		t.Fatalf("expected %d articles, got %d", len(articles), len(got))
+	}
+
+	if got[0].Slug != articles[0].Slug || got[len(got)-1].Slug != articles[len(articles)-1].Slug {
+		t.Errorf("expected the articles newest first, got %s ... %s", got[0].Slug, got[len(got)-1].Slug)
+	}
+
+	if local.calls != 2 || devTo.calls != 1 {
+		t.Errorf("expected every page to be read once, got %d local and %d dev.to calls", local.calls, devTo.calls)
+	}
+}
+
+func TestAggregatorListArticlesByTag(t *testing.T) {
+	local := &stubSource{name: types.SOURCE_LOCAL, articles: []types.ArticleResponse{
+		stubArticle("local-go", 9, types.SOURCE_LOCAL, 0, "go", "sql"),
+		stubArticle("local-web", 7, types.SOURCE_LOCAL, 0, "web"),
+		stubArticle("imported-go", 4, types.SOURCE_LOCAL, 200, "go"),
+	}}
+
+	devTo := &stubSource{name: types.SOURCE_DEVTO, articles: []types.ArticleResponse{
+		stubArticle("devto-go", 8, types.SOURCE_DEVTO, 300, "Go"),
+		stubArticle("imported-go", 4, types.SOURCE_DEVTO, 200, "go"),
+		stubArticle("devto-rust", 2, types.SOURCE_DEVTO, 400, "rust"),
+	}}
+
+	aggregator := NewAggregator(local, devTo)
+
+	got, err := aggregator.ListArticlesByTag("go", 1, 10)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []string{"local-go", "devto-go", "imported-go"}; !equal(slugs(got), want) {
+		t.Errorf("ListArticlesByTag() = %v, want %v", slugs(got), want)
+	}
+
+	got, err = aggregator.ListArticlesByTag("go", 2, 2)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []string{"imported-go"}; !equal(slugs(got), want) {
+		t.Errorf("ListArticlesByTag() second page = %v, want %v", slugs(got), want)
+	}
+}
+
+func TestFilterByTag(t *testing.T) {
+	articles := []types.ArticleResponse{
+		stubArticle("go", 2, types.SOURCE_LOCAL, 0, "go", "sql"),
+		stubArticle("web", 1, types.SOURCE_LOCAL, 0, " Web"),
+	}
+
+	if got := FilterByTag(articles, "WEB"); !equal(slugs(got), []string{"web"}) {
+		t.Errorf("expected only the web article, got %v", slugs(got))
+	}
+
+	if got := FilterByTag(articles, "rust"); len(got) != 0 {
+		t.Errorf("expected no articles, got %v", slugs(got))
+	}
+}
+
+func TestAggregatorGetArticle(t *testing.T) {
+	local := &stubSource{name: types.SOURCE_LOCAL, articles: []types.ArticleResponse{
+		stubArticle("both", 2, types.SOURCE_LOCAL, 0),
+	}}
+
+	devTo := &stubSource{name: types.SOURCE_DEVTO, articles: []types.ArticleResponse{
+		stubArticle("both", 2, types.SOURCE_DEVTO, 100),
+		stubArticle("devto-only", 1, types.SOURCE_DEVTO, 200),
+	}}
+
+	failing := &stubSource{name: "failing", err: errUpstream}
+
+	tests := []struct {
+		name    string
+		sources []ContentSource
+		slug    string
+		source  string
+		err     error
+	}{
+		{name: "first source that has it", sources: []ContentSource{local, devTo}, slug: "both", source: types.SOURCE_LOCAL},
+		{name: "falls back to the next source", sources: []ContentSource{local, devTo}, slug: "devto-only", source: types.SOURCE_DEVTO},
+		{name: "skips failing sources", sources: []ContentSource{failing, devTo}, slug: "devto-only", source: types.SOURCE_DEVTO},
+		{name: "not found", sources: []ContentSource{local, devTo}, slug: "missing", err: pkgTypes.ErrArticleNotFound},
+		{name: "reports the failure when nobody has it", sources: []ContentSource{local, failing}, slug: "missing", err: errUpstream},
+		{name: "fails without sources", slug: "both", err: ErrNoSourcesEnabled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewAggregator(tt.sources...).GetArticle(tt.slug)
+
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("GetArticle() error = %v, want %v", err, tt.err)
+			}
+
+			if err == nil && got.Source != tt.source {
+				t.Errorf("GetArticle() source = %s, want %s", got.Source, tt.source)
+			}
+		})
+	}
+}
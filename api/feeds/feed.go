@@ -0,0 +1,83 @@
+package feeds
+
+import (
+	"strings"
+	"time"
+
+	"github.com/samluiz/blog/api/parsers"
+	"github.com/samluiz/blog/api/types"
+)
+
+// what every feed format is built from, so rss, atom and json feed always list the same articles
+type Feed struct {
+	Title       string
+	Description string
+	// the page the feed is about and the url the feed itself is served from
+	Link    string
+	FeedURL string
+	Author  Author
+	Updated time.Time
+	Items   []Item
+}
+
+type Author struct {
+	Name   string
+	URL    string
+	Avatar string
+}
+
+type Item struct {
+	ID          string
+	Title       string
+	Link        string
+	Summary     string
+	ContentHTML string
+	Tags        []string
+	Published   time.Time
+	Updated     time.Time
+}
+
+var feedMarkdownOptions = parsers.Options{Extensions: parsers.ALL_EXTENSIONS}
+
+// builds the feed items from the articles, rendering their markdown to html. the feed is as recent as its newest change
+func New(title, description, link, feedURL string, author Author, articles []types.ArticleResponse) Feed {
+	feed := Feed{
+		Title:       title,
+		Description: description,
+		Link:        link,
+		FeedURL:     feedURL,
+		Author:      author,
+		Items:       make([]Item, 0, len(articles)),
+	}
+
+	siteURL := strings.TrimSuffix(link, "/")
+
+	for _, a := range articles {
+		updated := a.UpdatedAtTime
+
+		if updated.Before(a.PublishedAtTime) {
+			updated = a.PublishedAtTime
+		}
+
+		articleURL := siteURL + "/articles/" + a.Slug
+
+		// feed readers don't load the stylesheet of the site, so code blocks are left plain and headings without anchors.
+		// embeds are links there, since the script playing the videos doesn't run either
+		feed.Items = append(feed.Items, Item{
+			ID:          articleURL,
+			Title:       a.Title,
+			Link:        articleURL,
+			Summary:     a.Description,
+			ContentHTML: string(parsers.MarkdownToHTML([]byte(a.BodyMarkdown), feedMarkdownOptions)),
+			Tags:        a.TagList,
+			Published:   a.PublishedAtTime,
+			Updated:     updated,
+		})
+
+		if updated.After(feed.Updated) {
+			feed.Updated = updated
+		}
+	}
+
+	return feed
+}
@@ -0,0 +1,176 @@
+package feeds
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samluiz/blog/api/types"
+)
+
+var (
+	january  = time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC)
+	february = time.Date(2024, time.February, 1, 10, 0, 0, 0, time.UTC)
+	march    = time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+)
+
+var me = Author{Name: "me", URL: "https://github.com/me", Avatar: "https://example.com/me.png"}
+
+func testArticles() []types.ArticleResponse {
+	return []types.ArticleResponse{
+		{Title: "Go & SQL", Slug: "go-sql", Description: "about go", TagList: []string{"go", "sql"}, BodyMarkdown: "# Hello", PublishedAtTime: february, UpdatedAtTime: march},
+		{Title: "Web", Slug: "web", TagList: []string{" Web"}, BodyMarkdown: "*web*", PublishedAtTime: january},
+	}
+}
+
+func TestNew(t *testing.T) {
+	feed := New("blog", "desc", "https://example.com/", "https://example.com/feed.xml", me, testArticles())
+
+	if !feed.Updated.Equal(march) {
+		t.Errorf("expected the feed to be updated at the newest change, got %v", feed.Updated)
+	}
+
+	first := feed.Items[0]
+
+	if first.Link != "https://example.com/articles/go-sql" || first.ID != first.Link {
+		t.Errorf("expected an absolute link as id, got %q and %q", first.Link, first.ID)
+	}
+
+	if !strings.Contains(first.ContentHTML, "<h1") {
+		t.Errorf("expected the markdown to be rendered, got %q", first.ContentHTML)
+	}
+
+	if second := feed.Items[1]; !second.Updated.Equal(january) {
+		t.Errorf("expected never updated articles to use the publish date, got %v", second.Updated)
+	}
+}
+
+func TestRSS(t *testing.T) {
+	feed := New("blog", "desc", "https://example.com/", "https://example.com/feed.xml", me, testArticles())
+	feed.Items[0].ContentHTML = "<p>some ]]> text</p>"
+
+	body, err := feed.RSS()
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var parsed struct {
+		Version string `xml:"version,attr"`
+		Channel struct {
+			Title string `xml:"title"`
+			Items []struct {
+				Title      string   `xml:"title"`
+				GUID       string   `xml:"guid"`
+				PubDate    string   `xml:"pubDate"`
+				Content    string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+				Categories []string `xml:"category"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+
+	if err := xml.Unmarshal(body, &parsed); err != nil {
+		t.Fatalf("expected valid xml, got %v:\n%s", err, body)
+	}
+
+	if parsed.Version != "2.0" || len(parsed.Channel.Items) != 2 {
+		t.Fatalf("expected an rss 2.0 feed with 2 items, got %+v", parsed)
+	}
+
+	item := parsed.Channel.Items[0]
+
+	if item.Title != "Go & SQL" || item.GUID != "https://example.com/articles/go-sql" || len(item.Categories) != 2 {
+		t.Errorf("unexpected item %+v", item)
+	}
+
+	if _, err := time.Parse(time.RFC1123Z, item.PubDate); err != nil {
+		t.Errorf("expected an rfc 822 date, got %q", item.PubDate)
+	}
+
+	if !strings.Contains(item.Content, "some ]]> text") {
+		t.Errorf("expected the html content to survive the cdata section, got %q", item.Content)
+	}
+}
+
+func TestAtom(t *testing.T) {
+	body, err := New("blog", "desc", "https://example.com/", "https://example.com/atom.xml", me, testArticles()).Atom()
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var parsed struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		Updated string   `xml:"updated"`
+		Author  string   `xml:"author>name"`
+		Entries []struct {
+			ID      string `xml:"id"`
+			Updated string `xml:"updated"`
+			Content struct {
+				Type  string `xml:"type,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"content"`
+		} `xml:"entry"`
+	}
+
+	if err := xml.Unmarshal(body, &parsed); err != nil {
+		t.Fatalf("expected valid xml, got %v:\n%s", err, body)
+	}
+
+	if parsed.Updated != "2024-03-01T10:00:00Z" || parsed.Author != "me" || len(parsed.Entries) != 2 {
+		t.Fatalf("unexpected feed %+v", parsed)
+	}
+
+	if entry := parsed.Entries[0]; entry.Content.Type != "html" || !strings.Contains(entry.Content.Value, "<h1") {
+		t.Errorf("expected escaped html content, got %+v", entry.Content)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	body, err := New("blog", "desc", "https://example.com/", "https://example.com/feed.json", me, testArticles()).JSON()
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var parsed struct {
+		Version string `json:"version"`
+		FeedURL string `json:"feed_url"`
+		Authors []struct {
+			Name   string `json:"name"`
+			URL    string `json:"url"`
+			Avatar string `json:"avatar"`
+		} `json:"authors"`
+		Items []struct {
+			ID            string   `json:"id"`
+			ContentHTML   string   `json:"content_html"`
+			DatePublished string   `json:"date_published"`
+			DateModified  string   `json:"date_modified"`
+			Tags          []string `json:"tags"`
+		} `json:"items"`
+	}
+
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		t.Fatalf("expected valid json, got %v", err)
+	}
+
+	if parsed.Version != JSON_FEED_VERSION || parsed.FeedURL != "https://example.com/feed.json" {
+		t.Errorf("unexpected feed %+v", parsed)
+	}
+
+	if len(parsed.Authors) != 1 || parsed.Authors[0].Avatar != me.Avatar || parsed.Authors[0].URL != me.URL {
+		t.Errorf("expected the author with avatar and url, got %+v", parsed.Authors)
+	}
+
+	if len(parsed.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(parsed.Items))
+	}
+
+	item := parsed.Items[0]
+
+	if item.DatePublished != "2024-02-01T10:00:00Z" || item.DateModified != "2024-03-01T10:00:00Z" || len(item.Tags) != 2 || !strings.Contains(item.ContentHTML, "<h1") {
+		t.Errorf("unexpected item %+v", item)
+	}
+}
@@ -0,0 +1,78 @@
+package feeds
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const JSON_FEED_VERSION = "https://jsonfeed.org/version/1.1"
+
+// https://www.jsonfeed.org/version/1.1/
+type jsonFeed struct {
+	Version     string       `json:"version"`
+	Title       string       `json:"title"`
+	HomePageURL string       `json:"home_page_url"`
+	FeedURL     string       `json:"feed_url"`
+	Description string       `json:"description,omitempty"`
+	Icon        string       `json:"icon,omitempty"`
+	Language    string       `json:"language"`
+	Authors     []jsonAuthor `json:"authors"`
+	Items       []jsonItem   `json:"items"`
+}
+
+type jsonAuthor struct {
+	Name   string `json:"name"`
+	URL    string `json:"url,omitempty"`
+	Avatar string `json:"avatar,omitempty"`
+}
+
+type jsonItem struct {
+	ID            string       `json:"id"`
+	URL           string       `json:"url"`
+	Title         string       `json:"title"`
+	ContentHTML   string       `json:"content_html"`
+	Summary       string       `json:"summary,omitempty"`
+	DatePublished string       `json:"date_published"`
+	DateModified  string       `json:"date_modified"`
+	Tags          []string     `json:"tags"`
+	Authors       []jsonAuthor `json:"authors"`
+}
+
+// encodes the feed as json feed 1.1
+func (f Feed) JSON() ([]byte, error) {
+	authors := []jsonAuthor{{Name: f.Author.Name, URL: f.Author.URL, Avatar: f.Author.Avatar}}
+
+	feed := jsonFeed{
+		Version:     JSON_FEED_VERSION,
+		Title:       f.Title,
+		HomePageURL: f.Link,
+		FeedURL:     f.FeedURL,
+		Description: f.Description,
+		Icon:        f.Author.Avatar,
+		Language:    "en",
+		Authors:     authors,
+		Items:       make([]jsonItem, 0, len(f.Items)),
+	}
+
+	for _, item := range f.Items {
+		tags := item.Tags
+
+		if tags == nil {
+			tags = []string{}
+		}
+
+		feed.Items = append(feed.Items, jsonItem{
+			ID:            item.ID,
+			URL:           item.Link,
+			Title:         item.Title,
+			ContentHTML:   item.ContentHTML,
+			Summary:       item.Summary,
+			DatePublished: item.Published.UTC().Format(time.RFC3339),
+			DateModified:  item.Updated.UTC().Format(time.RFC3339),
+			Tags:          tags,
+			Authors:       authors,
+		})
+	}
+
+	return json.MarshalIndent(feed, "", "  ")
+}
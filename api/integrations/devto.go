@@ -15,6 +15,8 @@ import (
 const DEV_TO_API_BASE_URL = "https://dev.to/api"
 const DEV_TO_USERNAME = "samluiz"
 
+var ErrDevToArticleNotFound = errors.New("article not found on dev.to")
+
 func GetArticleBySlugDevTo(slug string) (*types.ArticleResponse, error) {
 	var getArticleResponse types.GetArticleByPathResponse
 	var articleResponse types.ArticleResponse
@@ -27,6 +29,10 @@ func GetArticleBySlugDevTo(slug string) (*types.ArticleResponse, error) {
 
 	log.Default().Printf("Status: %v", status)
 
+	if status == 404 {
+		return nil, ErrDevToArticleNotFound
+	}
+
 	if (status != 200) || (err != nil) {
 		return nil, errors.New("error getting article from dev.to: " + string(response))
 	}
@@ -36,14 +42,13 @@ func GetArticleBySlugDevTo(slug string) (*types.ArticleResponse, error) {
 		return nil, jsonErr
 	}
 
-	getArticleResponse.PublishedAt = date.FormatDate(getArticleResponse.PublishedAt)
-	articleResponse = types.ArticleResponse(getArticleResponse)
+	articleResponse = toArticleResponse(types.GetArticlesResponse(getArticleResponse))
 
 	return &articleResponse, nil
 }
 
 func GetArticlesFromDevTo(page, perPage int) ([]types.ArticleResponse, error) {
-	var articles []types.GetArticleByPathResponse
+	var articles []types.GetArticlesResponse
 	articlesResponse := make([]types.ArticleResponse, len(articles))
 
 	log.Default().Println("getting articles from dev.to")
@@ -66,9 +71,76 @@ func GetArticlesFromDevTo(page, perPage int) ([]types.ArticleResponse, error) {
 	}
 
 	for _, a := range articles {
-		a.PublishedAt = date.FormatDate(a.PublishedAt)
-		articlesResponse = append(articlesResponse, types.ArticleResponse(a))
+		articlesResponse = append(articlesResponse, toArticleResponse(a))
 	}
 
 	return articlesResponse, nil
 }
+
+// creates a published article on dev.to, returning its id and url
+func CreateDevToArticle(article types.DevToArticleBody) (*types.DevToArticleResponse, error) {
+	log.Default().Println("creating article on dev.to")
+
+	request := fiber.Post(DEV_TO_API_BASE_URL + "/articles")
+
+	return sendDevToArticle(request, article)
+}
+
+func UpdateDevToArticle(id int, article types.DevToArticleBody) (*types.DevToArticleResponse, error) {
+	log.Default().Printf("updating article %d on dev.to", id)
+
+	request := fiber.Put(fmt.Sprintf("%s/articles/%d", DEV_TO_API_BASE_URL, id))
+
+	return sendDevToArticle(request, article)
+}
+
+func sendDevToArticle(request *fiber.Agent, article types.DevToArticleBody) (*types.DevToArticleResponse, error) {
+	var articleResponse types.DevToArticleResponse
+
+	request.Set("api-key", os.Getenv("DEV_TO_API_KEY"))
+	request.Set("Accept", "application/vnd.forem.api-v1+json")
+	request.JSON(types.DevToArticleRequest{Article: article})
+
+	status, response, errs := request.Bytes()
+
+	log.Default().Printf("Status: %v", status)
+
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+
+	if status != 200 && status != 201 {
+		return nil, fmt.Errorf("dev.to answered with status %d: %s", status, string(response))
+	}
+
+	jsonErr := json.Unmarshal(response, &articleResponse)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	return &articleResponse, nil
+}
+
+func toArticleResponse(a types.GetArticlesResponse) types.ArticleResponse {
+	publishedAt := date.ParseDate(a.PublishedAt)
+	updatedAt := publishedAt
+
+	if a.EditedAt != "" {
+		updatedAt = date.ParseDate(a.EditedAt)
+	}
+
+	return types.ArticleResponse{
+		ID:                 a.ID,
+		Title:              a.Title,
+		Description:        a.Description,
+		Slug:               a.Slug,
+		TagList:            a.TagList,
+		PublishedAt:        date.FormatTime(publishedAt),
+		PublishedAtTime:    publishedAt,
+		UpdatedAtTime:      updatedAt,
+		ReadingTimeMinutes: a.ReadingTimeMinutes,
+		BodyMarkdown:       a.BodyMarkdown,
+		Source:             types.SOURCE_DEVTO,
+		DevToID:            a.ID,
+	}
+}
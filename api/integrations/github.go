@@ -76,10 +76,11 @@ func GetGithubAuthUserInfo(accessToken string) (*types.GithubUserResponse, error
 	return &githubUserResponse, nil
 }
 
-func GetGithubBio(user string) (string, error) {
+// public profile of the github user, which the site shows as the author of the blog
+func GetGithubProfile(user string) (*types.GithubUserResponse, error) {
 	var githubUserResponse types.GithubUserResponse
 
-	log.Default().Println("getting github description...")
+	log.Default().Println("getting github profile...")
 
 	request := fiber.Get(GITHUB_API_BASE_URL + "/users/" + user)
 	request.Request().Header.Set("Accept", "application/vnd.github+json")
@@ -87,14 +88,14 @@ func GetGithubBio(user string) (string, error) {
 	status, response, err := request.Bytes()
 
 	if (status != 200) || (err != nil) {
-		return "", errors.New("error getting user info from github: " + string(response))
+		return nil, errors.New("error getting user info from github: " + string(response))
 	}
 
 	jsonErr := json.Unmarshal(response, &githubUserResponse)
 
 	if jsonErr != nil {
-		return "", jsonErr
+		return nil, jsonErr
 	}
 
-	return githubUserResponse.Bio, nil
+	return &githubUserResponse, nil
 }
@@ -0,0 +1,222 @@
+package jobs
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samluiz/blog/api/content"
+	"github.com/samluiz/blog/api/integrations"
+	apiTypes "github.com/samluiz/blog/api/types"
+	articleUtils "github.com/samluiz/blog/common/article"
+	"github.com/samluiz/blog/common/cache"
+	"github.com/samluiz/blog/pkg/article"
+	"github.com/samluiz/blog/pkg/types"
+)
+
+const (
+	CROSSPOST_DESCRIPTION_LENGTH = 160
+	CROSSPOST_LINK_RETRIES       = 3
+	CROSSPOST_LINK_BACKOFF       = 500 * time.Millisecond
+)
+
+// dev.to only accepts lowercase alphanumeric tags
+var invalidDevToTagChars = regexp.MustCompile(`[^a-z0-9]`)
+
+// publishes native articles on dev.to, retrying the failed ones in the background
+type CrossPoster interface {
+	Enqueue(articleId int) error
+	RetryPending()
+	Start(interval time.Duration)
+	Stop()
+}
+
+type crossPoster struct {
+	articleService article.Service
+	cache          *cache.Cache
+	siteURL        string
+	maxAttempts    int
+	mu             sync.Mutex
+	inFlight       map[int]bool
+	// dev.to posts created for articles whose link couldn't be saved, so retries update them instead of creating duplicates
+	unlinked map[int]*apiTypes.DevToArticleResponse
+	stop     chan struct{}
+}
+
+func NewCrossPoster(articleService article.Service, cache *cache.Cache, siteURL string, maxAttempts int) CrossPoster {
+	return &crossPoster{
+		articleService: articleService,
+		cache:          cache,
+		siteURL:        siteURL,
+		maxAttempts:    maxAttempts,
+		inFlight:       map[int]bool{},
+		unlinked:       map[int]*apiTypes.DevToArticleResponse{},
+	}
+}
+
+// marks the article to be cross posted and tries to do it right away, without blocking the caller.
+// drafts and imported articles are refused with types.ErrNotPublished and types.ErrImportedArticle
+func (p *crossPoster) Enqueue(articleId int) error {
+	if err := p.articleService.SetCrossPostPending(articleId); err != nil {
+		return err
+	}
+
+	go p.crossPost(articleId)
+
+	return nil
+}
+
+func (p *crossPoster) RetryPending() {
+	articles, err := p.articleService.FindPendingCrossPosts(p.maxAttempts)
+
+	if err != nil {
+		LOGGER.Error("error finding pending cross posts: %v", err)
+		return
+	}
+
+	for _, a := range articles {
+		p.crossPost(a.ID)
+	}
+}
+
+func (p *crossPoster) Start(interval time.Duration) {
+	if p.stop != nil {
+		return
+	}
+
+	p.stop = make(chan struct{})
+
+	go every(interval, p.stop, p.RetryPending)
+}
+
+func (p *crossPoster) Stop() {
+	if p.stop != nil {
+		close(p.stop)
+		p.stop = nil
+	}
+}
+
+func (p *crossPoster) crossPost(articleId int) {
+	p.mu.Lock()
+
+	if p.inFlight[articleId] {
+		p.mu.Unlock()
+		return
+	}
+
+	p.inFlight[articleId] = true
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		delete(p.inFlight, articleId)
+		p.mu.Unlock()
+	}()
+
+	a, err := p.articleService.FindArticleById(articleId)
+
+	if err != nil {
+		LOGGER.Error("error finding article %d to cross post: %v", articleId, err)
+		return
+	}
+
+	// the article may have been unpublished since it was enqueued
+	if a.Source != types.SOURCE_LOCAL || !a.IsPublished || a.DeletedAt != nil {
+		LOGGER.Info("skipping cross post of article %d, it's no longer a published native article", articleId)
+		return
+	}
+
+	body := apiTypes.DevToArticleBody{
+		Title:        a.Title,
+		BodyMarkdown: a.Content,
+		Published:    true,
+		Tags:         devToTags(a.Tags),
+		CanonicalURL: p.siteURL + "/articles/" + a.Slug,
+		Description:  a.Description,
+	}
+
+	if body.Description == "" {
+		body.Description = articleUtils.Excerpt(a.Content, CROSSPOST_DESCRIPTION_LENGTH)
+	}
+
+	devToId := a.DevToID
+
+	p.mu.Lock()
+	if created, ok := p.unlinked[articleId]; ok && devToId == nil {
+		devToId = &created.ID
+	}
+	p.mu.Unlock()
+
+	var response *apiTypes.DevToArticleResponse
+
+	if devToId != nil {
+		response, err = integrations.UpdateDevToArticle(*devToId, body)
+	} else {
+		response, err = integrations.CreateDevToArticle(body)
+	}
+
+	if err != nil {
+		LOGGER.Error("error cross posting article %d: %v", articleId, err)
+
+		if err := p.articleService.MarkCrossPostFailed(articleId, err.Error()); err != nil {
+			LOGGER.Error("error saving the cross post failure of article %d: %v", articleId, err)
+		}
+		return
+	}
+
+	if a.DevToID == nil {
+		if err := p.link(articleId, response); err != nil {
+			LOGGER.Error("error saving dev.to post %d of article %d: %v", response.ID, articleId, err)
+			return
+		}
+	}
+
+	if err := p.articleService.MarkCrossPosted(articleId); err != nil {
+		LOGGER.Error("error saving the cross post of article %d: %v", articleId, err)
+		return
+	}
+
+	// the cached local articles don't have the dev.to id yet, which the aggregator needs to drop the duplicated dev.to post
+	p.cache.InvalidatePrefix(content.CACHE_PREFIX + apiTypes.SOURCE_LOCAL)
+
+	LOGGER.Info("article %d cross posted to %s", articleId, response.URL)
+}
+
+// saves the id of a newly created dev.to post right away. if the database keeps failing,
+// the post is remembered in memory so the next attempt updates it instead of creating a duplicate
+func (p *crossPoster) link(articleId int, response *apiTypes.DevToArticleResponse) error {
+	var err error
+
+	for i := 0; i < CROSSPOST_LINK_RETRIES; i++ {
+		if i > 0 {
+			time.Sleep(CROSSPOST_LINK_BACKOFF * time.Duration(i))
+		}
+
+		if err = p.articleService.LinkDevToArticle(articleId, response.ID, response.URL); err == nil {
+			p.mu.Lock()
+			delete(p.unlinked, articleId)
+			p.mu.Unlock()
+			return nil
+		}
+	}
+
+	p.mu.Lock()
+	p.unlinked[articleId] = response
+	p.mu.Unlock()
+
+	return err
+}
+
+func devToTags(tags string) []string {
+	devToTags := []string{}
+
+	for _, tag := range strings.Split(tags, ",") {
+		tag = invalidDevToTagChars.ReplaceAllString(tag, "")
+		if tag != "" {
+			devToTags = append(devToTags, tag)
+		}
+	}
+
+	return devToTags
+}
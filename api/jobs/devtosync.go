@@ -0,0 +1,229 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samluiz/blog/api/content"
+	"github.com/samluiz/blog/api/integrations"
+	apiTypes "github.com/samluiz/blog/api/types"
+	"github.com/samluiz/blog/common/cache"
+	"github.com/samluiz/blog/pkg/article"
+	"github.com/samluiz/blog/pkg/types"
+)
+
+const DEV_TO_SYNC_PAGE_SIZE = 100
+
+var (
+	ErrSyncInProgress = errors.New("a dev.to sync is already running")
+	ErrNoSyncAuthor   = errors.New("there is no author to own the imported articles")
+	// returned with the result when some articles failed and the others were still synced
+	ErrSyncIncomplete = errors.New("some dev.to articles couldn't be synced")
+)
+
+type SyncResult struct {
+	Added     int           `json:"added"`
+	Updated   int           `json:"updated"`
+	Removed   int           `json:"removed"`
+	Unchanged int           `json:"unchanged"`
+	Failed    []SyncFailure `json:"failed"`
+}
+
+// an article the sync couldn't save. it's tried again on the next sync
+type SyncFailure struct {
+	DevToID int    `json:"devto_id"`
+	Slug    string `json:"slug"`
+	Error   string `json:"error"`
+}
+
+// copies the published dev.to articles into the local database
+type DevToSync interface {
+	Sync() (*SyncResult, error)
+	Start(interval time.Duration)
+	Stop()
+}
+
+type devToSync struct {
+	articleService article.Service
+	authorId       int
+	cache          *cache.Cache
+	// lists every published dev.to article. replaced in tests so they don't call dev.to
+	fetch   func() ([]apiTypes.ArticleResponse, error)
+	running sync.Mutex
+	stop    chan struct{}
+}
+
+func NewDevToSync(articleService article.Service, authorId int, cache *cache.Cache) DevToSync {
+	return &devToSync{articleService: articleService, authorId: authorId, cache: cache, fetch: fetchAllDevToArticles}
+}
+
+func (s *devToSync) Start(interval time.Duration) {
+	if s.stop != nil {
+		return
+	}
+
+	s.stop = make(chan struct{})
+
+	go every(interval, s.stop, func() {
+		if _, err := s.Sync(); err != nil {
+			LOGGER.Error("error syncing dev.to articles: %v", err)
+		}
+	})
+
+	LOGGER.Info("dev.to sync scheduled every %v", interval)
+}
+
+func (s *devToSync) Stop() {
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+func (s *devToSync) Sync() (*SyncResult, error) {
+	if !s.running.TryLock() {
+		return nil, ErrSyncInProgress
+	}
+	defer s.running.Unlock()
+
+	if s.authorId == 0 {
+		return nil, ErrNoSyncAuthor
+	}
+
+	remote, err := s.fetch()
+
+	if err != nil {
+		return nil, err
+	}
+
+	linked, err := s.articleService.FindDevToLinkedArticles()
+
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[int]*types.GetArticleOutput, len(linked))
+	crossPosted := make(map[int]bool)
+
+	for _, a := range linked {
+		// native articles cross posted to dev.to are owned by us, so the sync must never touch them
+		if a.Source == types.SOURCE_LOCAL {
+			crossPosted[*a.DevToID] = true
+			continue
+		}
+		existing[*a.DevToID] = a
+	}
+
+	result := &SyncResult{Failed: []SyncFailure{}}
+	seen := make(map[int]bool, len(remote))
+	var errs []error
+
+	// one bad article shouldn't keep the others from syncing
+	fail := func(devToId int, slug string, err error) {
+		LOGGER.Error("error syncing dev.to article %d: %v", devToId, err)
+		result.Failed = append(result.Failed, SyncFailure{DevToID: devToId, Slug: slug, Error: err.Error()})
+		errs = append(errs, fmt.Errorf("dev.to article %d: %w", devToId, err))
+	}
+
+	for _, r := range remote {
+		if crossPosted[r.ID] {
+			continue
+		}
+
+		seen[r.ID] = true
+
+		input := &types.ImportArticleInput{
+			DevToID:     r.ID,
+			Title:       r.Title,
+			Slug:        r.Slug,
+			Description: r.Description,
+			Content:     r.BodyMarkdown,
+			Tags:        r.TagList,
+			AuthorID:    s.authorId,
+			PublishedAt: r.PublishedAtTime,
+		}
+
+		local, ok := existing[r.ID]
+
+		if !ok {
+			if err := s.articleService.CreateImportedArticle(input); err != nil {
+				fail(r.ID, r.Slug, err)
+				continue
+			}
+			result.Added++
+			continue
+		}
+
+		if !hasChanged(local, input) {
+			result.Unchanged++
+			continue
+		}
+
+		if err := s.articleService.UpdateImportedArticle(local.ID, input); err != nil {
+			fail(r.ID, r.Slug, err)
+			continue
+		}
+		result.Updated++
+	}
+
+	for devToId, local := range existing {
+		if seen[devToId] || local.DeletedAt != nil {
+			continue
+		}
+
+		if err := s.articleService.SoftDeleteArticle(local.ID); err != nil {
+			fail(devToId, local.Slug, err)
+			continue
+		}
+		result.Removed++
+	}
+
+	if result.Added+result.Updated+result.Removed > 0 {
+		s.cache.InvalidatePrefix(content.CACHE_PREFIX)
+	}
+
+	LOGGER.Info("dev.to sync finished: %d added, %d updated, %d removed, %d failed", result.Added, result.Updated, result.Removed, len(result.Failed))
+
+	if len(errs) > 0 {
+		return result, errors.Join(append([]error{ErrSyncIncomplete}, errs...)...)
+	}
+
+	return result, nil
+}
+
+func fetchAllDevToArticles() ([]apiTypes.ArticleResponse, error) {
+	var all []apiTypes.ArticleResponse
+
+	for page := 1; ; page++ {
+		articles, err := integrations.GetArticlesFromDevTo(page, DEV_TO_SYNC_PAGE_SIZE)
+
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, articles...)
+
+		if len(articles) < DEV_TO_SYNC_PAGE_SIZE {
+			return all, nil
+		}
+	}
+}
+
+func hasChanged(local *types.GetArticleOutput, remote *types.ImportArticleInput) bool {
+	if local.DeletedAt != nil {
+		return true
+	}
+
+	if local.PublishedAt == nil || !local.PublishedAt.Equal(remote.PublishedAt) {
+		return true
+	}
+
+	return local.Title != remote.Title ||
+		local.Slug != remote.Slug ||
+		local.Description != remote.Description ||
+		local.Content != remote.Content ||
+		local.Tags != strings.Join(remote.Tags, ",")
+}
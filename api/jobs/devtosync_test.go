@@ -0,0 +1,310 @@
+package jobs
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	apiTypes "github.com/samluiz/blog/api/types"
+	"github.com/samluiz/blog/common/cache"
+	"github.com/samluiz/blog/internal/testdb"
+	"github.com/samluiz/blog/pkg/article"
+	"github.com/samluiz/blog/pkg/types"
+)
+
+func newTestArticleService(t *testing.T) (article.Service, int) {
+	t.Helper()
+
+	db, authorId := testdb.OpenWithAdmin(t)
+
+	return article.NewService(article.NewRepository(db)), authorId
+}
+
+func remoteArticle(id int, title string) apiTypes.ArticleResponse {
+	return apiTypes.ArticleResponse{
+		ID:              id,
+		Title:           title,
+		Slug:            "post-" + title,
+		Description:     "about " + title,
+		BodyMarkdown:    "# " + title,
+		TagList:         []string{"go", "web"},
+		PublishedAtTime: time.Date(2024, time.January, id, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func importedByDevToId(t *testing.T, articleService article.Service) map[int]*types.GetArticleOutput {
+	t.Helper()
+
+	linked, err := articleService.FindDevToLinkedArticles()
+
+	if err != nil {
+		t.Fatalf("error finding the imported articles: %v", err)
+	}
+
+	byId := map[int]*types.GetArticleOutput{}
+
+	for _, a := range linked {
+		byId[*a.DevToID] = a
+	}
+
+	return byId
+}
+
+func TestHasChanged(t *testing.T) {
+	published := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	deleted := published.Add(time.Hour)
+
+	local := func(change func(a *types.GetArticleOutput)) *types.GetArticleOutput {
+		a := &types.GetArticleOutput{
+			Title:       "title",
+			Slug:        "title-1",
+			Description: "description",
+			Content:     "content",
+			Tags:        "go,web",
+			PublishedAt: &published,
+		}
+
+		if change != nil {
+			change(a)
+		}
+
+		return a
+	}
+
+	remote := &types.ImportArticleInput{
+		Title:       "title",
+		Slug:        "title-1",
+		Description: "description",
+		Content:     "content",
+		Tags:        []string{"go", "web"},
+		PublishedAt: published,
+	}
+
+	tests := []struct {
+		name  string
+		local *types.GetArticleOutput
+		want  bool
+	}{
+		{name: "same article", local: local(nil), want: false},
+		{name: "title", local: local(func(a *types.GetArticleOutput) { a.Title = "old title" }), want: true},
+		{name: "slug", local: local(func(a *types.GetArticleOutput) { a.Slug = "title-2" }), want: true},
+		{name: "description", local: local(func(a *types.GetArticleOutput) { a.Description = "" }), want: true},
+		{name: "content", local: local(func(a *types.GetArticleOutput) { a.Content = "old content" }), want: true},
+		{name: "tags", local: local(func(a *types.GetArticleOutput) { a.Tags = "go" }), want: true},
+		{name: "tag order", local: local(func(a *types.GetArticleOutput) { a.Tags = "web,go" }), want: true},
+		{name: "publish date", local: local(func(a *types.GetArticleOutput) { p := published.Add(time.Minute); a.PublishedAt = &p }), want: true},
+		{name: "missing publish date", local: local(func(a *types.GetArticleOutput) { a.PublishedAt = nil }), want: true},
+		{name: "removed before", local: local(func(a *types.GetArticleOutput) { a.DeletedAt = &deleted }), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasChanged(tt.local, remote); got != tt.want {
+				t.Errorf("hasChanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSync(t *testing.T) {
+	articleService, authorId := newTestArticleService(t)
+
+	// a native article cross posted to dev.to shows up in the dev.to list too, and must be left alone
+	native, err := articleService.CreateArticle(&types.CreateArticleInput{Title: "native", Content: "mine", AuthorID: authorId, IsPublished: true})
+
+	if err != nil {
+		t.Fatalf("error creating the native article: %v", err)
+	}
+
+	if err := articleService.LinkDevToArticle(native.ID, 99, "https://dev.to/admin/native"); err != nil {
+		t.Fatalf("error linking the native article: %v", err)
+	}
+
+	crossPosted := remoteArticle(99, "native changed on dev.to")
+
+	var remote []apiTypes.ArticleResponse
+
+	s := &devToSync{
+		articleService: articleService,
+		authorId:       authorId,
+		cache:          cache.New(time.Hour, 0),
+		fetch:          func() ([]apiTypes.ArticleResponse, error) { return remote, nil },
+	}
+
+	steps := []struct {
+		name    string
+		remote  []apiTypes.ArticleResponse
+		want    SyncResult
+		deleted []int
+	}{
+		{
+			name:   "imports new articles",
+			remote: []apiTypes.ArticleResponse{remoteArticle(1, "a"), remoteArticle(2, "b"), remoteArticle(3, "c"), crossPosted},
+			want:   SyncResult{Added: 3},
+		},
+		{
+			name:   "nothing changed",
+			remote: []apiTypes.ArticleResponse{remoteArticle(1, "a"), remoteArticle(2, "b"), remoteArticle(3, "c"), crossPosted},
+			want:   SyncResult{Unchanged: 3},
+		},
+		{
+			name:    "updates, adds and removes",
+			remote:  []apiTypes.ArticleResponse{remoteArticle(1, "a edited"), remoteArticle(2, "b"), remoteArticle(4, "d"), crossPosted},
+			want:    SyncResult{Added: 1, Updated: 1, Removed: 1, Unchanged: 1},
+			deleted: []int{3},
+		},
+		{
+			name:    "removed articles are only counted once",
+			remote:  []apiTypes.ArticleResponse{remoteArticle(1, "a edited"), remoteArticle(2, "b"), remoteArticle(4, "d")},
+			want:    SyncResult{Unchanged: 3},
+			deleted: []int{3},
+		},
+		{
+			name:   "restores articles published again",
+			remote: []apiTypes.ArticleResponse{remoteArticle(1, "a edited"), remoteArticle(2, "b"), remoteArticle(3, "c"), remoteArticle(4, "d")},
+			want:   SyncResult{Updated: 1, Unchanged: 3},
+		},
+	}
+
+	for _, step := range steps {
+		remote = step.remote
+
+		result, err := s.Sync()
+
+		if err != nil {
+			t.Fatalf("%s: Sync() error = %v", step.name, err)
+		}
+
+		step.want.Failed = []SyncFailure{}
+
+		if !reflect.DeepEqual(*result, step.want) {
+			t.Errorf("%s: Sync() = %+v, want %+v", step.name, *result, step.want)
+		}
+
+		imported := importedByDevToId(t, articleService)
+
+		for _, id := range step.deleted {
+			if imported[id] == nil || imported[id].DeletedAt == nil {
+				t.Errorf("%s: dev.to article %d wasn't soft deleted", step.name, id)
+			}
+		}
+	}
+
+	imported := importedByDevToId(t, articleService)
+
+	if got := imported[1].Title; got != "a edited" {
+		t.Errorf("updated title = %q, want %q", got, "a edited")
+	}
+
+	if got := imported[1].Tags; got != "go,web" {
+		t.Errorf("imported tags = %q, want %q", got, "go,web")
+	}
+
+	if got := imported[99]; got.Source != types.SOURCE_LOCAL || got.Title != "native" {
+		t.Errorf("cross posted article = %s from %s, want it untouched", got.Title, got.Source)
+	}
+}
+
+// fails to save one dev.to article, like a constraint the remote data breaks would
+type failingArticleService struct {
+	article.Service
+	devToId int
+}
+
+var errSave = errors.New("error saving the article")
+
+func (s *failingArticleService) CreateImportedArticle(input *types.ImportArticleInput) error {
+	if input.DevToID == s.devToId {
+		return errSave
+	}
+
+	return s.Service.CreateImportedArticle(input)
+}
+
+func TestSyncContinuesAfterFailures(t *testing.T) {
+	articleService, authorId := newTestArticleService(t)
+
+	s := &devToSync{
+		articleService: &failingArticleService{articleService, 2},
+		authorId:       authorId,
+		cache:          cache.New(time.Hour, 0),
+		fetch: func() ([]apiTypes.ArticleResponse, error) {
+			return []apiTypes.ArticleResponse{remoteArticle(1, "a"), remoteArticle(2, "b"), remoteArticle(3, "c")}, nil
+		},
+	}
+
+	result, err := s.Sync()
+
+	if !errors.Is(err, ErrSyncIncomplete) || !errors.Is(err, errSave) {
+		t.Errorf("Sync() error = %v, want %v and %v", err, ErrSyncIncomplete, errSave)
+	}
+
+	if result == nil || result.Added != 2 {
+		t.Fatalf("Sync() = %+v, want the other articles added", result)
+	}
+
+	if want := []SyncFailure{{DevToID: 2, Slug: "post-b", Error: errSave.Error()}}; !reflect.DeepEqual(result.Failed, want) {
+		t.Errorf("Failed = %+v, want %+v", result.Failed, want)
+	}
+
+	imported := importedByDevToId(t, articleService)
+
+	if imported[1] == nil || imported[2] != nil || imported[3] == nil {
+		t.Errorf("expected only the failing article to be missing, got %v", imported)
+	}
+}
+
+// imported articles mirror dev.to, so local changes would be overwritten or imported again by the next sync
+func TestImportedArticlesAreReadOnly(t *testing.T) {
+	articleService, authorId := newTestArticleService(t)
+
+	s := &devToSync{
+		articleService: articleService,
+		authorId:       authorId,
+		cache:          cache.New(time.Hour, 0),
+		fetch: func() ([]apiTypes.ArticleResponse, error) {
+			return []apiTypes.ArticleResponse{remoteArticle(1, "a")}, nil
+		},
+	}
+
+	if _, err := s.Sync(); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	id := importedByDevToId(t, articleService)[1].ID
+
+	tests := []struct {
+		name   string
+		change func() error
+	}{
+		{name: "update", change: func() error {
+			_, err := articleService.UpdateArticle(id, &types.UpdateArticleInput{Title: "local edit"})
+			return err
+		}},
+		{name: "unpublish", change: func() error {
+			_, err := articleService.PublishArticle(id, &types.PublishArticleInput{IsPublished: false})
+			return err
+		}},
+		{name: "delete", change: func() error { return articleService.DeleteArticle(id) }},
+		{name: "cross post", change: func() error { return articleService.SetCrossPostPending(id) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.change(); !errors.Is(err, types.ErrImportedArticle) {
+				t.Errorf("error = %v, want %v", err, types.ErrImportedArticle)
+			}
+		})
+	}
+}
+
+func TestSyncWithoutAuthor(t *testing.T) {
+	articleService, _ := newTestArticleService(t)
+
+	s := NewDevToSync(articleService, 0, cache.New(time.Hour, 0))
+
+	if _, err := s.Sync(); !errors.Is(err, ErrNoSyncAuthor) {
+		t.Errorf("Sync() error = %v, want %v", err, ErrNoSyncAuthor)
+	}
+}
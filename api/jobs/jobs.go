@@ -0,0 +1,25 @@
+package jobs
+
+import (
+	"os"
+	"time"
+
+	"github.com/samluiz/blog/common/logger"
+)
+
+var LOGGER = logger.New(os.Stdout, logger.DebugLevel, "[JOBS]")
+
+// runs the job every interval until the stop channel is closed
+func every(interval time.Duration, stop <-chan struct{}, job func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			job()
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package jobs
+
+import (
+	"sync"
+	"time"
+
+	"github.com/samluiz/blog/api/content"
+	apiTypes "github.com/samluiz/blog/api/types"
+	"github.com/samluiz/blog/common/cache"
+	"github.com/samluiz/blog/pkg/article"
+)
+
+// publishes the drafts scheduled by the admin once their time comes
+type Publisher interface {
+	PublishDue() int
+	Start(interval time.Duration)
+	Stop()
+}
+
+type publisher struct {
+	articleService article.Service
+	cache          *cache.Cache
+	mu             sync.Mutex
+	stop           chan struct{}
+	now            func() time.Time
+}
+
+func NewPublisher(articleService article.Service, cache *cache.Cache) Publisher {
+	return &publisher{
+		articleService: articleService,
+		cache:          cache,
+		now:            time.Now,
+	}
+}
+
+// publishes every draft whose time has come, returning how many it published. each article is only published
+// if it's still scheduled for the time that was read, so runs overlapping here or in other instances don't publish it twice
+func (p *publisher) PublishDue() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	articles, err := p.articleService.FindScheduledArticles()
+
+	if err != nil {
+		LOGGER.Error("error finding scheduled articles: %v", err)
+		return 0
+	}
+
+	now := p.now()
+	published := 0
+
+	for _, a := range articles {
+		if a.PublishAt == nil || a.PublishAt.After(now) {
+			continue
+		}
+
+		ok, err := p.articleService.PublishScheduledArticle(a.ID, *a.PublishAt)
+
+		if err != nil {
+			LOGGER.Error("error publishing scheduled article %d: %v", a.ID, err)
+			continue
+		}
+
+		if ok {
+			published++
+			LOGGER.Info("scheduled article %d published", a.ID)
+		}
+	}
+
+	if published > 0 {
+		p.cache.InvalidatePrefix(content.CACHE_PREFIX + apiTypes.SOURCE_LOCAL)
+	}
+
+	return published
+}
+
+// publishes what came due while the server was down right away, then checks every interval
+func (p *publisher) Start(interval time.Duration) {
+	if p.stop != nil {
+		return
+	}
+
+	p.stop = make(chan struct{})
+
+	go func(stop <-chan struct{}) {
+		p.PublishDue()
+		every(interval, stop, func() { p.PublishDue() })
+	}(p.stop)
+}
+
+func (p *publisher) Stop() {
+	if p.stop != nil {
+		close(p.stop)
+		p.stop = nil
+	}
+}
@@ -0,0 +1,165 @@
+package jobs
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/samluiz/blog/common/cache"
+	"github.com/samluiz/blog/pkg/article"
+	"github.com/samluiz/blog/pkg/types"
+)
+
+func newTestPublisher(articleService article.Service, now time.Time) *publisher {
+	return &publisher{
+		articleService: articleService,
+		cache:          cache.New(time.Hour, 0),
+		now:            func() time.Time { return now },
+	}
+}
+
+func scheduleDraft(t *testing.T, articleService article.Service, authorId int, title string, publishAt time.Time) *types.GetArticleOutput {
+	t.Helper()
+
+	draft, err := articleService.CreateArticle(&types.CreateArticleInput{Title: title, Content: title, AuthorID: authorId})
+
+	if err != nil {
+		t.Fatalf("CreateArticle() error = %v", err)
+	}
+
+	scheduled, err := articleService.ScheduleArticle(draft.ID, &publishAt)
+
+	if err != nil {
+		t.Fatalf("ScheduleArticle() error = %v", err)
+	}
+
+	return scheduled
+}
+
+func TestPublisherPublishesDueArticlesOnce(t *testing.T) {
+	articleService, authorId := newTestArticleService(t)
+
+	now := time.Now()
+	due := scheduleDraft(t, articleService, authorId, "due", now.Add(time.Hour))
+	later := scheduleDraft(t, articleService, authorId, "later", now.Add(3*time.Hour))
+
+	p := newTestPublisher(articleService, now.Add(2*time.Hour))
+
+	if published := p.PublishDue(); published != 1 {
+		t.Fatalf("PublishDue() = %d, want 1", published)
+	}
+
+	if published := p.PublishDue(); published != 0 {
+		t.Errorf("second PublishDue() = %d, want nothing left to publish", published)
+	}
+
+	got, _ := articleService.FindArticleById(due.ID)
+
+	if !got.IsPublished || got.Visibility != types.PUBLIC || got.PublishedAt == nil || got.PublishAt != nil {
+		t.Errorf("due article = published %v, visibility %s, published at %v, publish at %v, want it public and unscheduled", got.IsPublished, got.Visibility, got.PublishedAt, got.PublishAt)
+	}
+
+	got, _ = articleService.FindArticleById(later.ID)
+
+	if got.IsPublished || got.PublishAt == nil {
+		t.Errorf("expected the later article to still be a scheduled draft")
+	}
+}
+
+func TestPublisherRunsConcurrently(t *testing.T) {
+	articleService, authorId := newTestArticleService(t)
+
+	now := time.Now()
+
+	for _, title := range []string{"a", "b", "c"} {
+		scheduleDraft(t, articleService, authorId, title, now.Add(time.Minute))
+	}
+
+	// two instances of the app running the job at the same time
+	publishers := []*publisher{newTestPublisher(articleService, now.Add(time.Hour)), newTestPublisher(articleService, now.Add(time.Hour))}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	total := 0
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func(p *publisher) {
+			defer wg.Done()
+
+			n := p.PublishDue()
+
+			mu.Lock()
+			total += n
+			mu.Unlock()
+		}(publishers[i%2])
+	}
+
+	wg.Wait()
+
+	if total != 3 {
+		t.Errorf("published %d times, want each of the 3 articles published once", total)
+	}
+}
+
+func TestPublishScheduledArticleSkipsChangedSchedules(t *testing.T) {
+	articleService, authorId := newTestArticleService(t)
+
+	now := time.Now()
+	rescheduled := scheduleDraft(t, articleService, authorId, "rescheduled", now.Add(time.Hour))
+	cancelled := scheduleDraft(t, articleService, authorId, "cancelled", now.Add(time.Hour))
+
+	// the job read both articles, then the admin changed them before it published
+	if _, err := articleService.ScheduleArticle(rescheduled.ID, timePointer(now.Add(24*time.Hour))); err != nil {
+		t.Fatalf("ScheduleArticle() error = %v", err)
+	}
+
+	if _, err := articleService.ScheduleArticle(cancelled.ID, nil); err != nil {
+		t.Fatalf("ScheduleArticle() error = %v", err)
+	}
+
+	for _, a := range []*types.GetArticleOutput{rescheduled, cancelled} {
+		if ok, err := articleService.PublishScheduledArticle(a.ID, *a.PublishAt); ok || err != nil {
+			t.Errorf("PublishScheduledArticle(%q) = %v, %v, want it left as a draft", a.Title, ok, err)
+		}
+	}
+
+	if published := newTestPublisher(articleService, now.Add(2*time.Hour)).PublishDue(); published != 0 {
+		t.Errorf("PublishDue() = %d, want the rescheduled article to wait for its new time", published)
+	}
+}
+
+func TestScheduleArticleErrors(t *testing.T) {
+	articleService, authorId := newTestArticleService(t)
+
+	published, err := articleService.CreateArticle(&types.CreateArticleInput{Title: "published", Content: "x", AuthorID: authorId, IsPublished: true})
+
+	if err != nil {
+		t.Fatalf("CreateArticle() error = %v", err)
+	}
+
+	if _, err := articleService.ScheduleArticle(published.ID, timePointer(time.Now().Add(time.Hour))); !errors.Is(err, types.ErrAlreadyPublished) {
+		t.Errorf("error = %v, want %v", err, types.ErrAlreadyPublished)
+	}
+
+	if _, err := articleService.ScheduleArticle(published.ID, timePointer(time.Now().Add(-time.Hour))); !errors.Is(err, types.ErrScheduleInPast) {
+		t.Errorf("error = %v, want %v", err, types.ErrScheduleInPast)
+	}
+
+	draft := scheduleDraft(t, articleService, authorId, "draft", time.Now().Add(time.Hour))
+
+	// publishing by hand replaces the schedule
+	if _, err := articleService.PublishArticle(draft.ID, &types.PublishArticleInput{IsPublished: true}); err != nil {
+		t.Fatalf("PublishArticle() error = %v", err)
+	}
+
+	if got, _ := articleService.FindArticleById(draft.ID); got.PublishAt != nil {
+		t.Errorf("publish at = %v, want it cleared when published by hand", got.PublishAt)
+	}
+}
+
+func timePointer(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,111 @@
+package jobs
+
+import (
+	"sync"
+	"time"
+
+	"github.com/samluiz/blog/pkg/article"
+	"github.com/samluiz/blog/pkg/comment"
+)
+
+// deletes for good the articles and comments that stayed in the trash longer than the retention
+type TrashPurger interface {
+	PurgeExpired() (int, int)
+	Start(interval time.Duration)
+	Stop()
+}
+
+type trashPurger struct {
+	articleService article.Service
+	commentService comment.Service
+	retention      time.Duration
+	mu             sync.Mutex
+	stop           chan struct{}
+	now            func() time.Time
+}
+
+func NewTrashPurger(articleService article.Service, commentService comment.Service, retention time.Duration) TrashPurger {
+	return &trashPurger{
+		articleService: articleService,
+		commentService: commentService,
+		retention:      retention,
+		now:            time.Now,
+	}
+}
+
+// returns how many articles and comments were purged. what was restored since it was read is kept.
+// trashed articles aren't listed anywhere, so nothing is cached for them
+func (p *trashPurger) PurgeExpired() (int, int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cutoff := p.now().Add(-p.retention)
+
+	articles, comments := 0, 0
+
+	trashedArticles, err := p.articleService.FindTrashedArticles()
+
+	if err != nil {
+		LOGGER.Error("error finding trashed articles: %v", err)
+	}
+
+	for _, a := range trashedArticles {
+		if a.DeletedAt == nil || a.DeletedAt.After(cutoff) {
+			continue
+		}
+
+		purged, err := p.articleService.PurgeArticle(a.ID)
+
+		if err != nil {
+			LOGGER.Error("error purging article %d: %v", a.ID, err)
+		} else if purged {
+			articles++
+		}
+	}
+
+	trashedComments, err := p.commentService.FindTrashedComments()
+
+	if err != nil {
+		LOGGER.Error("error finding trashed comments: %v", err)
+	}
+
+	for _, c := range trashedComments {
+		if c.DeletedAt == nil || c.DeletedAt.After(cutoff) {
+			continue
+		}
+
+		purged, err := p.commentService.PurgeComment(c.ID)
+
+		if err != nil {
+			LOGGER.Error("error purging comment %d: %v", c.ID, err)
+		} else if purged {
+			comments++
+		}
+	}
+
+	if articles+comments > 0 {
+		LOGGER.Info("trash purged: %d articles and %d comments", articles, comments)
+	}
+
+	return articles, comments
+}
+
+func (p *trashPurger) Start(interval time.Duration) {
+	if p.stop != nil {
+		return
+	}
+
+	p.stop = make(chan struct{})
+
+	go func(stop <-chan struct{}) {
+		p.PurgeExpired()
+		every(interval, stop, func() { p.PurgeExpired() })
+	}(p.stop)
+}
+
+func (p *trashPurger) Stop() {
+	if p.stop != nil {
+		close(p.stop)
+		p.stop = nil
+	}
+}
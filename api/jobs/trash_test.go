@@ -0,0 +1,80 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/samluiz/blog/internal/testdb"
+	"github.com/samluiz/blog/pkg/article"
+	"github.com/samluiz/blog/pkg/comment"
+	"github.com/samluiz/blog/pkg/types"
+)
+
+func TestTrashPurgerPurgesExpiredItems(t *testing.T) {
+	db, authorId := testdb.OpenWithAdmin(t)
+
+	articleService := article.NewService(article.NewRepository(db))
+	commentService := comment.NewService(comment.NewRepository(db), comment.ModerationRules{})
+	admin := types.CommentActor{ID: authorId, Type: types.AUTHOR_USER, IsAdmin: true}
+
+	var articles []*types.GetArticleOutput
+
+	for _, title := range []string{"old", "recent", "kept"} {
+		a, err := articleService.CreateArticle(&types.CreateArticleInput{Title: title, Content: title, AuthorID: authorId})
+
+		if err != nil {
+			t.Fatalf("CreateArticle() error = %v", err)
+		}
+
+		articles = append(articles, a)
+	}
+
+	var comments []*types.Comment
+
+	for i := 0; i < 2; i++ {
+		c, err := commentService.CreateComment(&types.CreateCommentInput{Content: "hello", ArticleID: articles[2].ID, AuthorID: authorId, AuthorType: types.AUTHOR_USER})
+
+		if err != nil {
+			t.Fatalf("CreateComment() error = %v", err)
+		}
+
+		comments = append(comments, c)
+	}
+
+	if err := articleService.DeleteArticle(articles[0].ID); err != nil {
+		t.Fatalf("DeleteArticle() error = %v", err)
+	}
+
+	if err := commentService.DeleteComment(comments[0].ID, admin); err != nil {
+		t.Fatalf("DeleteComment() error = %v", err)
+	}
+
+	now := time.Now()
+	p := NewTrashPurger(articleService, commentService, time.Hour).(*trashPurger)
+	p.now = func() time.Time { return now.Add(2 * time.Hour) }
+
+	// trashed after the first ones, so still within the retention when the job runs
+	db.MustExec("UPDATE articles SET deleted_at = ? WHERE id = ?", now.Add(90*time.Minute).UTC(), articles[1].ID)
+
+	if a, c := p.PurgeExpired(); a != 1 || c != 1 {
+		t.Fatalf("PurgeExpired() = %d, %d, want 1, 1", a, c)
+	}
+
+	trashed, err := articleService.FindTrashedArticles()
+
+	if err != nil || len(trashed) != 1 || trashed[0].ID != articles[1].ID {
+		t.Errorf("expected the recently trashed article kept, got %+v, %v", trashed, err)
+	}
+
+	if _, err := articleService.FindArticleById(articles[2].ID); err != nil {
+		t.Errorf("FindArticleById() error = %v, want articles outside the trash kept", err)
+	}
+
+	if left, _ := commentService.FindCommentsByArticleId(articles[2].ID); len(left) != 1 || left[0].ID != comments[1].ID {
+		t.Errorf("expected only the comment outside the trash left, got %d", len(left))
+	}
+
+	if trashedComments, _ := commentService.FindTrashedComments(); len(trashedComments) != 0 {
+		t.Errorf("expected the comment trash to be empty, got %d", len(trashedComments))
+	}
+}
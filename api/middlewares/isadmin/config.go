@@ -0,0 +1,11 @@
+package isadmin
+
+import (
+	"github.com/gofiber/fiber/v2/middleware/session"
+)
+
+type Config struct {
+	Session *session.Store
+	// when true, denied requests get a json error body instead of a redirect to the login page
+	JSON bool
+}
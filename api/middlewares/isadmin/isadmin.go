@@ -0,0 +1,56 @@
+package isadmin
+
+import (
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/samluiz/blog/api/routes"
+	"github.com/samluiz/blog/api/types"
+)
+
+func New(config Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		session, err := config.Session.Get(c)
+
+		if err != nil {
+			log.Default().Printf("error retrieving the session: %v", err)
+			return deny(c, config, fiber.StatusUnauthorized)
+		}
+
+		isLogged := session.Get(routes.IS_LOGGED)
+
+		if isLogged == nil || isLogged == false {
+			return deny(c, config, fiber.StatusUnauthorized)
+		}
+
+		user, ok := session.Get("user").(types.SessionUser)
+
+		if !ok || !user.IsAdmin {
+			log.Default().Printf("user is not an admin. denying access to %v", c.Path())
+			return deny(c, config, fiber.StatusForbidden)
+		}
+
+		return c.Next()
+	}
+}
+
+func deny(c *fiber.Ctx, config Config, status int) error {
+	if config.JSON {
+		code := types.ERR_UNAUTHORIZED
+		if status == fiber.StatusForbidden {
+			code = types.ERR_FORBIDDEN
+		}
+		return c.Status(status).JSON(types.ErrorResponse{
+			Status:  status,
+			Code:    code,
+			Message: utils.StatusMessage(status),
+		})
+	}
+
+	if status == fiber.StatusForbidden {
+		return c.Redirect("/")
+	}
+
+	return c.Redirect("/auth/login?redirect=" + c.Path())
+}
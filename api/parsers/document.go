@@ -0,0 +1,130 @@
+package parsers
+
+import (
+	"html"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	mdhtml "github.com/gomarkdown/markdown/html"
+)
+
+type Document struct {
+	HTML []byte
+	// the headings, the ones under a heading are its children
+	TOC        []*Heading
+	WordCount  int
+	FirstImage string
+}
+
+type Heading struct {
+	Level    int
+	Text     string
+	ID       string
+	Children []*Heading
+}
+
+// the parser can give two headings the same id and the renderer only fixes it while writing the html.
+// they are made unique first, the same way the renderer would, so the table of contents links to the right ids
+func uniqueHeadingIDs(doc ast.Node) {
+	ids := mdhtml.NewRenderer(mdhtml.RendererOptions{})
+
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if heading, ok := node.(*ast.Heading); ok && entering && heading.HeadingID != "" {
+			heading.HeadingID = ids.EnsureUniqueHeadingID(heading.HeadingID)
+		}
+		return ast.GoToNext
+	})
+}
+
+// closes the heading the default renderer opened, with a link to it before the closing tag
+func renderHeadingAnchor(w io.Writer, heading *ast.Heading) {
+	level := strconv.Itoa(heading.Level)
+
+	io.WriteString(w, ` <a href="#`+html.EscapeString(heading.HeadingID)+`" class="heading-anchor" aria-label="link to this section">#</a></h`+level+">\n")
+}
+
+// headings deeper than the one before them are nested under it, skipped levels included
+func tableOfContents(doc ast.Node) []*Heading {
+	var toc []*Heading
+	var parents []*Heading
+
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		heading, ok := node.(*ast.Heading)
+
+		if !ok || !entering {
+			return ast.GoToNext
+		}
+
+		text := strings.Join(strings.Fields(plainText(heading)), " ")
+
+		if heading.HeadingID == "" || text == "" {
+			return ast.SkipChildren
+		}
+
+		h := &Heading{Level: heading.Level, Text: text, ID: heading.HeadingID}
+
+		for len(parents) > 0 && parents[len(parents)-1].Level >= h.Level {
+			parents = parents[:len(parents)-1]
+		}
+
+		if len(parents) == 0 {
+			toc = append(toc, h)
+		} else {
+			parent := parents[len(parents)-1]
+			parent.Children = append(parent.Children, h)
+		}
+
+		parents = append(parents, h)
+
+		return ast.SkipChildren
+	})
+
+	return toc
+}
+
+// text of the node without the markup, including inline code and without the alt text of images
+func plainText(node ast.Node) string {
+	var b strings.Builder
+
+	ast.WalkFunc(node, func(n ast.Node, entering bool) ast.WalkStatus {
+		switch n := n.(type) {
+		case *ast.Text:
+			b.Write(n.Literal)
+		case *ast.Code:
+			b.Write(n.Literal)
+		case *ast.Image:
+			// the alt text is for the ones who can't see the image, not part of the text
+			return ast.SkipChildren
+		case *ast.Paragraph, *ast.Heading, *ast.TableCell:
+			// the text of one block doesn't run into the next one
+			if !entering {
+				b.WriteByte(' ')
+			}
+		}
+
+		return ast.GoToNext
+	})
+
+	return b.String()
+}
+
+// words of the prose, code blocks and html are left out since they aren't read like text
+func countWords(doc ast.Node) int {
+	return len(strings.Fields(plainText(doc)))
+}
+
+func firstImage(doc ast.Node) string {
+	var image string
+
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if img, ok := node.(*ast.Image); ok && entering {
+			image = string(img.Destination)
+			return ast.Terminate
+		}
+		return ast.GoToNext
+	})
+
+	return image
+}
@@ -0,0 +1,94 @@
+package parsers
+
+import (
+	"strings"
+	"testing"
+)
+
+const tocMarkdown = `# Intro
+
+Some words about ` + "`code`" + ` here.
+
+## Setup
+
+### Install
+
+## Setup
+
+#### Deep
+
+# The *end*
+
+![cover](/static/cover.png) ![other](/static/other.png)
+
+` + "```go\nnot counted at all\n```\n"
+
+// flattens the table of contents to "level:id:text" lines, indented by depth
+func flattenTOC(toc []*Heading, depth int) []string {
+	var lines []string
+
+	for _, h := range toc {
+		lines = append(lines, strings.Repeat("  ", depth)+string(rune('0'+h.Level))+":"+h.ID+":"+h.Text)
+		lines = append(lines, flattenTOC(h.Children, depth+1)...)
+	}
+
+	return lines
+}
+
+func TestParseMarkdownTableOfContents(t *testing.T) {
+	doc := ParseMarkdown([]byte(tocMarkdown), Options{})
+
+	want := []string{
+		"1:intro:Intro",
+		"  2:setup:Setup",
+		"    3:install:Install",
+		"  2:setup-1:Setup",
+		"    4:deep:Deep",
+		"1:the-end:The end",
+	}
+
+	if got := flattenTOC(doc.TOC, 0); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("TOC =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// the ids of the table of contents are the ones the html links to
+	for _, id := range []string{`id="setup"`, `id="setup-1"`, `id="the-end"`} {
+		if !strings.Contains(string(doc.HTML), id) {
+			t.Errorf("expected %s in the html, got %s", id, doc.HTML)
+		}
+	}
+}
+
+func TestParseMarkdownWordCountAndImage(t *testing.T) {
+	doc := ParseMarkdown([]byte(tocMarkdown), Options{})
+
+	// headings and prose with inline code, without the code block
+	if doc.WordCount != 12 {
+		t.Errorf("WordCount = %d, want 12", doc.WordCount)
+	}
+
+	if doc.FirstImage != "/static/cover.png" {
+		t.Errorf("FirstImage = %q, want /static/cover.png", doc.FirstImage)
+	}
+
+	if doc := ParseMarkdown([]byte("no images"), Options{}); doc.FirstImage != "" {
+		t.Errorf("FirstImage = %q, want none", doc.FirstImage)
+	}
+}
+
+func TestParseMarkdownHeadingAnchors(t *testing.T) {
+	got := string(ParseMarkdown([]byte("## Setup\n\n## Setup\n"), Options{HeadingAnchors: true}).HTML)
+
+	for _, want := range []string{
+		`<h2 id="setup">Setup <a href="#setup" class="heading-anchor" aria-label="link to this section">#</a></h2>`,
+		`<h2 id="setup-1">Setup <a href="#setup-1" class="heading-anchor" aria-label="link to this section">#</a></h2>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %s in %s", want, got)
+		}
+	}
+
+	if got := string(MarkdownToHTML([]byte("## Setup\n"), Options{})); strings.Contains(got, "heading-anchor") {
+		t.Errorf("expected no anchors without the option, got %s", got)
+	}
+}
@@ -0,0 +1,163 @@
+package parsers
+
+import (
+	"bytes"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+)
+
+// syntax added on top of markdown, chosen per call with Options.Extensions
+type Extension int
+
+const (
+	// > [!NOTE] blockquotes rendered as callouts, like github does
+	EXTENSION_CALLOUTS Extension = 1 << iota
+	// {{< youtube id >}} and {{< gist user/id >}} on their own line
+	EXTENSION_EMBEDS
+	// text[^1] with [^1]: the note, listed at the end of the article
+	EXTENSION_FOOTNOTES
+
+	ALL_EXTENSIONS = EXTENSION_CALLOUTS | EXTENSION_EMBEDS | EXTENSION_FOOTNOTES
+)
+
+// blockquote with its kind on the first line, the rest of the line is an optional title in plain text
+type Callout struct {
+	ast.Container
+
+	Kind  string
+	Title string
+}
+
+// content of another site, rendered without loading anything from it until the reader asks for it
+type Embed struct {
+	ast.Leaf
+
+	Provider string
+	ID       string
+}
+
+var calloutMarker = regexp.MustCompile(`(?i)^ {0,3}> ?\[!(note|tip|important|warning|caution)\][ \t]*(.*?)[ \t]*$`)
+
+var quoteLine = regexp.MustCompile(`^ {0,3}> ?`)
+
+var shortcode = regexp.MustCompile(`^ {0,3}\{\{<[ \t]*([a-z]+)[ \t]+([^\s>]+)[ \t]*>\}\}[ \t]*$`)
+
+// ids each provider accepts. shortcodes with other ids are left as text
+var embedIDs = map[string]*regexp.Regexp{
+	"youtube": regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`),
+	"gist":    regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]{0,38}/[0-9a-f]{1,64}$`),
+}
+
+// parses the blocks of the enabled extensions, leaving everything else to the parser
+func extensionsHook(extensions Extension) parser.BlockFunc {
+	return func(data []byte) (ast.Node, []byte, int) {
+		if extensions&EXTENSION_EMBEDS != 0 {
+			if embed, consumed := parseEmbed(data); consumed > 0 {
+				return embed, nil, consumed
+			}
+		}
+
+		if extensions&EXTENSION_CALLOUTS != 0 {
+			if callout, content, consumed := parseCallout(data); consumed > 0 {
+				return callout, content, consumed
+			}
+		}
+
+		return nil, nil, 0
+	}
+}
+
+func parseEmbed(data []byte) (*Embed, int) {
+	line, consumed := firstLine(data)
+
+	m := shortcode.FindSubmatch(line)
+
+	if m == nil {
+		return nil, 0
+	}
+
+	provider, id := string(m[1]), string(m[2])
+
+	if valid, ok := embedIDs[provider]; !ok || !valid.MatchString(id) {
+		return nil, 0
+	}
+
+	return &Embed{Provider: provider, ID: id}, consumed
+}
+
+// the content of the callout is every quoted line after the marker, without the quote, parsed as markdown
+func parseCallout(data []byte) (*Callout, []byte, int) {
+	line, consumed := firstLine(data)
+
+	m := calloutMarker.FindSubmatch(line)
+
+	if m == nil {
+		return nil, nil, 0
+	}
+
+	callout := &Callout{Kind: strings.ToLower(string(m[1])), Title: string(m[2])}
+
+	content := []byte{}
+
+	for consumed < len(data) {
+		line, n := firstLine(data[consumed:])
+
+		prefix := quoteLine.Find(line)
+
+		if prefix == nil {
+			break
+		}
+
+		content = append(append(content, line[len(prefix):]...), '\n')
+		consumed += n
+	}
+
+	return callout, content, consumed
+}
+
+// the first line of data without the line break, and how long it is with it
+func firstLine(data []byte) ([]byte, int) {
+	if end := bytes.IndexByte(data, '\n'); end >= 0 {
+		return data[:end], end + 1
+	}
+
+	return data, len(data)
+}
+
+func renderCallout(w io.Writer, callout *Callout, entering bool) {
+	if !entering {
+		io.WriteString(w, "</div>\n")
+		return
+	}
+
+	title := callout.Title
+
+	if title == "" {
+		title = strings.ToUpper(callout.Kind[:1]) + callout.Kind[1:]
+	}
+
+	io.WriteString(w, `<div class="callout callout-`+callout.Kind+`" role="note">`+"\n")
+	io.WriteString(w, `<p class="callout-title">`+html.EscapeString(title)+"</p>\n")
+}
+
+// youtube videos are a link to the video until played, then static/js/embeds.js swaps it for a youtube-nocookie player.
+// gists are a link, their embed script writes into the page and tracks the reader
+func renderEmbed(w io.Writer, embed *Embed) {
+	id := html.EscapeString(embed.ID)
+
+	switch embed.Provider {
+	case "youtube":
+		io.WriteString(w, `<figure class="embed embed-youtube" data-youtube-id="`+id+`">`+"\n")
+		io.WriteString(w, `<a href="https://www.youtube.com/watch?v=`+id+`" target="_blank">Play the video from YouTube</a>`+"\n")
+	case "gist":
+		io.WriteString(w, `<figure class="embed embed-gist">`+"\n")
+		io.WriteString(w, `<a href="https://gist.github.com/`+id+`" target="_blank">View the gist `+id+` on GitHub</a>`+"\n")
+	}
+
+	io.WriteString(w, "</figure>\n")
+}
@@ -0,0 +1,93 @@
+package parsers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCallouts(t *testing.T) {
+	md := "> [!WARNING] Breaking change\n> be **careful**\n>\n> - a\n\n> [!tip]\n> short\n\n> plain quote\n"
+
+	got := string(MarkdownToHTML([]byte(md), Options{Extensions: EXTENSION_CALLOUTS}))
+
+	for _, want := range []string{
+		`<div class="callout callout-warning" role="note">` + "\n" + `<p class="callout-title">Breaking change</p>` + "\n" + `<p>be <strong>careful</strong></p>`,
+		"<li>a</li>\n</ul></div>",
+		`<div class="callout callout-tip" role="note">` + "\n" + `<p class="callout-title">Tip</p>` + "\n" + `<p>short</p>`,
+		"<blockquote>\n<p>plain quote</p>\n</blockquote>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in %s", want, got)
+		}
+	}
+
+	if got := string(MarkdownToHTML([]byte(md), Options{})); strings.Contains(got, "callout") {
+		t.Errorf("expected no callouts without the extension, got %s", got)
+	}
+}
+
+func TestEmbeds(t *testing.T) {
+	tests := []struct {
+		name string
+		md   string
+		want string
+	}{
+		{
+			name: "youtube",
+			md:   "{{< youtube dQw4w9WgXcQ >}}",
+			want: `<figure class="embed embed-youtube" data-youtube-id="dQw4w9WgXcQ">` + "\n" +
+				`<a href="https://www.youtube.com/watch?v=dQw4w9WgXcQ" target="_blank" rel="noreferrer noopener">Play the video from YouTube</a>` + "\n</figure>",
+		},
+		{
+			name: "gist",
+			md:   "{{<gist samluiz/0a1b2c>}}",
+			want: `<figure class="embed embed-gist">` + "\n" +
+				`<a href="https://gist.github.com/samluiz/0a1b2c" target="_blank" rel="noreferrer noopener">View the gist samluiz/0a1b2c on GitHub</a>` + "\n</figure>",
+		},
+		{
+			name: "invalid ids stay text",
+			md:   `{{< youtube x"onload=alert(1) >}}`,
+			want: `<p>{{&lt; youtube x”onload=alert(1) &gt;}}</p>`,
+		},
+		{
+			name: "unknown providers stay text",
+			md:   "{{< vimeo 123 >}}",
+			want: `<p>{{&lt; vimeo 123 &gt;}}</p>`,
+		},
+		{
+			name: "shortcodes in code blocks stay code",
+			md:   "```\n{{< youtube dQw4w9WgXcQ >}}\n```",
+			want: "<pre><code>{{&lt; youtube dQw4w9WgXcQ &gt;}}\n</code></pre>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(MarkdownToHTML([]byte(tt.md), Options{Extensions: EXTENSION_EMBEDS}))
+
+			if strings.TrimSpace(got) != tt.want {
+				t.Errorf("MarkdownToHTML() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFootnotes(t *testing.T) {
+	md := "A claim[^source].\n\n[^source]: the *source*\n"
+
+	got := string(MarkdownToHTML([]byte(md), Options{Extensions: EXTENSION_FOOTNOTES}))
+
+	for _, want := range []string{
+		`<sup class="footnote-ref" id="fnref:source"><a href="#fn:source">1</a></sup>`,
+		`<div class="footnotes">`,
+		`<li id="fn:source">the <em>source</em> <a class="footnote-return" href="#fnref:source">↩</a></li>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %s in %s", want, got)
+		}
+	}
+
+	if got := string(MarkdownToHTML([]byte(md), Options{})); strings.Contains(got, "footnote") {
+		t.Errorf("expected no footnotes without the extension, got %s", got)
+	}
+}
@@ -0,0 +1,199 @@
+package parsers
+
+import (
+	"bytes"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/gomarkdown/markdown/ast"
+	mdhtml "github.com/gomarkdown/markdown/html"
+)
+
+// prefix of every css class of the highlighted code, so they can't clash with the ones of the site
+const CLASS_PREFIX = "hl-"
+
+// opening fence with a line annotation, like ```go {3-5}
+var annotatedFence = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})[ \t]*([^\\s{`]*)[ \t]*\\{([^}\n]*)\\}[ \t]*$")
+
+var fenceLine = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+
+// the parser only accepts a single word or a {...} block after the fence, so annotated fences are rewritten
+// to ```{go {3-5} and the info string keeps both. fences inside code blocks are left alone
+func normalizeFences(md []byte) []byte {
+	lines := bytes.Split(md, []byte("\n"))
+	open := ""
+
+	for i, line := range lines {
+		fence := fenceLine.FindSubmatch(line)
+
+		if open != "" {
+			if fence != nil && fence[1][0] == open[0] && len(fence[1]) >= len(open) && len(bytes.TrimSpace(line[len(fence[0]):])) == 0 {
+				open = ""
+			}
+			continue
+		}
+
+		if fence == nil {
+			continue
+		}
+
+		open = string(fence[1])
+
+		if m := annotatedFence.FindSubmatch(line); m != nil {
+			lines[i] = []byte(string(m[1]) + string(m[2]) + "{" + string(m[3]) + " {" + string(m[4]) + "}")
+		}
+	}
+
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// renders fenced code blocks with highlighted tokens, leaving every other node to the default renderer
+func codeBlockHook(options Options) mdhtml.RenderNodeFunc {
+	theme := themeName(options.Theme)
+
+	return func(w io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+		block, ok := node.(*ast.CodeBlock)
+
+		if !ok || !block.IsFenced {
+			return ast.GoToNext, false
+		}
+
+		lang, marked := parseFenceInfo(string(block.Info))
+
+		io.WriteString(w, highlightCode(string(block.Literal), lang, theme, marked, options.LineNumbers))
+
+		return ast.GoToNext, true
+	}
+}
+
+// splits the info string of a fence like "go {1,3-5}" into the language and the ranges of lines to mark
+func parseFenceInfo(info string) (string, [][2]int) {
+	info = strings.TrimSpace(info)
+	lang := info
+	annotation := ""
+
+	if start := strings.IndexByte(info, '{'); start >= 0 {
+		lang = strings.TrimSpace(info[:start])
+		annotation = info[start+1:]
+
+		if end := strings.IndexByte(annotation, '}'); end >= 0 {
+			annotation = annotation[:end]
+		}
+	}
+
+	// anything else after the language, like a file name, isn't used
+	if fields := strings.Fields(lang); len(fields) > 0 {
+		lang = fields[0]
+	}
+
+	return lang, parseLineRanges(annotation)
+}
+
+// invalid parts of the annotation are ignored, so a typo only loses that part
+func parseLineRanges(annotation string) [][2]int {
+	var ranges [][2]int
+
+	for _, part := range strings.Split(annotation, ",") {
+		from, to, isRange := strings.Cut(strings.TrimSpace(part), "-")
+
+		start, err := strconv.Atoi(strings.TrimSpace(from))
+
+		if err != nil || start < 1 {
+			continue
+		}
+
+		end := start
+
+		if isRange {
+			end, err = strconv.Atoi(strings.TrimSpace(to))
+
+			if err != nil || end < start {
+				continue
+			}
+		}
+
+		ranges = append(ranges, [2]int{start, end})
+	}
+
+	return ranges
+}
+
+// every line is wrapped on its own, with the newline inside, so it can be numbered and marked.
+// unknown languages are written as plain text, still escaped
+func highlightCode(code, lang, theme string, marked [][2]int, lineNumbers bool) string {
+	lexer := lexers.Get(lang)
+
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, code)
+
+	if err != nil {
+		iterator, _ = lexers.Fallback.Tokenise(nil, code)
+	}
+
+	formatter := chromahtml.New(
+		chromahtml.WithClasses(true),
+		chromahtml.ClassPrefix(CLASS_PREFIX),
+		chromahtml.WithLineNumbers(lineNumbers),
+		chromahtml.HighlightLines(marked),
+		chromahtml.WithPreWrapper(codeBlockWrapper{lang, theme}),
+	)
+
+	var b strings.Builder
+
+	// with classes the style is only used for the css, see HighlightCSS
+	if err := formatter.Format(&b, styles.Fallback, iterator); err != nil {
+		return "<pre><code>" + html.EscapeString(code) + "</code></pre>\n"
+	}
+
+	return b.String()
+}
+
+// the pre of the code blocks, with the theme and the language the stylesheet and the page look for
+type codeBlockWrapper struct {
+	lang  string
+	theme string
+}
+
+func (w codeBlockWrapper) Start(code bool, _ string) string {
+	var b strings.Builder
+
+	b.WriteString(`<pre class="hl hl-theme-` + w.theme + `"`)
+
+	if w.lang != "" {
+		b.WriteString(` data-lang="` + html.EscapeString(w.lang) + `"`)
+	}
+
+	b.WriteString(">")
+
+	if !code {
+		return b.String()
+	}
+
+	b.WriteString("<code")
+
+	if w.lang != "" {
+		b.WriteString(` class="language-` + html.EscapeString(w.lang) + `"`)
+	}
+
+	b.WriteString(">")
+
+	return b.String()
+}
+
+func (w codeBlockWrapper) End(code bool) string {
+	if code {
+		return "</code></pre>\n"
+	}
+
+	return "</pre>\n"
+}
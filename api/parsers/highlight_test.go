@@ -0,0 +1,121 @@
+package parsers
+
+import (
+	"html"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestParseFenceInfo(t *testing.T) {
+	tests := []struct {
+		info   string
+		lang   string
+		marked [][2]int
+	}{
+		{info: "", lang: ""},
+		{info: "go", lang: "go"},
+		{info: "go {3-5}", lang: "go", marked: [][2]int{{3, 5}}},
+		{info: "go{1, 4}", lang: "go", marked: [][2]int{{1, 1}, {4, 4}}},
+		{info: "{2}", lang: "", marked: [][2]int{{2, 2}}},
+		{info: "js main.js {x,2-1,0,7}", lang: "js", marked: [][2]int{{7, 7}}},
+	}
+
+	for _, tt := range tests {
+		lang, marked := parseFenceInfo(tt.info)
+
+		if lang != tt.lang {
+			t.Errorf("parseFenceInfo(%q) lang = %q, want %q", tt.info, lang, tt.lang)
+		}
+
+		if !reflect.DeepEqual(marked, tt.marked) {
+			t.Errorf("parseFenceInfo(%q) marked = %v, want %v", tt.info, marked, tt.marked)
+		}
+	}
+}
+
+var lineNumber = regexp.MustCompile(`<span class="hl-ln">\d+</span>`)
+var tags = regexp.MustCompile(`<[^>]*>`)
+
+func TestHighlightKeepsTheCode(t *testing.T) {
+	code := "package main\n\n/* block\ncomment */\nfunc main() {\n\ts := `raw \\` + \"esc\\\"aped\" // done\n\tx := 0x1F + 'a' < 1\n}\n\"unterminated\n"
+
+	for _, lang := range []string{"go", "unknown"} {
+		highlighted := highlightCode(code, lang, DEFAULT_THEME, nil, true)
+		text := html.UnescapeString(tags.ReplaceAllString(lineNumber.ReplaceAllString(highlighted, ""), ""))
+
+		// the newline after the closing pre is left too
+		if text != code+"\n" {
+			t.Errorf("highlighted %s code doesn't add up to the code:\n%s", lang, text)
+		}
+	}
+}
+
+func TestMarkdownToHTMLHighlightsCode(t *testing.T) {
+	md := "text\n\n```go {2}\n// hi\nx := \"<b>\"\n```\n"
+
+	got := string(MarkdownToHTML([]byte(md), Options{Highlight: true, Theme: "solarized", LineNumbers: true}))
+
+	for _, want := range []string{
+		`<pre class="hl hl-theme-solarized" data-lang="go"><code class="language-go">`,
+		`<span class="hl-line"><span class="hl-ln">1</span><span class="hl-cl"><span class="hl-c1">// hi` + "\n</span></span></span>",
+		`<span class="hl-line hl-hl"><span class="hl-ln">2</span>`,
+		`<span class="hl-s">&#34;&lt;b&gt;&#34;</span>`,
+		"<p>text</p>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+
+	if strings.Contains(got, `<span class="hl-ln">3</span>`) {
+		t.Errorf("expected the trailing newline not to add a line:\n%s", got)
+	}
+}
+
+func TestMarkdownToHTMLWithoutHighlight(t *testing.T) {
+	got := string(MarkdownToHTML([]byte("```go {2}\nx := 1\n```\n"), Options{}))
+
+	if strings.Contains(got, "hl-") || !strings.Contains(got, `<code class="language-go">x := 1`) {
+		t.Errorf("expected a plain code block, got:\n%s", got)
+	}
+}
+
+func TestHighlightUnknownLanguageAndTheme(t *testing.T) {
+	got := string(MarkdownToHTML([]byte("```\"><script>\n<i>\n```\n"), Options{Highlight: true, Theme: "nope"}))
+
+	if strings.Contains(got, "<script>") || strings.Contains(got, "<i>") {
+		t.Errorf("expected the language and the code to be escaped, got:\n%s", got)
+	}
+
+	if !strings.Contains(got, "hl-theme-"+DEFAULT_THEME) {
+		t.Errorf("expected the default theme, got:\n%s", got)
+	}
+}
+
+func TestHighlightCSS(t *testing.T) {
+	css := HighlightCSS("gruvbox", "typo")
+
+	if !strings.Contains(css, "pre.hl-theme-gruvbox .hl-k { color: #af3a03 }") || !strings.Contains(css, "pre.hl-theme-gruvbox .hl-k { color: #fe8019 }") {
+		t.Errorf("expected the light and dark keyword colors of gruvbox, got:\n%s", css)
+	}
+
+	if !strings.Contains(css, "pre.hl-theme-"+DEFAULT_THEME+" ") {
+		t.Errorf("expected unknown themes to fall back to the default one")
+	}
+
+	if strings.Index(css, "#fe8019") < strings.Index(css, "@media (prefers-color-scheme: dark)") {
+		t.Errorf("expected the dark palette inside the dark color scheme query")
+	}
+}
+
+func TestNormalizeFences(t *testing.T) {
+	md := "```go {2}\na\n```\n~~~~\n```js {1}\n~~~\n~~~~\n  ```{3}\n```"
+
+	want := "```{go {2}\na\n```\n~~~~\n```js {1}\n~~~\n~~~~\n  ```{ {3}\n```"
+
+	if got := string(normalizeFences([]byte(md))); got != want {
+		t.Errorf("normalizeFences() = %q, want %q", got, want)
+	}
+}
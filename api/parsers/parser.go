@@ -1,19 +1,84 @@
 package parsers
 
 import (
+	"io"
+
 	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
 	"github.com/gomarkdown/markdown/html"
 	"github.com/gomarkdown/markdown/parser"
 )
 
-func MarkdownToHTML(md []byte) []byte {
+type Options struct {
+	// highlights fenced code blocks on the server, so the page doesn't change once it's loaded
+	Highlight bool
+	// one of the themes of HighlightCSS, falling back to DEFAULT_THEME
+	Theme       string
+	LineNumbers bool
+	// adds a link to itself after every heading, so sections can be shared
+	HeadingAnchors bool
+	// syntax parsed on top of markdown, none when it's not set
+	Extensions Extension
+}
+
+func MarkdownToHTML(md []byte, options Options) []byte {
+	return ParseMarkdown(md, options).HTML
+}
+
+// renders the markdown and keeps what the page needs to know about it besides the html
+func ParseMarkdown(md []byte, options Options) Document {
 	extensions := parser.CommonExtensions | parser.AutoHeadingIDs | parser.NoEmptyLineBeforeBlock
+	htmlFlags := html.CommonFlags | html.HrefTargetBlank
+
+	if options.Extensions&EXTENSION_FOOTNOTES != 0 {
+		extensions |= parser.Footnotes
+		htmlFlags |= html.FootnoteReturnLinks
+	}
+
 	p := parser.NewWithExtensions(extensions)
-	doc := p.Parse(md)
+	p.Opts.ParserHook = extensionsHook(options.Extensions)
+	doc := p.Parse(normalizeFences(md))
 
-	htmlFlags := html.CommonFlags | html.HrefTargetBlank
-	opts := html.RendererOptions{Flags: htmlFlags}
+	uniqueHeadingIDs(doc)
+
+	opts := html.RendererOptions{Flags: htmlFlags, RenderNodeHook: renderHook(options), FootnoteReturnLinkContents: "↩"}
 	renderer := html.NewRenderer(opts)
 
-	return markdown.Render(doc, renderer)
+	return Document{
+		HTML:       sanitize(markdown.Render(doc, renderer)),
+		TOC:        tableOfContents(doc),
+		WordCount:  countWords(doc),
+		FirstImage: firstImage(doc),
+	}
+}
+
+// nodes rendered differently from the default renderer, depending on the options
+func renderHook(options Options) html.RenderNodeFunc {
+	var renderCodeBlock html.RenderNodeFunc
+
+	if options.Highlight {
+		renderCodeBlock = codeBlockHook(options)
+	}
+
+	return func(w io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+		switch node := node.(type) {
+		case *Callout:
+			renderCallout(w, node, entering)
+			return ast.GoToNext, true
+		case *Embed:
+			renderEmbed(w, node)
+			return ast.GoToNext, true
+		case *ast.CodeBlock:
+			if renderCodeBlock != nil {
+				return renderCodeBlock(w, node, entering)
+			}
+		case *ast.Heading:
+			if options.HeadingAnchors && !entering && node.HeadingID != "" {
+				renderHeadingAnchor(w, node)
+				return ast.GoToNext, true
+			}
+		}
+
+		return ast.GoToNext, false
+	}
 }
@@ -0,0 +1,100 @@
+package parsers
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// classes the renderer and the extensions write. the ones ending with "-" allow every class starting with them
+var allowedClasses = []string{"hl", "hl-", "language-", "heading-anchor", "callout", "callout-", "embed", "embed-", "footnotes", "footnote-"}
+
+// the rendered html of an article goes through this policy before it reaches a page. article bodies are written
+// by the admins, so it keeps the highlighted code blocks, heading anchors, tables, images, callouts, embeds and
+// footnotes. whatever the markdown has, the html that's left:
+//   - only has the elements and attributes listed here. the others are removed keeping their text, while scripts,
+//     styles, frames and the like are removed with their content
+//   - never has event handlers or inline styles
+//   - only links to http, https, mailto and relative urls, for links and images alike
+//   - opens new tabs only with target="_blank", always with rel="noopener". links to other sites get rel="noreferrer"
+//   - is well formed, so it can't break the page it's put in
+var articlePolicy = newArticlePolicy()
+
+func newArticlePolicy() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+
+	p.AllowElements(
+		"p", "br", "hr", "em", "strong", "del", "s", "ins", "sub", "sup", "mark", "small", "kbd", "abbr", "q", "cite",
+		"blockquote", "ul", "ol", "li", "dl", "dt", "dd", "table", "caption", "thead", "tbody", "tfoot", "tr", "th", "td",
+		"pre", "code", "span", "div", "figure", "figcaption", "details", "summary", "h1", "h2", "h3", "h4", "h5", "h6",
+	)
+
+	p.AllowAttrs("href").OnElements("a")
+	p.AllowAttrs("target").Matching(regexp.MustCompile(`^_blank$`)).OnElements("a")
+	p.AllowAttrs("aria-label").Matching(bluemonday.SpaceSeparatedTokens).OnElements("a")
+	p.AllowAttrs("title").OnElements("a", "img", "abbr")
+	p.AllowAttrs("src", "alt").OnElements("img")
+	p.AllowAttrs("width", "height").Matching(bluemonday.NumberOrPercent).OnElements("img")
+	p.AllowAttrs("id").Matching(regexp.MustCompile(`^[\w:.-]+$`)).OnElements("a", "h1", "h2", "h3", "h4", "h5", "h6", "sup", "li")
+	p.AllowAttrs("class").Matching(classList(allowedClasses)).OnElements("a", "p", "sup", "pre", "code", "span", "div", "figure")
+	p.AllowAttrs("start").Matching(bluemonday.Integer).OnElements("ol")
+	p.AllowAttrs("align").Matching(regexp.MustCompile(`^(left|center|right)$`)).OnElements("th", "td")
+	p.AllowAttrs("colspan", "rowspan").Matching(bluemonday.Integer).OnElements("th", "td")
+	p.AllowAttrs("data-lang").Matching(regexp.MustCompile(`^[\w+#.-]+$`)).OnElements("pre")
+	p.AllowAttrs("data-youtube-id").Matching(regexp.MustCompile(`^[\w-]+$`)).OnElements("figure")
+	p.AllowAttrs("role").Matching(regexp.MustCompile(`^note$`)).OnElements("div")
+	p.AllowAttrs("open").Matching(regexp.MustCompile(`^(open)?$`)).OnElements("details")
+
+	p.AllowURLSchemes("http", "https", "mailto")
+	p.AllowRelativeURLs(true)
+	p.RequireParseableURLs(true)
+	p.RequireNoReferrerOnFullyQualifiedLinks(true)
+
+	return p
+}
+
+// matches a class attribute made only of allowed classes. bluemonday checks the whole value, so one unknown class drops all of them
+func classList(allowed []string) *regexp.Regexp {
+	var alternatives []string
+
+	for _, class := range allowed {
+		if strings.HasSuffix(class, "-") {
+			alternatives = append(alternatives, regexp.QuoteMeta(class)+`[\w-]+`)
+		} else {
+			alternatives = append(alternatives, regexp.QuoteMeta(class))
+		}
+	}
+
+	class := `(?:` + strings.Join(alternatives, "|") + `)`
+
+	return regexp.MustCompile(`^\s*` + class + `(?:\s+` + class + `)*\s*$`)
+}
+
+func sanitize(src []byte) []byte {
+	return articlePolicy.SanitizeBytes(balance(src))
+}
+
+// parsing the html the way browsers do closes the elements left open and drops stray closing tags,
+// which the policy keeps as they are
+func balance(src []byte) []byte {
+	context := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+
+	nodes, err := html.ParseFragment(bytes.NewReader(src), context)
+
+	// can't happen reading from memory, but nothing is better than html we couldn't check
+	if err != nil {
+		return nil
+	}
+
+	var b bytes.Buffer
+
+	for _, node := range nodes {
+		html.Render(&b, node)
+	}
+
+	return b.Bytes()
+}
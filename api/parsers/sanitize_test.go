@@ -0,0 +1,150 @@
+package parsers
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// markup that runs code once in a page. escaped text that only looks like it is harmless
+var dangerous = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)<(script|iframe|style|svg|math|object|embed|body)`),
+	regexp.MustCompile(`(?i)<[^>]*\son\w+\s*=`),
+	regexp.MustCompile(`(?i)(href|src)="\s*(javascript|vbscript|data):`),
+}
+
+func unsafeMarkup(html string) string {
+	for _, d := range dangerous {
+		if m := d.FindString(html); m != "" {
+			return m
+		}
+	}
+
+	return ""
+}
+
+func TestSanitizeRemovesXSS(t *testing.T) {
+	payloads := []string{
+		`<script>alert(1)</script>`,
+		`<SCRIPT SRC=//evil.test/x.js></SCRIPT>`,
+		`<scr<script>ipt>alert(1)</script>`,
+		`<script>document.write("</scripts>")</script>`,
+		`<img src=x onerror=alert(1)>`,
+		`<img src="x" ONERROR="alert(1)" />`,
+		`<img src="javascript:alert(1)">`,
+		`<a href="javascript:alert(1)">x</a>`,
+		`<a href="JaVaScRiPt:alert(1)">x</a>`,
+		`<a href="java	script:alert(1)">x</a>`,
+		`<a href=" &#106;avascript:alert(1)">x</a>`,
+		`<a href="&#x6A;&#x61;&#x76;&#x61;&#x73;&#x63;&#x72;&#x69;&#x70;&#x74;:alert(1)">x</a>`,
+		`<a href="vbscript:msgbox(1)">x</a>`,
+		`<a href="data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==">x</a>`,
+		`<div onmouseover="alert(1)">hover</div>`,
+		`<p/onclick=alert(1)>x</p>`,
+		`<body onload=alert(1)>`,
+		`<iframe src="https://evil.test"></iframe>`,
+		`<svg><script>alert(1)</script></svg>`,
+		`<svg onload=alert(1)>`,
+		`<style>body{background:url("javascript:alert(1)")}</style>`,
+		`<math><mi xlink:href="javascript:alert(1)">x</mi></math>`,
+		`<!--<img src=x onerror=alert(1)>-->`,
+		`<a href="#" title='"><script>alert(1)</script>'>x</a>`,
+		`<img src=x onerror=alert(1)//`,
+		`<<script>alert(1)//<</script>`,
+	}
+
+	for _, payload := range payloads {
+		got := string(sanitize([]byte(payload)))
+
+		if m := unsafeMarkup(got); m != "" {
+			t.Errorf("sanitize(%q) = %q, still has %q", payload, got, m)
+		}
+	}
+}
+
+func TestSanitizeMarkdown(t *testing.T) {
+	md := "[click](javascript:alert(1)) <script>alert(1)</script>\n\n<img src=x onerror=alert(1)>\n\n[ok](https://example.com)\n"
+
+	got := string(MarkdownToHTML([]byte(md), Options{}))
+
+	if m := unsafeMarkup(got); m != "" {
+		t.Errorf("MarkdownToHTML() = %q, still has %q", got, m)
+	}
+
+	if !strings.Contains(got, `<a href="https://example.com" target="_blank" rel="noreferrer noopener">ok</a>`) {
+		t.Errorf("expected the safe link to be kept, got %s", got)
+	}
+}
+
+func TestSanitizeKeepsTrustedMarkup(t *testing.T) {
+	md := "## Setup\n\n```go {1}\nx := \"<b>\"\n```\n\n| a |\n|:--|\n| 1 |\n\n![alt](/static/a.png \"title\")\n"
+
+	options := Options{Highlight: true, LineNumbers: true, HeadingAnchors: true}
+
+	got := string(MarkdownToHTML([]byte(md), options))
+
+	for _, want := range []string{
+		`<h2 id="setup">Setup <a href="#setup" class="heading-anchor" aria-label="link to this section">#</a></h2>`,
+		`<pre class="hl hl-theme-github" data-lang="go"><code class="language-go">`,
+		`<span class="hl-line hl-hl"><span class="hl-ln">1</span>`,
+		`<span class="hl-s">&#34;&lt;b&gt;&#34;</span>`,
+		`<th align="left">a</th>`,
+		`<img src="/static/a.png" alt="alt" title="title"/>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %s in %s", want, got)
+		}
+	}
+}
+
+func TestSanitizePolicy(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "unknown elements keep their text",
+			html: `<p>a <font color=red>b</font> <custom-tag>c</custom-tag></p>`,
+			want: `<p>a b c</p>`,
+		},
+		{
+			name: "classes outside the allowlist are removed",
+			html: `<span class="hl-k fixed inset-0">x</span><span class="hl-k hl-line">y</span><div class="fixed">z</div>`,
+			want: `<span>x</span><span class="hl-k hl-line">y</span><div>z</div>`,
+		},
+		{
+			name: "relative and allowed urls",
+			html: `<a href="/a:b">1</a><a href="#x">2</a><a href="mailto:a@b.c">3</a><a href="ftp://x">4</a>`,
+			want: `<a href="/a:b">1</a><a href="#x">2</a><a href="mailto:a@b.c">3</a>4`,
+		},
+		{
+			name: "only blank targets, always with noopener",
+			html: `<a href="/" target="_top" rel="opener">1</a><a href="/" target="_blank">2</a>`,
+			want: `<a href="/">1</a><a href="/" target="_blank" rel="noopener">2</a>`,
+		},
+		{
+			name: "stray closing tags are dropped and open ones closed",
+			html: `</div></article><p><em>x</p><div>`,
+			want: `<p><em>x</em></p><div></div>`,
+		},
+		{
+			name: "attribute values are escaped again",
+			html: `<img alt='a"b<c' src=/x>`,
+			want: `<img alt="a&#34;b&lt;c" src="/x"/>`,
+		},
+		{
+			name: "styles and unknown attributes are removed",
+			html: `<p style="position:fixed" data-x="1" class="callout-title">x</p>`,
+			want: `<p class="callout-title">x</p>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(sanitize([]byte(tt.html))); got != tt.want {
+				t.Errorf("sanitize() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package parsers
+
+import (
+	"bufio"
+	"sort"
+	"strings"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+const DEFAULT_THEME = "github"
+
+// every theme has a light and a dark chroma style, picked by the color scheme of the reader like the rest of the site
+type theme struct {
+	Light string
+	Dark  string
+}
+
+var themes = map[string]theme{
+	"github":    {Light: "github", Dark: "github-dark"},
+	"solarized": {Light: "solarized-light", Dark: "solarized-dark"},
+	"gruvbox":   {Light: "gruvbox-light", Dark: "gruvbox"},
+}
+
+// layout shared by every theme
+const baseCSS = `pre.hl { padding: 1rem 0; border-radius: 0.375rem; overflow-x: auto; line-height: 1.5; }
+pre.hl code { display: block; min-width: fit-content; padding: 0; background: transparent; color: inherit; font-weight: inherit; }
+pre.hl code::before, pre.hl code::after { content: none; }
+pre.hl .hl-line { padding: 0 1rem; }
+pre.hl .hl-ln { min-width: 2.5em; margin-right: 1rem; text-align: right; }
+`
+
+func Themes() []string {
+	names := make([]string, 0, len(themes))
+
+	for name := range themes {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// unknown themes fall back to the default one, so a typo in the config doesn't leave code blocks unstyled
+func themeName(name string) string {
+	if _, ok := themes[name]; !ok {
+		return DEFAULT_THEME
+	}
+
+	return name
+}
+
+// stylesheet for the highlighted code blocks with the given themes, or every theme when none is given
+func HighlightCSS(names ...string) string {
+	if len(names) == 0 {
+		names = Themes()
+	}
+
+	var light, dark strings.Builder
+	written := map[string]bool{}
+
+	for _, name := range names {
+		name = themeName(name)
+
+		if written[name] {
+			continue
+		}
+
+		written[name] = true
+		t := themes[name]
+
+		writeStyle(&light, name, t.Light)
+		writeStyle(&dark, name, t.Dark)
+	}
+
+	return baseCSS + light.String() + "@media (prefers-color-scheme: dark) {\n" + dark.String() + "}\n"
+}
+
+// the css chroma writes for the style, scoped to the code blocks of the theme
+func writeStyle(b *strings.Builder, name, style string) {
+	var css strings.Builder
+
+	formatter := chromahtml.New(chromahtml.WithClasses(true), chromahtml.ClassPrefix(CLASS_PREFIX))
+	formatter.WriteCSS(&css, styles.Get(style))
+
+	scanner := bufio.NewScanner(strings.NewReader(css.String()))
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// the other rules, like the background of the whole page, aren't scoped and would apply to every theme
+		if !strings.Contains(line, "."+CLASS_PREFIX+"chroma") {
+			continue
+		}
+
+		b.WriteString(strings.ReplaceAll(line, "."+CLASS_PREFIX+"chroma", "pre.hl-theme-"+name) + "\n")
+	}
+}
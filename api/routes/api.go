@@ -0,0 +1,361 @@
+package routes
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/samluiz/blog/api/jobs"
+	apiTypes "github.com/samluiz/blog/api/types"
+	"github.com/samluiz/blog/common/pagination"
+	"github.com/samluiz/blog/pkg/types"
+)
+
+const (
+	MAX_TITLE_LENGTH = 200
+	MAX_TAGS         = 4
+	MAX_TAG_LENGTH   = 30
+)
+
+func (r *router) APIListArticles(c *fiber.Ctx) error {
+	user, ok := r.sessionUser(c)
+
+	if !ok {
+		return apiError(c, fiber.StatusUnauthorized, apiTypes.ERR_UNAUTHORIZED, "you must be logged in")
+	}
+
+	authorId := c.QueryInt("author_id", user.ID)
+
+	p := pagination.Pagination{
+		Page:    c.QueryInt("page", 1),
+		Size:    c.QueryInt("size", pagination.DEFAULT_SIZE),
+		OrderBy: c.Query("order_by"),
+		SortBy:  strings.ToUpper(c.Query("sort_by")),
+	}
+
+	articles, totalPages, err := r.articleService.FindArticlesByUserId(authorId, p)
+
+	if err != nil {
+		return apiErrorFromErr(c, err)
+	}
+
+	c.Set("X-Page", strconv.Itoa(p.Page))
+	c.Set("X-Per-Page", strconv.Itoa(p.Size))
+	c.Set("X-Total-Pages", strconv.Itoa(totalPages))
+
+	response := make([]apiTypes.NativeArticleResponse, 0, len(articles))
+
+	for _, a := range articles {
+		response = append(response, toNativeArticleResponse(a))
+	}
+
+	return c.JSON(response)
+}
+
+func (r *router) APIGetArticle(c *fiber.Ctx) error {
+	article, err := r.articleService.FindArticleBySlug(c.Params("slug"))
+
+	if err != nil {
+		return apiErrorFromErr(c, err)
+	}
+
+	return c.JSON(toNativeArticleResponse(article))
+}
+
+func (r *router) APICreateArticle(c *fiber.Ctx) error {
+	user, ok := r.sessionUser(c)
+
+	if !ok {
+		return apiError(c, fiber.StatusUnauthorized, apiTypes.ERR_UNAUTHORIZED, "you must be logged in")
+	}
+
+	var body apiTypes.CreateArticleRequest
+
+	if err := c.BodyParser(&body); err != nil {
+		return apiError(c, fiber.StatusBadRequest, apiTypes.ERR_BAD_REQUEST, "invalid request body")
+	}
+
+	body.Title = strings.TrimSpace(body.Title)
+	body.Tags = normalizeTags(body.Tags)
+
+	if fields := validateArticle(body.Title, body.Tags); len(fields) > 0 {
+		return apiValidationError(c, fields)
+	}
+
+	article, err := r.articleService.CreateArticle(&types.CreateArticleInput{
+		Title:       body.Title,
+		Content:     body.Content,
+		IsPublished: body.IsPublished,
+		AuthorID:    user.ID,
+		Tags:        body.Tags,
+	})
+
+	if err != nil {
+		return apiErrorFromErr(c, err)
+	}
+
+	r.invalidateLocalContent()
+
+	response := toNativeArticleResponse(article)
+
+	if body.IsPublished && body.CrossPost {
+		r.apiEnqueueCrossPost(&response)
+	}
+
+	c.Location("/api/v1/articles/" + article.Slug)
+
+	return c.Status(fiber.StatusCreated).JSON(response)
+}
+
+func (r *router) APIUpdateArticle(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+
+	if err != nil {
+		return apiError(c, fiber.StatusBadRequest, apiTypes.ERR_BAD_REQUEST, "invalid article id")
+	}
+
+	var body apiTypes.UpdateArticleRequest
+
+	if err := c.BodyParser(&body); err != nil {
+		return apiError(c, fiber.StatusBadRequest, apiTypes.ERR_BAD_REQUEST, "invalid request body")
+	}
+
+	body.Title = strings.TrimSpace(body.Title)
+	body.Tags = normalizeTags(body.Tags)
+
+	if fields := validateArticle(body.Title, body.Tags); len(fields) > 0 {
+		return apiValidationError(c, fields)
+	}
+
+	user, _ := r.sessionUser(c)
+
+	article, err := r.articleService.UpdateArticle(id, &types.UpdateArticleInput{
+		Title:    body.Title,
+		Content:  body.Content,
+		Tags:     body.Tags,
+		EditorID: user.ID,
+	})
+
+	if err != nil {
+		return apiErrorFromErr(c, err)
+	}
+
+	r.invalidateLocalContent()
+
+	return c.JSON(toNativeArticleResponse(article))
+}
+
+// publishes the article. sending {"crosspost": true} or ?crosspost=true also publishes it on dev.to
+func (r *router) APIPublishArticle(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+
+	if err != nil {
+		return apiError(c, fiber.StatusBadRequest, apiTypes.ERR_BAD_REQUEST, "invalid article id")
+	}
+
+	var body apiTypes.PublishArticleRequest
+
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&body); err != nil {
+			return apiError(c, fiber.StatusBadRequest, apiTypes.ERR_BAD_REQUEST, "invalid request body")
+		}
+	}
+
+	article, err := r.setArticlePublished(id, true)
+
+	if err != nil {
+		return apiErrorFromErr(c, err)
+	}
+
+	response := toNativeArticleResponse(article)
+
+	if body.CrossPost || c.QueryBool("crosspost") {
+		r.apiEnqueueCrossPost(&response)
+	}
+
+	return c.JSON(response)
+}
+
+// the article is already saved at this point, so a failed enqueue is reported
+// in crosspost_status instead of failing the whole request
+func (r *router) apiEnqueueCrossPost(response *apiTypes.NativeArticleResponse) {
+	if err := r.enqueueCrossPost(response.ID); err != nil {
+		response.CrossPostStatus = types.CROSSPOST_FAILED
+		response.CrossPostError = err.Error()
+		return
+	}
+
+	response.CrossPostStatus = types.CROSSPOST_PENDING
+	response.CrossPostError = ""
+}
+
+func (r *router) APIUnpublishArticle(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+
+	if err != nil {
+		return apiError(c, fiber.StatusBadRequest, apiTypes.ERR_BAD_REQUEST, "invalid article id")
+	}
+
+	article, err := r.setArticlePublished(id, false)
+
+	if err != nil {
+		return apiErrorFromErr(c, err)
+	}
+
+	return c.JSON(toNativeArticleResponse(article))
+}
+
+func (r *router) APIDeleteArticle(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+
+	if err != nil {
+		return apiError(c, fiber.StatusBadRequest, apiTypes.ERR_BAD_REQUEST, "invalid article id")
+	}
+
+	if err := r.articleService.DeleteArticle(id); err != nil {
+		return apiErrorFromErr(c, err)
+	}
+
+	r.invalidateLocalContent()
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (r *router) APISyncDevTo(c *fiber.Ctx) error {
+	result, err := r.devToSync.Sync()
+
+	// the articles that failed are listed in the result
+	if err != nil && !errors.Is(err, jobs.ErrSyncIncomplete) {
+		if errors.Is(err, jobs.ErrSyncInProgress) {
+			return apiError(c, fiber.StatusConflict, apiTypes.ERR_CONFLICT, err.Error())
+		}
+		LOGGER.Error(err.Error())
+		return apiError(c, fiber.StatusBadGateway, apiTypes.ERR_UPSTREAM, "error syncing articles from dev.to")
+	}
+
+	return c.JSON(result)
+}
+
+func (r *router) setArticlePublished(id int, isPublished bool) (*types.GetArticleOutput, error) {
+	article, err := r.articleService.PublishArticle(id, &types.PublishArticleInput{IsPublished: isPublished})
+
+	if err != nil {
+		return nil, err
+	}
+
+	r.invalidateLocalContent()
+
+	return article, nil
+}
+
+func (r *router) sessionUser(c *fiber.Ctx) (apiTypes.SessionUser, bool) {
+	session, err := r.store.Get(c)
+
+	if err != nil {
+		LOGGER.Error("error getting session: %v", err)
+		return apiTypes.SessionUser{}, false
+	}
+
+	user, ok := session.Get("user").(apiTypes.SessionUser)
+
+	return user, ok
+}
+
+func validateArticle(title string, tags []string) map[string]string {
+	fields := map[string]string{}
+
+	if title == "" {
+		fields["title"] = "title is required"
+	} else if len(title) > MAX_TITLE_LENGTH {
+		fields["title"] = "title must have at most " + strconv.Itoa(MAX_TITLE_LENGTH) + " characters"
+	}
+
+	if len(tags) > MAX_TAGS {
+		fields["tags"] = "an article can have at most " + strconv.Itoa(MAX_TAGS) + " tags"
+	}
+
+	for _, tag := range tags {
+		if len(tag) > MAX_TAG_LENGTH || strings.Contains(tag, ",") {
+			fields["tags"] = "tags must have at most " + strconv.Itoa(MAX_TAG_LENGTH) + " characters and can't contain commas"
+			break
+		}
+	}
+
+	return fields
+}
+
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" {
+			normalized = append(normalized, tag)
+		}
+	}
+
+	return normalized
+}
+
+func splitTags(tags string) []string {
+	if tags == "" {
+		return []string{}
+	}
+
+	return strings.Split(tags, ",")
+}
+
+func toNativeArticleResponse(a *types.GetArticleOutput) apiTypes.NativeArticleResponse {
+	return apiTypes.NativeArticleResponse{
+		ID:          a.ID,
+		Title:       a.Title,
+		Slug:        a.Slug,
+		Content:     a.Content,
+		Tags:        splitTags(a.Tags),
+		AuthorID:    a.AuthorID,
+		Visibility:  a.Visibility,
+		IsPublished: a.IsPublished,
+		PublishedAt: a.PublishedAt,
+		CreatedAt:   a.CreatedAt,
+		UpdatedAt:   a.UpdatedAt,
+
+		DevToID:         a.DevToID,
+		DevToURL:        a.DevToURL,
+		CrossPostStatus: a.CrossPostStatus,
+		CrossPostError:  a.CrossPostError,
+	}
+}
+
+func apiError(c *fiber.Ctx, status int, code string, message string) error {
+	return c.Status(status).JSON(apiTypes.ErrorResponse{
+		Status:  status,
+		Code:    code,
+		Message: message,
+	})
+}
+
+func apiValidationError(c *fiber.Ctx, fields map[string]string) error {
+	return c.Status(fiber.StatusUnprocessableEntity).JSON(apiTypes.ErrorResponse{
+		Status:  fiber.StatusUnprocessableEntity,
+		Code:    apiTypes.ERR_VALIDATION,
+		Message: "the request has invalid fields",
+		Fields:  fields,
+	})
+}
+
+// maps domain errors to their http status, hiding unexpected errors behind a generic message
+func apiErrorFromErr(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, types.ErrArticleNotFound), errors.Is(err, types.ErrUserNotFound):
+		return apiError(c, fiber.StatusNotFound, apiTypes.ERR_NOT_FOUND, err.Error())
+	case errors.Is(err, pagination.ErrPageOutOfRange), errors.Is(err, pagination.ErrSizeOutOfRange),
+		errors.Is(err, pagination.ErrInvalidSortBy), errors.Is(err, types.ErrInvalidOrderBy):
+		return apiError(c, fiber.StatusBadRequest, apiTypes.ERR_BAD_REQUEST, err.Error())
+	case errors.Is(err, types.ErrNotPublished), errors.Is(err, types.ErrImportedArticle):
+		return apiError(c, fiber.StatusConflict, apiTypes.ERR_CONFLICT, err.Error())
+	default:
+		LOGGER.Error(err.Error())
+		return apiError(c, fiber.StatusInternalServerError, apiTypes.ERR_INTERNAL_ERROR, "something went wrong")
+	}
+}
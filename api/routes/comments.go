@@ -0,0 +1,242 @@
+package routes
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	apiTypes "github.com/samluiz/blog/api/types"
+	"github.com/samluiz/blog/pkg/types"
+)
+
+const (
+	MAX_COMMENT_LENGTH = 2000
+	// replies below this depth can't be answered, so threads stay readable on small screens
+	MAX_COMMENT_DEPTH = 4
+)
+
+type commentView struct {
+	*types.Comment
+	Slug      string
+	CanChange bool
+	CanReply  bool
+	Replies   []commentView
+}
+
+// renders the comments of the article. it's loaded by htmx below the article and re-rendered after every change
+func (r *router) CommentsPartial(c *fiber.Ctx) error {
+	return r.renderComments(c, "")
+}
+
+func (r *router) CreateComment(c *fiber.Ctx) error {
+	user, ok := r.sessionUser(c)
+
+	if !ok {
+		return r.renderComments(c, "You must be logged in to comment.")
+	}
+
+	article, err := r.commentableArticle(c.Params("slug"))
+
+	if err != nil {
+		return r.commentError(c, err)
+	}
+
+	content := strings.TrimSpace(c.FormValue("content"))
+
+	if message := validateComment(content); message != "" {
+		return r.renderComments(c, message)
+	}
+
+	input := &types.CreateCommentInput{
+		Content:    content,
+		ArticleID:  article.ID,
+		AuthorID:   user.ID,
+		AuthorType: commentActor(user).Type,
+	}
+
+	if parentId, err := strconv.Atoi(c.FormValue("parent_id")); err == nil {
+		input.ParentID = &parentId
+	}
+
+	comment, err := r.commentService.CreateComment(input)
+
+	if err != nil {
+		return r.commentError(c, err)
+	}
+
+	return r.renderComments(c, moderationMessage(comment))
+}
+
+func (r *router) UpdateComment(c *fiber.Ctx) error {
+	user, ok := r.sessionUser(c)
+
+	if !ok {
+		return r.renderComments(c, "You must be logged in to edit comments.")
+	}
+
+	id, err := c.ParamsInt("id")
+
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+
+	content := strings.TrimSpace(c.FormValue("content"))
+
+	if message := validateComment(content); message != "" {
+		return r.renderComments(c, message)
+	}
+
+	comment, err := r.commentService.UpdateComment(id, &types.UpdateCommentInput{Content: content}, commentActor(user))
+
+	if err != nil {
+		return r.commentError(c, err)
+	}
+
+	return r.renderComments(c, moderationMessage(comment))
+}
+
+func (r *router) DeleteComment(c *fiber.Ctx) error {
+	user, ok := r.sessionUser(c)
+
+	if !ok {
+		return r.renderComments(c, "You must be logged in to delete comments.")
+	}
+
+	id, err := c.ParamsInt("id")
+
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+
+	if err := r.commentService.DeleteComment(id, commentActor(user)); err != nil {
+		return r.commentError(c, err)
+	}
+
+	return r.renderComments(c, "")
+}
+
+func (r *router) renderComments(c *fiber.Ctx, message string) error {
+	slug := c.Params("slug")
+
+	article, err := r.commentableArticle(slug)
+
+	// articles only on dev.to have no local row to attach comments to
+	if err != nil {
+		if !errors.Is(err, types.ErrArticleNotFound) {
+			LOGGER.Error(err.Error())
+		}
+		return c.SendString("")
+	}
+
+	threads, err := r.commentService.FindCommentThreadsByArticleId(article.ID)
+
+	if err != nil {
+		LOGGER.Error(err.Error())
+	}
+
+	user, isLogged := r.sessionUser(c)
+	actor := commentActor(user)
+
+	count := 0
+	views := make([]commentView, 0, len(threads))
+
+	for _, thread := range threads {
+		views = append(views, toCommentView(thread, article.Slug, actor, isLogged, 1, &count))
+	}
+
+	return c.Render("partials/comments", fiber.Map{
+		"Slug":      article.Slug,
+		"Comments":  views,
+		"Count":     count,
+		"IsLogged":  isLogged,
+		"LoginURL":  "/auth/login?redirect=" + url.QueryEscape("/articles/"+article.Slug+"#comments"),
+		"MaxLength": MAX_COMMENT_LENGTH,
+		"Message":   message,
+		"Error":     err,
+	}, "")
+}
+
+// comments can only be written on published articles stored locally
+func (r *router) commentableArticle(slug string) (*types.GetArticleOutput, error) {
+	article, err := r.articleService.FindArticleBySlug(slug)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !article.IsPublished || article.DeletedAt != nil {
+		return nil, types.ErrArticleNotFound
+	}
+
+	return article, nil
+}
+
+func (r *router) commentError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, types.ErrUserUnauthorized):
+		return r.renderComments(c, "You can only change your own comments.")
+	case errors.Is(err, types.ErrCommentNotFound):
+		return r.renderComments(c, "This comment doesn't exist anymore.")
+	case errors.Is(err, types.ErrUserBanned):
+		return r.renderComments(c, "You can no longer comment on this blog.")
+	case errors.Is(err, types.ErrInvalidParentComment):
+		return r.renderComments(c, "You can only reply to comments of this article.")
+	case errors.Is(err, types.ErrArticleNotFound):
+		return fiber.ErrNotFound
+	default:
+		LOGGER.Error(err.Error())
+		return r.renderComments(c, "Something went wrong. Please try again.")
+	}
+}
+
+func toCommentView(thread *types.CommentThread, slug string, actor types.CommentActor, isLogged bool, depth int, count *int) commentView {
+	*count++
+
+	view := commentView{
+		Comment:   thread.Comment,
+		Slug:      slug,
+		CanChange: isLogged && thread.CanBeChangedBy(actor),
+		CanReply:  isLogged && depth < MAX_COMMENT_DEPTH,
+		Replies:   make([]commentView, 0, len(thread.Replies)),
+	}
+
+	for _, reply := range thread.Replies {
+		view.Replies = append(view.Replies, toCommentView(reply, slug, actor, isLogged, depth+1, count))
+	}
+
+	return view
+}
+
+// admins log in with a password and are stored in users, readers log in with github and are stored in external_users
+func commentActor(user apiTypes.SessionUser) types.CommentActor {
+	authorType := types.AUTHOR_USER
+
+	if user.Provider != "" {
+		authorType = types.AUTHOR_EXTERNAL
+	}
+
+	return types.CommentActor{ID: user.ID, Type: authorType, IsAdmin: user.IsAdmin}
+}
+
+// spam is reported as waiting for moderation too, so spammers don't learn what gave them away
+func moderationMessage(comment *types.Comment) string {
+	if comment.IsApproved() {
+		return ""
+	}
+
+	return "Your comment is waiting for moderation and will show up once it's approved."
+}
+
+func validateComment(content string) string {
+	if content == "" {
+		return "Comments can't be empty."
+	}
+
+	if len(content) > MAX_COMMENT_LENGTH {
+		return "Comments must have at most " + strconv.Itoa(MAX_COMMENT_LENGTH) + " characters."
+	}
+
+	return ""
+}
@@ -0,0 +1,511 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/samluiz/blog/api/content"
+	"github.com/samluiz/blog/api/jobs"
+	"github.com/samluiz/blog/api/parsers"
+	apiTypes "github.com/samluiz/blog/api/types"
+	"github.com/samluiz/blog/common/pagination"
+	"github.com/samluiz/blog/pkg/types"
+)
+
+const DASHBOARD_ARTICLES_PAGE_SIZE = 50
+const DASHBOARD_COMMENTS_PAGE_SIZE = 50
+
+func (r *router) AdminDashboardPage(c *fiber.Ctx) error {
+	session, err := r.store.Get(c)
+
+	if err != nil {
+		LOGGER.Error("error getting session: %v", err)
+	}
+
+	isLogged := session.Get(IS_LOGGED)
+	user := session.Get("user")
+
+	return c.Render("pages/dashboard", fiber.Map{
+		"IsLogged":  isLogged,
+		"User":      user,
+		"PageTitle": "dashboard",
+	})
+}
+
+// renders the list of drafts and published articles of the logged admin. it's loaded by htmx inside the dashboard
+func (r *router) AdminArticlesPartial(c *fiber.Ctx) error {
+	return r.renderAdminArticles(c)
+}
+
+func (r *router) AdminNewArticlePage(c *fiber.Ctx) error {
+	return r.renderArticleEditor(c, nil)
+}
+
+func (r *router) AdminEditArticlePage(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+
+	article, err := r.articleService.FindArticleById(id)
+
+	if err != nil {
+		if errors.Is(err, types.ErrArticleNotFound) {
+			return fiber.ErrNotFound
+		}
+		return err
+	}
+
+	if article.Source == types.SOURCE_DEVTO {
+		return fiber.NewError(fiber.StatusForbidden, types.ErrImportedArticle.Error())
+	}
+
+	return r.renderArticleEditor(c, article)
+}
+
+func (r *router) AdminCreateArticle(c *fiber.Ctx) error {
+	user, ok := r.sessionUser(c)
+
+	if !ok {
+		return fiber.ErrUnauthorized
+	}
+
+	title, content, tags := articleFormValues(c)
+
+	if message := validationMessage(validateArticle(title, tags)); message != "" {
+		return c.SendString(message)
+	}
+
+	article, err := r.articleService.CreateArticle(&types.CreateArticleInput{
+		Title:    title,
+		Content:  content,
+		AuthorID: user.ID,
+		Tags:     tags,
+	})
+
+	if err != nil {
+		LOGGER.Error(err.Error())
+		return c.SendString("Error while creating the article. Please try again.")
+	}
+
+	c.Set("HX-Redirect", DASHBOARD_URL+"/articles/"+strconv.Itoa(article.ID)+"/edit")
+
+	return c.SendStatus(fiber.StatusCreated)
+}
+
+func (r *router) AdminUpdateArticle(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+
+	title, content, tags := articleFormValues(c)
+
+	if message := validationMessage(validateArticle(title, tags)); message != "" {
+		return c.SendString(message)
+	}
+
+	user, _ := r.sessionUser(c)
+
+	_, err = r.articleService.UpdateArticle(id, &types.UpdateArticleInput{
+		Title:    title,
+		Content:  content,
+		Tags:     tags,
+		EditorID: user.ID,
+	})
+
+	if err != nil {
+		if errors.Is(err, types.ErrImportedArticle) {
+			return c.SendString("Imported articles can only be edited on dev.to.")
+		}
+		LOGGER.Error(err.Error())
+		return c.SendString("Error while saving the article. Please try again.")
+	}
+
+	r.invalidateLocalContent()
+
+	return c.SendString("Saved.")
+}
+
+// renders the markdown sent by the editor, so the admin can see the article while writing it
+func (r *router) AdminPreviewArticle(c *fiber.Ctx) error {
+	c.Type("html")
+	return c.Send(parsers.MarkdownToHTML([]byte(c.FormValue("content")), markdownOptions()))
+}
+
+// publishes the article. with ?crosspost=true it's also sent to dev.to
+func (r *router) AdminPublishArticle(c *fiber.Ctx) error {
+	return r.setAdminArticlePublished(c, true, c.QueryBool("crosspost"))
+}
+
+// sends the article to dev.to again. used to retry failed cross posts or to cross post already published articles
+func (r *router) AdminCrossPostArticle(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+
+	r.enqueueCrossPost(id)
+
+	return r.renderAdminArticles(c)
+}
+
+func (r *router) enqueueCrossPost(id int) error {
+	err := r.crossPoster.Enqueue(id)
+
+	if errors.Is(err, types.ErrNotPublished) || errors.Is(err, types.ErrImportedArticle) {
+		LOGGER.Warning("refused to cross post article %d: %v", id, err)
+	} else if err != nil {
+		LOGGER.Error("error enqueuing cross post of article %d: %v", id, err)
+	}
+
+	return err
+}
+
+func (r *router) AdminUnpublishArticle(c *fiber.Ctx) error {
+	return r.setAdminArticlePublished(c, false, false)
+}
+
+// the time comes from the browser as an rfc 3339 date, converted from the admin's local time
+func (r *router) AdminScheduleArticle(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+
+	publishAt, err := time.Parse(time.RFC3339, c.FormValue("publish_at"))
+
+	if err != nil {
+		return r.renderAdminArticlesMessage(c, "Invalid publish date")
+	}
+
+	return r.scheduleAdminArticle(c, id, &publishAt)
+}
+
+func (r *router) AdminUnscheduleArticle(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+
+	return r.scheduleAdminArticle(c, id, nil)
+}
+
+func (r *router) scheduleAdminArticle(c *fiber.Ctx, id int, publishAt *time.Time) error {
+	_, err := r.articleService.ScheduleArticle(id, publishAt)
+
+	switch {
+	case errors.Is(err, types.ErrScheduleInPast):
+		return r.renderAdminArticlesMessage(c, "Articles can only be scheduled for a future date")
+	case errors.Is(err, types.ErrAlreadyPublished):
+		return r.renderAdminArticlesMessage(c, "The article is already published")
+	case err != nil:
+		LOGGER.Error(err.Error())
+	}
+
+	return r.renderAdminArticles(c)
+}
+
+func (r *router) AdminDeleteArticle(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+
+	if err := r.articleService.DeleteArticle(id); err != nil && !errors.Is(err, types.ErrArticleNotFound) {
+		LOGGER.Error(err.Error())
+	}
+
+	r.invalidateLocalContent()
+
+	return r.renderAdminArticles(c)
+}
+
+func (r *router) setAdminArticlePublished(c *fiber.Ctx, isPublished bool, crossPost bool) error {
+	id, err := c.ParamsInt("id")
+
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+
+	if _, err = r.setArticlePublished(id, isPublished); err != nil {
+		LOGGER.Error(err.Error())
+	} else if crossPost {
+		r.enqueueCrossPost(id)
+	}
+
+	return r.renderAdminArticles(c)
+}
+
+// drafts and published articles are paged separately, so a big dev.to import can't push the drafts out of the list.
+// the current pages come from the query or, when an action re-renders the list, from the hx-vals of the partial
+func (r *router) renderAdminArticles(c *fiber.Ctx) error {
+	return r.renderAdminArticlesMessage(c, "")
+}
+
+// renders the lists of articles with a message about the action that was refused
+func (r *router) renderAdminArticlesMessage(c *fiber.Ctx, message string) error {
+	user, ok := r.sessionUser(c)
+
+	if !ok {
+		return fiber.ErrUnauthorized
+	}
+
+	drafts, err := r.findAdminArticles(user.ID, false, adminPageParam(c, "drafts_page"))
+
+	if err != nil {
+		LOGGER.Error(err.Error())
+	}
+
+	published, publishedErr := r.findAdminArticles(user.ID, true, adminPageParam(c, "published_page"))
+
+	if publishedErr != nil {
+		LOGGER.Error(publishedErr.Error())
+		err = publishedErr
+	}
+
+	return c.Render("partials/admin-articles", fiber.Map{
+		"Drafts":    drafts,
+		"Published": published,
+		"Error":     err,
+		"Message":   message,
+	}, "")
+}
+
+// the page of a paginated list, with the links to its neighbours
+type listPage struct {
+	Page       int
+	TotalPages int
+}
+
+func (p listPage) HasPrevious() bool { return p.Page > 1 }
+
+func (p listPage) HasNext() bool { return p.Page < p.TotalPages }
+
+func (p listPage) Previous() int { return p.Page - 1 }
+
+func (p listPage) Next() int { return p.Page + 1 }
+
+type adminArticlesPage struct {
+	listPage
+	Articles []*types.GetArticleOutput
+}
+
+// a page that no longer exists, e.g. after deleting the last article of the last page, falls back to the last one
+func (r *router) findAdminArticles(userId int, isPublished bool, page int) (adminArticlesPage, error) {
+	p := pagination.Pagination{
+		Page:    page,
+		Size:    DASHBOARD_ARTICLES_PAGE_SIZE,
+		OrderBy: "updated_at",
+		SortBy:  "DESC",
+	}
+
+	articles, totalPages, err := r.articleService.FindArticlesByUserIdAndPublished(userId, isPublished, p)
+
+	if errors.Is(err, pagination.ErrPageOutOfRange) && totalPages > 0 {
+		p.Page = totalPages
+		articles, totalPages, err = r.articleService.FindArticlesByUserIdAndPublished(userId, isPublished, p)
+	}
+
+	return adminArticlesPage{listPage{p.Page, totalPages}, articles}, err
+}
+
+func adminPageParam(c *fiber.Ctx, key string) int {
+	value := c.Query(key)
+
+	if value == "" {
+		value = c.FormValue(key)
+	}
+
+	page, err := strconv.Atoi(value)
+
+	if err != nil || page < 1 {
+		return 1
+	}
+
+	return page
+}
+
+func (r *router) renderArticleEditor(c *fiber.Ctx, article *types.GetArticleOutput) error {
+	session, err := r.store.Get(c)
+
+	if err != nil {
+		LOGGER.Error("error getting session: %v", err)
+	}
+
+	pageTitle := "new article"
+
+	if article != nil {
+		pageTitle = "editing " + article.Title
+	}
+
+	return c.Render("pages/article-editor", fiber.Map{
+		"IsLogged":  session.Get(IS_LOGGED),
+		"User":      session.Get("user"),
+		"Article":   article,
+		"PageTitle": pageTitle,
+	})
+}
+
+func articleFormValues(c *fiber.Ctx) (string, string, []string) {
+	title := strings.TrimSpace(c.FormValue("title"))
+	content := c.FormValue("content")
+	tags := normalizeTags(strings.Split(c.FormValue("tags"), ","))
+
+	return title, content, tags
+}
+
+func validationMessage(fields map[string]string) string {
+	messages := make([]string, 0, len(fields))
+
+	for _, message := range fields {
+		messages = append(messages, message)
+	}
+
+	sort.Strings(messages)
+
+	return strings.Join(messages, ". ")
+}
+
+// removes cached entries so the next request fetches them again. the prefix selects which ones, empty means all
+func (r *router) AdminInvalidateCache(c *fiber.Ctx) error {
+	prefix := c.FormValue("prefix")
+
+	removed := r.cache.InvalidatePrefix(prefix)
+
+	return c.SendString("Cleared " + strconv.Itoa(removed) + " cached entries.")
+}
+
+func (r *router) AdminSyncDevTo(c *fiber.Ctx) error {
+	result, err := r.devToSync.Sync()
+
+	if err != nil && !errors.Is(err, jobs.ErrSyncIncomplete) {
+		if errors.Is(err, jobs.ErrSyncInProgress) {
+			return c.SendString("A sync is already running.")
+		}
+		LOGGER.Error(err.Error())
+		return c.SendString("Error while syncing the articles from dev.to.")
+	}
+
+	message := fmt.Sprintf("Sync finished: %d added, %d updated, %d removed.", result.Added, result.Updated, result.Removed)
+
+	if len(result.Failed) > 0 {
+		message += fmt.Sprintf(" %d articles failed and will be tried again on the next sync.", len(result.Failed))
+	}
+
+	c.Set("HX-Trigger", "articles-changed")
+
+	return c.SendString(message)
+}
+
+func (r *router) AdminCommentsPage(c *fiber.Ctx) error {
+	session, err := r.store.Get(c)
+
+	if err != nil {
+		LOGGER.Error("error getting session: %v", err)
+	}
+
+	return c.Render("pages/admin-comments", fiber.Map{
+		"IsLogged":  session.Get(IS_LOGGED),
+		"User":      session.Get("user"),
+		"PageTitle": "comments",
+	})
+}
+
+// renders the comments with the given status, pending ones by default. it's loaded by htmx inside the comments page
+func (r *router) AdminCommentsPartial(c *fiber.Ctx) error {
+	return r.renderAdminComments(c, "")
+}
+
+// applies the clicked action to every selected comment of the queue
+func (r *router) AdminModerateComments(c *fiber.Ctx) error {
+	var ids []int
+
+	for _, value := range c.Request().PostArgs().PeekMulti("ids") {
+		if id, err := strconv.Atoi(string(value)); err == nil {
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) == 0 {
+		return r.renderAdminComments(c, "Select at least one comment.")
+	}
+
+	action := c.FormValue("action")
+
+	affected, err := r.commentService.ModerateComments(ids, action)
+
+	if err != nil {
+		if errors.Is(err, types.ErrInvalidModeration) {
+			return r.renderAdminComments(c, "Unknown moderation action.")
+		}
+		LOGGER.Error(err.Error())
+		return r.renderAdminComments(c, "Error while moderating the comments.")
+	}
+
+	if action == types.MODERATE_BAN {
+		return r.renderAdminComments(c, fmt.Sprintf("Banned %d users and marked their pending comments as spam.", affected))
+	}
+
+	return r.renderAdminComments(c, fmt.Sprintf("Marked %d comments as %s.", affected, moderatedStatus[action]))
+}
+
+var moderatedStatus = map[string]string{
+	types.MODERATE_APPROVE: types.COMMENT_APPROVED,
+	types.MODERATE_REJECT:  types.COMMENT_REJECTED,
+	types.MODERATE_SPAM:    types.COMMENT_SPAM,
+}
+
+var adminCommentStatuses = []string{types.COMMENT_PENDING, types.COMMENT_SPAM, types.COMMENT_REJECTED, types.COMMENT_APPROVED}
+
+func (r *router) renderAdminComments(c *fiber.Ctx, message string) error {
+	status := c.Query("status")
+
+	if status == "" {
+		status = c.FormValue("status", types.COMMENT_PENDING)
+	}
+
+	p := pagination.Pagination{
+		Page:    adminPageParam(c, "page"),
+		Size:    DASHBOARD_COMMENTS_PAGE_SIZE,
+		OrderBy: "created_at",
+		SortBy:  "DESC",
+	}
+
+	comments, totalPages, err := r.commentService.FindCommentsByStatus(status, p)
+
+	// moderating the last comments of the last page leaves it empty, so we show the one before it
+	if errors.Is(err, pagination.ErrPageOutOfRange) && totalPages > 0 {
+		p.Page = totalPages
+		comments, totalPages, err = r.commentService.FindCommentsByStatus(status, p)
+	}
+
+	if err != nil {
+		LOGGER.Error(err.Error())
+	}
+
+	return c.Render("partials/admin-comments", fiber.Map{
+		"Status":   status,
+		"Statuses": adminCommentStatuses,
+		"Comments": comments,
+		"Page":     listPage{p.Page, totalPages},
+		"Message":  message,
+		"Error":    err,
+	}, "")
+}
+
+// drops the cached local articles, so changes made by the admin show up right away
+func (r *router) invalidateLocalContent() {
+	r.cache.InvalidatePrefix(content.CACHE_PREFIX + apiTypes.SOURCE_LOCAL)
+}
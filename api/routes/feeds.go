@@ -0,0 +1,167 @@
+package routes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/samluiz/blog/api/feeds"
+	apiTypes "github.com/samluiz/blog/api/types"
+	"github.com/samluiz/blog/pkg/config"
+)
+
+const (
+	FEED_SIZE         = 20
+	FEED_TITLE        = "@" + GITHUB_USERNAME
+	FEED_DESCRIPTION  = "Articles about web development, backend, frontend, and whatever i wanna share."
+	FEED_MAX_AGE      = 5 * time.Minute
+	RSS_CONTENT_TYPE  = "application/rss+xml; charset=utf-8"
+	ATOM_CONTENT_TYPE = "application/atom+xml; charset=utf-8"
+	// the content type json feed recommends
+	JSON_FEED_CONTENT_TYPE = "application/feed+json; charset=utf-8"
+)
+
+func (r *router) RSSFeed(c *fiber.Ctx) error {
+	return r.sendFeed(c, "", "/feed.xml", feeds.Feed.RSS, RSS_CONTENT_TYPE)
+}
+
+func (r *router) AtomFeed(c *fiber.Ctx) error {
+	return r.sendFeed(c, "", "/atom.xml", feeds.Feed.Atom, ATOM_CONTENT_TYPE)
+}
+
+func (r *router) JSONFeed(c *fiber.Ctx) error {
+	return r.sendFeed(c, "", "/feed.json", feeds.Feed.JSON, JSON_FEED_CONTENT_TYPE)
+}
+
+func (r *router) TagRSSFeed(c *fiber.Ctx) error {
+	tag, err := url.PathUnescape(c.Params("tag"))
+
+	if err != nil || strings.TrimSpace(tag) == "" {
+		return fiber.ErrNotFound
+	}
+
+	return r.sendFeed(c, tag, "/tags/"+url.PathEscape(tag)+"/feed.xml", feeds.Feed.RSS, RSS_CONTENT_TYPE)
+}
+
+// feed readers don't follow the redirect to the html error page, so failures are answered with a plain status
+func (r *router) sendFeed(c *fiber.Ctx, tag string, path string, encode func(feeds.Feed) ([]byte, error), contentType string) error {
+	feed, err := r.buildFeed(tag, path)
+
+	if err != nil {
+		LOGGER.Error("error listing the articles of %s: %v", path, err)
+		return c.SendStatus(fiber.StatusServiceUnavailable)
+	}
+
+	body, err := encode(feed)
+
+	if err != nil {
+		LOGGER.Error("error encoding %s: %v", path, err)
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+
+	return sendConditional(c, body, contentType, feed.Updated)
+}
+
+// builds the feed of the latest articles, optionally only the ones with the given tag
+func (r *router) buildFeed(tag string, path string) (feeds.Feed, error) {
+	var articles []apiTypes.ArticleResponse
+	var err error
+
+	title := FEED_TITLE
+
+	if tag != "" {
+		articles, err = r.allArticlesByTag(tag)
+		title += " | #" + tag
+
+		if len(articles) > FEED_SIZE {
+			articles = articles[:FEED_SIZE]
+		}
+	} else {
+		articles, err = r.contentSource.ListArticles(1, FEED_SIZE)
+	}
+
+	if err != nil {
+		return feeds.Feed{}, err
+	}
+
+	siteURL := config.SiteURL()
+
+	return feeds.New(title, FEED_DESCRIPTION, siteURL+"/", siteURL+path, r.feedAuthor(), articles), nil
+}
+
+// the author is taken from the github profile, falling back to the username when github can't be reached
+func (r *router) feedAuthor() feeds.Author {
+	author := feeds.Author{Name: GITHUB_USERNAME, URL: "https://github.com/" + GITHUB_USERNAME}
+
+	profile, err := r.githubProfile()
+
+	if err != nil {
+		LOGGER.Error(err.Error())
+		return author
+	}
+
+	if profile.Name != "" {
+		author.Name = profile.Name
+	}
+
+	if profile.HTMLURL != "" {
+		author.URL = profile.HTMLURL
+	}
+
+	author.Avatar = profile.AvatarURL
+
+	return author
+}
+
+// sends the body with an etag and last modified date, answering 304 when the client already has it
+func sendConditional(c *fiber.Ctx, body []byte, contentType string, lastModified time.Time) error {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderCacheControl, "public, max-age="+strconv.Itoa(int(FEED_MAX_AGE.Seconds())))
+
+	if !lastModified.IsZero() {
+		c.Set(fiber.HeaderLastModified, lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(c.Get(fiber.HeaderIfNoneMatch), c.Get(fiber.HeaderIfModifiedSince), etag, lastModified) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+
+	return c.Send(body)
+}
+
+// if-none-match wins over if-modified-since, as rfc 9110 asks
+func notModified(ifNoneMatch, ifModifiedSince, etag string, lastModified time.Time) bool {
+	if ifNoneMatch != "" {
+		for _, candidate := range strings.Split(ifNoneMatch, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ifModifiedSince == "" || lastModified.IsZero() {
+		return false
+	}
+
+	since, err := http.ParseTime(ifModifiedSince)
+
+	if err != nil {
+		return false
+	}
+
+	// http dates have no fractions of a second
+	return !lastModified.Truncate(time.Second).After(since)
+}
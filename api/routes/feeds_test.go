@@ -0,0 +1,36 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNotModified(t *testing.T) {
+	lastModified := time.Date(2024, time.March, 1, 10, 0, 0, 500, time.UTC)
+	etag := `"abc"`
+
+	tests := []struct {
+		name            string
+		ifNoneMatch     string
+		ifModifiedSince string
+		want            bool
+	}{
+		{"unconditional", "", "", false},
+		{"same etag", `"abc"`, "", true},
+		{"weak etag in a list", `"old", W/"abc"`, "", true},
+		{"other etag", `"old"`, "", false},
+		{"etag wins over the date", `"old"`, lastModified.Format(http.TimeFormat), false},
+		{"not modified since", "", lastModified.Format(http.TimeFormat), true},
+		{"modified since", "", lastModified.Add(-time.Hour).Format(http.TimeFormat), false},
+		{"invalid date", "", "yesterday", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := notModified(tt.ifNoneMatch, tt.ifModifiedSince, etag, lastModified); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
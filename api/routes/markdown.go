@@ -0,0 +1,62 @@
+package routes
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/samluiz/blog/api/parsers"
+	"github.com/samluiz/blog/pkg/config"
+)
+
+const CSS_CONTENT_TYPE = "text/css; charset=utf-8"
+
+// short articles are read in one go, the table of contents only shows up when there is something to navigate
+const (
+	TOC_MIN_HEADINGS = 3
+	TOC_MIN_WORDS    = 600
+)
+
+// how articles are rendered on the site, set by the highlight config
+func markdownOptions() parsers.Options {
+	cfg := config.LoadHighlightConfig()
+
+	return parsers.Options{
+		Highlight:      cfg.Enabled,
+		Theme:          cfg.Theme,
+		LineNumbers:    cfg.LineNumbers,
+		HeadingAnchors: true,
+		Extensions:     parsers.ALL_EXTENSIONS,
+	}
+}
+
+// colors of the code blocks highlighted by the server, in the configured theme
+func (r *router) HighlightCSS(c *fiber.Ctx) error {
+	css := parsers.HighlightCSS(config.LoadHighlightConfig().Theme)
+
+	return sendConditional(c, []byte(css), CSS_CONTENT_TYPE, time.Time{})
+}
+
+// counts every heading of the table of contents, nested ones included
+func countHeadings(toc []*parsers.Heading) int {
+	count := len(toc)
+
+	for _, h := range toc {
+		count += countHeadings(h.Children)
+	}
+
+	return count
+}
+
+func showTOC(doc parsers.Document) bool {
+	return countHeadings(doc.TOC) >= TOC_MIN_HEADINGS && doc.WordCount >= TOC_MIN_WORDS
+}
+
+// link previews need an absolute url, images of the site itself are written as paths
+func previewImage(image string) string {
+	if strings.HasPrefix(image, "/") && !strings.HasPrefix(image, "//") {
+		return config.SiteURL() + image
+	}
+
+	return image
+}
@@ -0,0 +1,47 @@
+package routes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/samluiz/blog/api/parsers"
+)
+
+func TestShowTOC(t *testing.T) {
+	long := strings.Repeat("word ", TOC_MIN_WORDS)
+
+	tests := []struct {
+		name string
+		md   string
+		want bool
+	}{
+		{name: "long with nested headings", md: "# a\n\n## b\n\n### c\n\n" + long, want: true},
+		{name: "too few headings", md: "# a\n\n## b\n\n" + long},
+		{name: "too short", md: "# a\n\n## b\n\n## c\n\nsome words"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := showTOC(parsers.ParseMarkdown([]byte(tt.md), parsers.Options{})); got != tt.want {
+				t.Errorf("showTOC() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreviewImage(t *testing.T) {
+	t.Setenv("SITE_URL", "https://example.com/")
+
+	tests := map[string]string{
+		"":                        "",
+		"/static/cover.png":       "https://example.com/static/cover.png",
+		"https://cdn.test/a.png":  "https://cdn.test/a.png",
+		"//cdn.test/protocol.png": "//cdn.test/protocol.png",
+	}
+
+	for image, want := range tests {
+		if got := previewImage(image); got != want {
+			t.Errorf("previewImage(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
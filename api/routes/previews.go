@@ -0,0 +1,183 @@
+package routes
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/samluiz/blog/api/content"
+	"github.com/samluiz/blog/pkg/config"
+	"github.com/samluiz/blog/pkg/types"
+)
+
+const PREVIEW_URL = "/preview/"
+
+const (
+	PREVIEW_ACTIVE  = "active"
+	PREVIEW_EXPIRED = "expired"
+	PREVIEW_REVOKED = "revoked"
+)
+
+// a preview as the dashboard lists it, with the link to share
+type previewLink struct {
+	*types.ArticlePreview
+	URL    string
+	Status string
+}
+
+// renders the draft the token was signed for. reviewers don't log in, the link is all they need
+func (r *router) PreviewPage(c *fiber.Ctx) error {
+	preview, err := r.previewService.ResolvePreview(c.Params("token"))
+
+	if err != nil {
+		switch {
+		case errors.Is(err, types.ErrPreviewExpired), errors.Is(err, types.ErrPreviewRevoked):
+			return c.Status(fiber.StatusGone).Render("pages/not-found", fiber.Map{
+				"PageTitle": "preview unavailable",
+				"Message":   "This preview link has expired or was revoked. Ask the author for a new one.",
+				"NoIndex":   true,
+			})
+		case errors.Is(err, types.ErrInvalidPreviewToken), errors.Is(err, types.ErrPreviewNotFound):
+			return r.renderNotFound(c)
+		}
+		return err
+	}
+
+	article, err := r.articleService.FindArticleById(preview.ArticleID)
+
+	if err != nil {
+		if errors.Is(err, types.ErrArticleNotFound) {
+			return r.renderNotFound(c)
+		}
+		return err
+	}
+
+	if article.DeletedAt != nil {
+		return r.renderNotFound(c)
+	}
+
+	// once published there's nothing left to review, and the article has its own url
+	if article.IsPublished {
+		return c.Redirect("/articles/" + article.Slug)
+	}
+
+	session, err := r.store.Get(c)
+
+	if err != nil {
+		LOGGER.Error("error getting session: %v", err)
+	}
+
+	response := content.ToArticleResponse(article)
+
+	data := articlePageData(&response)
+	data["IsLogged"] = session.Get(IS_LOGGED)
+	data["User"] = session.Get("user")
+	data["IsPreview"] = true
+	data["PreviewExpiresAt"] = preview.ExpiresAt
+	data["NoIndex"] = true
+	data["Route"] = "preview/" + c.Params("token")
+
+	// the draft can change or the link be revoked at any time, so nothing keeps a copy
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	c.Set("X-Robots-Tag", "noindex")
+
+	return c.Render("pages/article", data)
+}
+
+// lists the preview links of the article. it's loaded by htmx inside the article editor
+func (r *router) AdminArticlePreviewsPartial(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+
+	return r.renderArticlePreviews(c, id, "")
+}
+
+func (r *router) AdminCreateArticlePreview(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+
+	article, err := r.articleService.FindArticleById(id)
+
+	if err != nil {
+		if errors.Is(err, types.ErrArticleNotFound) {
+			return fiber.ErrNotFound
+		}
+		return err
+	}
+
+	if article.IsPublished {
+		return r.renderArticlePreviews(c, id, "Published articles can be shared with their own link.")
+	}
+
+	if _, _, err := r.previewService.CreatePreview(id); err != nil {
+		LOGGER.Error(err.Error())
+		return r.renderArticlePreviews(c, id, "Error while creating the preview link. Please try again.")
+	}
+
+	return r.renderArticlePreviews(c, id, "")
+}
+
+func (r *router) AdminRevokeArticlePreview(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+
+	previewId, err := c.ParamsInt("previewId")
+
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+
+	if err := r.previewService.RevokePreview(previewId, id); err != nil {
+		if errors.Is(err, types.ErrPreviewNotFound) {
+			return r.renderArticlePreviews(c, id, "This preview link doesn't exist anymore.")
+		}
+		LOGGER.Error(err.Error())
+		return r.renderArticlePreviews(c, id, "Error while revoking the preview link. Please try again.")
+	}
+
+	return r.renderArticlePreviews(c, id, "")
+}
+
+func (r *router) renderArticlePreviews(c *fiber.Ctx, articleId int, message string) error {
+	previews, err := r.previewService.FindPreviewsByArticleId(articleId)
+
+	if err != nil {
+		LOGGER.Error(err.Error())
+	}
+
+	return c.Render("partials/article-previews", fiber.Map{
+		"ArticleID": articleId,
+		"Previews":  r.previewLinks(previews, time.Now()),
+		"Message":   message,
+		"Error":     err,
+	}, "")
+}
+
+func (r *router) previewLinks(previews []*types.ArticlePreview, now time.Time) []previewLink {
+	links := make([]previewLink, 0, len(previews))
+
+	siteURL := config.SiteURL()
+
+	for _, p := range previews {
+		status := PREVIEW_ACTIVE
+
+		if p.IsRevoked() {
+			status = PREVIEW_REVOKED
+		} else if p.IsExpired(now) {
+			status = PREVIEW_EXPIRED
+		}
+
+		links = append(links, previewLink{p, siteURL + PREVIEW_URL + r.previewService.Token(p), status})
+	}
+
+	return links
+}
@@ -0,0 +1,188 @@
+package routes
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/samluiz/blog/common/diff"
+	"github.com/samluiz/blog/pkg/types"
+)
+
+// unchanged lines kept around each change, the rest is collapsed
+const REVISION_DIFF_CONTEXT = 3
+
+// a revision as the dashboard lists it, numbered from the oldest
+type revisionRow struct {
+	*types.ArticleRevision
+	Number    int
+	IsCurrent bool
+}
+
+// a line of the diff between two revisions. collapsed lines are only counted
+type diffLine struct {
+	Op        diff.Op
+	Text      string
+	Collapsed int
+}
+
+func (r *router) AdminArticleRevisionsPage(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+
+	if err != nil {
+		return r.renderNotFound(c)
+	}
+
+	article, err := r.articleService.FindArticleById(id)
+
+	if err != nil {
+		if errors.Is(err, types.ErrArticleNotFound) {
+			return r.renderNotFound(c)
+		}
+		return err
+	}
+
+	if article.Source == types.SOURCE_DEVTO {
+		return fiber.NewError(fiber.StatusForbidden, types.ErrImportedArticle.Error())
+	}
+
+	revisions, err := r.articleService.FindRevisionsByArticleId(id)
+
+	if err != nil {
+		return err
+	}
+
+	session, err := r.store.Get(c)
+
+	if err != nil {
+		LOGGER.Error("error getting session: %v", err)
+	}
+
+	rows := make([]revisionRow, 0, len(revisions))
+
+	for i, revision := range revisions {
+		rows = append(rows, revisionRow{revision, len(revisions) - i, i == 0})
+	}
+
+	return c.Render("pages/article-revisions", fiber.Map{
+		"IsLogged":  session.Get(IS_LOGGED),
+		"User":      session.Get("user"),
+		"Article":   article,
+		"Revisions": rows,
+		"PageTitle": "revisions of " + article.Title,
+	})
+}
+
+// the changes from one revision to another, loaded by htmx when they're picked in the list
+func (r *router) AdminArticleRevisionsDiffPartial(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+
+	from, err := r.findRevisionParam(c, id, "from")
+
+	if err != nil {
+		return err
+	}
+
+	to, err := r.findRevisionParam(c, id, "to")
+
+	if err != nil {
+		return err
+	}
+
+	return c.Render("partials/revision-diff", fiber.Map{
+		"From":  from,
+		"To":    to,
+		"Lines": collapseUnchanged(diff.Lines(from.Content, to.Content), REVISION_DIFF_CONTEXT),
+	}, "")
+}
+
+func (r *router) AdminRestoreArticleRevision(c *fiber.Ctx) error {
+	user, ok := r.sessionUser(c)
+
+	if !ok {
+		return fiber.ErrUnauthorized
+	}
+
+	id, err := c.ParamsInt("id")
+
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+
+	revisionId, err := c.ParamsInt("revisionId")
+
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+
+	if _, err := r.articleService.RestoreRevision(id, revisionId, user.ID); err != nil {
+		switch {
+		case errors.Is(err, types.ErrRevisionNotFound), errors.Is(err, types.ErrArticleNotFound):
+			return fiber.ErrNotFound
+		case errors.Is(err, types.ErrImportedArticle):
+			return fiber.NewError(fiber.StatusForbidden, err.Error())
+		}
+		return err
+	}
+
+	r.invalidateLocalContent()
+
+	c.Set("HX-Redirect", DASHBOARD_URL+"/articles/"+strconv.Itoa(id)+"/revisions")
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (r *router) findRevisionParam(c *fiber.Ctx, articleId int, name string) (*types.ArticleRevision, error) {
+	revisionId, err := strconv.Atoi(c.Query(name))
+
+	if err != nil {
+		return nil, fiber.ErrBadRequest
+	}
+
+	revision, err := r.articleService.FindRevisionById(articleId, revisionId)
+
+	if errors.Is(err, types.ErrRevisionNotFound) {
+		return nil, fiber.ErrNotFound
+	}
+
+	return revision, err
+}
+
+// keeps the changed lines with the unchanged ones around them, replacing longer unchanged runs with how many lines they have
+func collapseUnchanged(lines []diff.Line, context int) []diffLine {
+	keep := make([]bool, len(lines))
+
+	for i, line := range lines {
+		if line.Op == diff.EQUAL {
+			continue
+		}
+
+		for j := max(0, i-context); j <= min(len(lines)-1, i+context); j++ {
+			keep[j] = true
+		}
+	}
+
+	var collapsed []diffLine
+
+	for i := 0; i < len(lines); {
+		if keep[i] {
+			collapsed = append(collapsed, diffLine{Op: lines[i].Op, Text: lines[i].Text})
+			i++
+			continue
+		}
+
+		start := i
+
+		for i < len(lines) && !keep[i] {
+			i++
+		}
+
+		collapsed = append(collapsed, diffLine{Op: diff.EQUAL, Collapsed: i - start})
+	}
+
+	return collapsed
+}
@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/samluiz/blog/common/diff"
+)
+
+func TestCollapseUnchanged(t *testing.T) {
+	var lines []diff.Line
+
+	for _, text := range []string{"1", "2", "3", "4", "5", "6"} {
+		lines = append(lines, diff.Line{Op: diff.EQUAL, Text: text})
+	}
+
+	lines = append(lines, diff.Line{Op: diff.DELETE, Text: "old"}, diff.Line{Op: diff.INSERT, Text: "new"}, diff.Line{Op: diff.EQUAL, Text: "7"})
+
+	want := []diffLine{
+		{Op: diff.EQUAL, Collapsed: 4},
+		{Op: diff.EQUAL, Text: "5"},
+		{Op: diff.EQUAL, Text: "6"},
+		{Op: diff.DELETE, Text: "old"},
+		{Op: diff.INSERT, Text: "new"},
+		{Op: diff.EQUAL, Text: "7"},
+	}
+
+	if got := collapseUnchanged(lines, 2); !reflect.DeepEqual(got, want) {
+		t.Errorf("collapseUnchanged() = %v, want %v", got, want)
+	}
+}
@@ -4,14 +4,24 @@ import (
 	"errors"
 	"html/template"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/session"
+	"github.com/samluiz/blog/api/content"
 	"github.com/samluiz/blog/api/integrations"
+	"github.com/samluiz/blog/api/jobs"
 	"github.com/samluiz/blog/api/parsers"
 	apiTypes "github.com/samluiz/blog/api/types"
+	"github.com/samluiz/blog/common/cache"
 	"github.com/samluiz/blog/common/logger"
+	"github.com/samluiz/blog/common/pagination"
 	"github.com/samluiz/blog/common/providers"
+	"github.com/samluiz/blog/pkg/article"
+	"github.com/samluiz/blog/pkg/comment"
+	"github.com/samluiz/blog/pkg/preview"
 	"github.com/samluiz/blog/pkg/types"
 	"github.com/samluiz/blog/pkg/user"
 	"golang.org/x/crypto/bcrypt"
@@ -19,6 +29,12 @@ import (
 
 const IS_LOGGED = "is_logged"
 const DASHBOARD_URL = "/dashboard"
+const GITHUB_USERNAME = "samluiz"
+const GITHUB_PROFILE_CACHE_KEY = "github:profile:" + GITHUB_USERNAME
+const LOGIN_REDIRECT = "login_redirect"
+const ARTICLES_PER_PAGE = 10
+const ARTICLES_PAGE_LINKS_RADIUS = 2
+const LOAD_MORE_MODE = "more"
 
 var LOGGER = logger.New(os.Stdout, logger.DebugLevel, "[ROUTER]")
 
@@ -34,20 +50,80 @@ type Router interface {
 	GithubCallback(c *fiber.Ctx) error
 	NotFoundPage(c *fiber.Ctx) error
 	ErrorPage(c *fiber.Ctx) error
+	AdminNewArticlePage(c *fiber.Ctx) error
+	AdminEditArticlePage(c *fiber.Ctx) error
+	AdminCreateArticle(c *fiber.Ctx) error
+	AdminUpdateArticle(c *fiber.Ctx) error
+	AdminPreviewArticle(c *fiber.Ctx) error
+	AdminPublishArticle(c *fiber.Ctx) error
+	AdminUnpublishArticle(c *fiber.Ctx) error
+	AdminScheduleArticle(c *fiber.Ctx) error
+	AdminUnscheduleArticle(c *fiber.Ctx) error
+	AdminDeleteArticle(c *fiber.Ctx) error
+	AdminCrossPostArticle(c *fiber.Ctx) error
+	AdminInvalidateCache(c *fiber.Ctx) error
+	AdminSyncDevTo(c *fiber.Ctx) error
+	APIListArticles(c *fiber.Ctx) error
+	APIGetArticle(c *fiber.Ctx) error
+	APICreateArticle(c *fiber.Ctx) error
+	APIUpdateArticle(c *fiber.Ctx) error
+	APIPublishArticle(c *fiber.Ctx) error
+	APIUnpublishArticle(c *fiber.Ctx) error
+	APIDeleteArticle(c *fiber.Ctx) error
+	APISyncDevTo(c *fiber.Ctx) error
+	AdminCommentsPage(c *fiber.Ctx) error
+	AdminCommentsPartial(c *fiber.Ctx) error
+	AdminModerateComments(c *fiber.Ctx) error
+	RSSFeed(c *fiber.Ctx) error
+	AtomFeed(c *fiber.Ctx) error
+	JSONFeed(c *fiber.Ctx) error
+	Sitemap(c *fiber.Ctx) error
+	SitemapPage(c *fiber.Ctx) error
+	Robots(c *fiber.Ctx) error
+	TagRSSFeed(c *fiber.Ctx) error
+	TagsPage(c *fiber.Ctx) error
+	TagPage(c *fiber.Ctx) error
+	SearchPage(c *fiber.Ctx) error
+	SearchResults(c *fiber.Ctx) error
+	HighlightCSS(c *fiber.Ctx) error
+	CommentsPartial(c *fiber.Ctx) error
+	CreateComment(c *fiber.Ctx) error
+	UpdateComment(c *fiber.Ctx) error
+	DeleteComment(c *fiber.Ctx) error
+	PreviewPage(c *fiber.Ctx) error
+	AdminArticlePreviewsPartial(c *fiber.Ctx) error
+	AdminCreateArticlePreview(c *fiber.Ctx) error
+	AdminRevokeArticlePreview(c *fiber.Ctx) error
+	AdminArticleRevisionsPage(c *fiber.Ctx) error
+	AdminArticleRevisionsDiffPartial(c *fiber.Ctx) error
+	AdminRestoreArticleRevision(c *fiber.Ctx) error
+	AdminTrashPage(c *fiber.Ctx) error
+	AdminTrashPartial(c *fiber.Ctx) error
+	AdminRestoreTrashedArticle(c *fiber.Ctx) error
+	AdminRestoreTrashedComment(c *fiber.Ctx) error
 }
 
 type router struct {
-	app         *fiber.App
-	store       *session.Store
-	userService user.Service
+	app            *fiber.App
+	store          *session.Store
+	userService    user.Service
+	articleService article.Service
+	commentService comment.Service
+	contentSource  content.ContentSource
+	cache          *cache.Cache
+	devToSync      jobs.DevToSync
+	crossPoster    jobs.CrossPoster
+	previewService preview.Service
+	// how long deleted articles and comments stay in the trash
+	trashRetention time.Duration
 }
 
-func NewRouter(app *fiber.App, store *session.Store, userService user.Service) Router {
-	return &router{app, store, userService}
+func NewRouter(app *fiber.App, store *session.Store, userService user.Service, articleService article.Service, commentService comment.Service, contentSource content.ContentSource, cache *cache.Cache, devToSync jobs.DevToSync, crossPoster jobs.CrossPoster, previewService preview.Service, trashRetention time.Duration) Router {
+	return &router{app, store, userService, articleService, commentService, contentSource, cache, devToSync, crossPoster, previewService, trashRetention}
 }
 
 func (r *router) HomePage(c *fiber.Ctx) error {
-	articles, err := integrations.GetArticlesFromDevTo(1, 3)
+	articles, err := r.contentSource.ListArticles(1, 3)
 
 	if err != nil {
 		LOGGER.Error(err.Error())
@@ -62,11 +138,13 @@ func (r *router) HomePage(c *fiber.Ctx) error {
 	isLogged := session.Get(IS_LOGGED)
 	user := session.Get("user")
 
-	bio, err := integrations.GetGithubBio("samluiz")
+	bio := "Software Engineer."
+	profile, err := r.githubProfile()
 
 	if err != nil {
 		LOGGER.Error(err.Error())
-		bio = "Software Engineer."
+	} else if profile.Bio != "" {
+		bio = profile.Bio
 	}
 
 	return c.Render("pages/home", fiber.Map{
@@ -81,45 +159,67 @@ func (r *router) HomePage(c *fiber.Ctx) error {
 	})
 }
 
+// the github profile of the blog author, shown on the home page and in the feeds
+func (r *router) githubProfile() (*apiTypes.GithubUserResponse, error) {
+	return cache.GetAs(r.cache, GITHUB_PROFILE_CACHE_KEY, func() (*apiTypes.GithubUserResponse, error) {
+		return integrations.GetGithubProfile(GITHUB_USERNAME)
+	})
+}
+
 func (r *router) ArticlePage(c *fiber.Ctx) error {
 	slug := c.Params("slug")
 
-	article, err := integrations.GetArticleBySlugDevTo(slug)
+	article, err := r.contentSource.GetArticle(slug)
 
-	if err != nil {
-		LOGGER.Error(err.Error())
-	}
+	session, sessionErr := r.store.Get(c)
 
-	markdownContent := template.HTML(parsers.MarkdownToHTML([]byte(article.BodyMarkdown)))
+	if sessionErr != nil {
+		LOGGER.Error("error getting session: %v", sessionErr)
+	}
 
-	session, err := r.store.Get(c)
+	isLogged := session.Get(IS_LOGGED)
+	user := session.Get("user")
 
 	if err != nil {
-		LOGGER.Error("error getting session: %v", err)
+		if !errors.Is(err, types.ErrArticleNotFound) {
+			LOGGER.Error(err.Error())
+		} else {
+			err = nil
+		}
+
+		return c.Render("pages/article", fiber.Map{
+			"IsLogged":  isLogged,
+			"User":      user,
+			"PageTitle": "article not found",
+			"Route":     "articles/" + slug,
+			"Error":     err,
+		})
 	}
 
-	isLogged := session.Get(IS_LOGGED)
-	user := session.Get("user")
+	data := articlePageData(article)
+	data["IsLogged"] = isLogged
+	data["User"] = user
+
+	return c.Render("pages/article", data)
+}
 
-	return c.Render("pages/article", fiber.Map{
+// what pages/article needs to show the article, without the session
+func articlePageData(article *apiTypes.ArticleResponse) fiber.Map {
+	doc := parsers.ParseMarkdown([]byte(article.BodyMarkdown), markdownOptions())
+
+	return fiber.Map{
 		"Article":     article,
-		"IsLogged":    isLogged,
-		"User":        user,
-		"Markdown":    markdownContent,
+		"Markdown":    template.HTML(doc.HTML),
+		"TOC":         doc.TOC,
+		"ShowTOC":     showTOC(doc),
+		"Image":       previewImage(doc.FirstImage),
 		"PageTitle":   article.Title,
 		"Description": article.Description,
 		"Route":       "articles/" + article.Slug,
-		"Error":       err,
-	})
+	}
 }
 
 func (r *router) ArticlesPage(c *fiber.Ctx) error {
-	articles, err := integrations.GetArticlesFromDevTo(1, 10)
-
-	if err != nil {
-		LOGGER.Error(err.Error())
-	}
-
 	session, err := r.store.Get(c)
 
 	if err != nil {
@@ -129,8 +229,34 @@ func (r *router) ArticlesPage(c *fiber.Ctx) error {
 	isLogged := session.Get(IS_LOGGED)
 	user := session.Get("user")
 
+	p := pagination.Pagination{Page: c.QueryInt("page", 1), Size: c.QueryInt("per_page", ARTICLES_PER_PAGE)}
+
+	all, err := r.allArticles()
+
+	if err != nil {
+		LOGGER.Error(err.Error())
+	}
+
+	articles, page, pageErr := paginateArticles(all, p, "/articles")
+
+	// answered in place instead of redirecting to the error page, so crawlers see the status of the url they asked for
+	if pageErr != nil {
+		if errors.Is(pageErr, pagination.ErrPageOutOfRange) {
+			return r.renderNotFound(c)
+		}
+		return c.Status(fiber.StatusBadRequest).SendString(pageErr.Error())
+	}
+
+	if c.Query("mode") == LOAD_MORE_MODE {
+		return c.Render("partials/articles-more", fiber.Map{
+			"Articles": articles,
+			"Page":     page,
+		}, "")
+	}
+
 	return c.Render("pages/articles", fiber.Map{
 		"Articles":    articles,
+		"Page":        page,
 		"IsLogged":    isLogged,
 		"User":        user,
 		"PageTitle":   "articles",
@@ -140,14 +266,73 @@ func (r *router) ArticlesPage(c *fiber.Ctx) error {
 	})
 }
 
-func (r *router) LoginPage(c *fiber.Ctx) error {
+// every published article, newest first
+func (r *router) allArticles() ([]apiTypes.ArticleResponse, error) {
+	return content.ListAll(r.contentSource)
+}
+
+// a page of a public list of articles, with the links to the other pages
+type articlesPage struct {
+	listPage
+	Path string
+	// already encoded query parameters the links must keep, like the search terms
+	Params  string
+	PerPage int
+	Numbers []int
+}
+
+// keeps per_page in the links only when it was changed, so the default urls stay short
+func (p articlesPage) URL(page int) string {
+	url := p.Path + "?"
+
+	if p.Params != "" {
+		url += p.Params + "&"
+	}
 
-	redirect := c.Query("redirect")
+	url += "page=" + strconv.Itoa(page)
 
-	if redirect == "" || redirect == "/auth/login" {
-		redirect = "/"
+	if p.PerPage != ARTICLES_PER_PAGE {
+		url += "&per_page=" + strconv.Itoa(p.PerPage)
 	}
 
+	return url
+}
+
+func (p articlesPage) LoadMoreURL() string {
+	return p.URL(p.Next()) + "&mode=" + LOAD_MORE_MODE
+}
+
+func paginateArticles(articles []apiTypes.ArticleResponse, p pagination.Pagination, path string) ([]apiTypes.ArticleResponse, articlesPage, error) {
+	offset, limit, totalPages, _, _, err := p.GetValues(len(articles))
+
+	if err != nil {
+		return nil, articlesPage{}, err
+	}
+
+	page := p.Page
+
+	if page < 1 {
+		page = 1
+	}
+
+	end := offset + limit
+
+	if end > len(articles) {
+		end = len(articles)
+	}
+
+	return articles[offset:end], articlesPage{
+		listPage: listPage{page, totalPages},
+		Path:     path,
+		PerPage:  limit,
+		Numbers:  pagination.Window(page, totalPages, ARTICLES_PAGE_LINKS_RADIUS),
+	}, nil
+}
+
+func (r *router) LoginPage(c *fiber.Ctx) error {
+
+	redirect := safeRedirect(c.Query("redirect"))
+
 	session, err := r.store.Get(c)
 
 	if err != nil {
@@ -162,6 +347,13 @@ func (r *router) LoginPage(c *fiber.Ctx) error {
 		return c.Redirect(DASHBOARD_URL)
 	}
 
+	// github only sends us back to the callback, so the page to return to is kept in the session
+	session.Set(LOGIN_REDIRECT, redirect)
+
+	if err := session.Save(); err != nil {
+		LOGGER.Error("error saving session: %v", err)
+	}
+
 	githubUrl := integrations.GetGithubAuthURL()
 
 	return c.Render("pages/login", fiber.Map{
@@ -171,27 +363,6 @@ func (r *router) LoginPage(c *fiber.Ctx) error {
 	})
 }
 
-func (r *router) AdminDashboardPage(c *fiber.Ctx) error {
-	session, err := r.store.Get(c)
-
-	if err != nil {
-		LOGGER.Error("error getting session: %v", err)
-	}
-
-	isLogged := session.Get(IS_LOGGED)
-	user := session.Get("user")
-
-	return c.Render("pages/dashboard", fiber.Map{
-		"IsLogged": isLogged,
-		"User":     user,
-	})
-}
-
-func (r *router) AdminArticlesPartial(c *fiber.Ctx) error {
-
-	return c.SendFile("views/partials/articles.html")
-}
-
 func (r *router) Authenticate(c *fiber.Ctx) error {
 	username := c.FormValue("username")
 	password := c.FormValue("password")
@@ -238,7 +409,7 @@ func (r *router) Authenticate(c *fiber.Ctx) error {
 	}
 
 	res := c.Response()
-	res.Header.Add("HX-Redirect", c.Get("X-Redirect"))
+	res.Header.Add("HX-Redirect", safeRedirect(c.Get("X-Redirect")))
 
 	return c.SendStatus(fiber.StatusOK)
 }
@@ -318,6 +489,9 @@ func (r *router) GithubCallback(c *fiber.Ctx) error {
 	session.Set("github_token", githubResponse.AccessToken)
 	session.Set(IS_LOGGED, true)
 
+	redirect, _ := session.Get(LOGIN_REDIRECT).(string)
+	session.Delete(LOGIN_REDIRECT)
+
 	err = session.Save()
 
 	if err != nil {
@@ -325,7 +499,13 @@ func (r *router) GithubCallback(c *fiber.Ctx) error {
 		return c.Redirect("/auth/login")
 	}
 
-	return c.Redirect("/")
+	return c.Redirect(safeRedirect(redirect))
+}
+
+func (r *router) renderNotFound(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusNotFound).Render("pages/not-found", fiber.Map{
+		"PageTitle": "404",
+	})
 }
 
 func (r *router) NotFoundPage(c *fiber.Ctx) error {
@@ -342,3 +522,12 @@ func (r *router) ErrorPage(c *fiber.Ctx) error {
 		"HttpStatus": httpStatus,
 	})
 }
+
+// only local paths are followed after logging in, so the login page can't be used to send users to other sites
+func safeRedirect(redirect string) string {
+	if !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") || strings.HasPrefix(redirect, "/\\") || redirect == "/auth/login" {
+		return "/"
+	}
+
+	return redirect
+}
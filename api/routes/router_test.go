@@ -0,0 +1,76 @@
+package routes
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	apiTypes "github.com/samluiz/blog/api/types"
+	"github.com/samluiz/blog/common/pagination"
+)
+
+func numberedArticles(n int) []apiTypes.ArticleResponse {
+	articles := make([]apiTypes.ArticleResponse, 0, n)
+
+	for i := 1; i <= n; i++ {
+		articles = append(articles, apiTypes.ArticleResponse{Slug: strconv.Itoa(i)})
+	}
+
+	return articles
+}
+
+func TestPaginateArticles(t *testing.T) {
+	tests := []struct {
+		name       string
+		pagination pagination.Pagination
+		total      int
+		first      string
+		count      int
+		totalPages int
+		err        error
+	}{
+		{"first page", pagination.Pagination{Page: 1, Size: 10}, 25, "1", 10, 3, nil},
+		{"last page", pagination.Pagination{Page: 3, Size: 10}, 25, "21", 5, 3, nil},
+		{"default size", pagination.Pagination{Page: 2}, 25, "11", 10, 3, nil},
+		{"empty blog", pagination.Pagination{Page: 1, Size: 10}, 0, "", 0, 0, nil},
+		{"after the last page", pagination.Pagination{Page: 4, Size: 10}, 25, "", 0, 0, pagination.ErrPageOutOfRange},
+		{"negative page", pagination.Pagination{Page: -1, Size: 10}, 25, "", 0, 0, pagination.ErrPageOutOfRange},
+		{"size too big", pagination.Pagination{Page: 1, Size: pagination.MAX_SIZE + 1}, 25, "", 0, 0, pagination.ErrSizeOutOfRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			articles, page, err := paginateArticles(numberedArticles(tt.total), tt.pagination, "/articles")
+
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v, got %v", tt.err, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if len(articles) != tt.count || page.TotalPages != tt.totalPages {
+				t.Fatalf("expected %d articles in %d pages, got %d in %d", tt.count, tt.totalPages, len(articles), page.TotalPages)
+			}
+
+			if tt.count > 0 && articles[0].Slug != tt.first {
+				t.Errorf("expected the page to start at %s, got %s", tt.first, articles[0].Slug)
+			}
+		})
+	}
+}
+
+func TestArticlesPageURL(t *testing.T) {
+	page := articlesPage{listPage: listPage{2, 5}, Path: "/articles", PerPage: ARTICLES_PER_PAGE}
+
+	if got := page.URL(3); got != "/articles?page=3" {
+		t.Errorf("expected the default size to be left out, got %s", got)
+	}
+
+	page.PerPage = 5
+
+	if got := page.LoadMoreURL(); got != "/articles?page=3&per_page=5&mode=more" {
+		t.Errorf("expected the next page in load more mode, got %s", got)
+	}
+}
@@ -0,0 +1,148 @@
+package routes
+
+import (
+	"errors"
+	"html/template"
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/samluiz/blog/common/date"
+	"github.com/samluiz/blog/common/pagination"
+	"github.com/samluiz/blog/pkg/types"
+)
+
+const (
+	SEARCH_PAGE_SIZE  = ARTICLES_PER_PAGE
+	MAX_SEARCH_LENGTH = 200
+)
+
+// an article found by a search, with the matched words marked
+type searchResult struct {
+	Slug        string
+	Title       template.HTML
+	Snippet     template.HTML
+	PublishedAt string
+	TagList     []string
+}
+
+func (r *router) SearchPage(c *fiber.Ctx) error {
+	session, err := r.store.Get(c)
+
+	if err != nil {
+		LOGGER.Error("error getting session: %v", err)
+	}
+
+	query := searchTerms(c.Query("q"))
+
+	results, page, err := r.search(query, c.QueryInt("page", 1))
+
+	if err != nil {
+		if errors.Is(err, pagination.ErrPageOutOfRange) {
+			return r.renderNotFound(c)
+		}
+		LOGGER.Error("error searching for %q: %v", query, err)
+	}
+
+	return c.Render("pages/search", fiber.Map{
+		"Query":       query,
+		"Results":     results,
+		"Page":        page,
+		"IsLogged":    session.Get(IS_LOGGED),
+		"User":        session.Get("user"),
+		"PageTitle":   "search",
+		"Description": "Search the articles.",
+		"Route":       "search",
+		"Error":       err,
+	})
+}
+
+// the first page of results, swapped in by htmx while the reader types
+func (r *router) SearchResults(c *fiber.Ctx) error {
+	query := searchTerms(c.Query("q"))
+
+	results, page, err := r.search(query, 1)
+
+	if err != nil {
+		LOGGER.Error("error searching for %q: %v", query, err)
+	}
+
+	// the address bar follows the search, so it can be shared and survives a reload
+	c.Set("HX-Push-Url", searchURL(query))
+
+	return c.Render("partials/search-results", fiber.Map{
+		"Query":   query,
+		"Results": results,
+		"Page":    page,
+		"Error":   err,
+	}, "")
+}
+
+func (r *router) search(query string, page int) ([]searchResult, articlesPage, error) {
+	if page < 1 {
+		page = 1
+	}
+
+	found, totalPages, err := r.articleService.SearchPublishedArticles(query, pagination.Pagination{Page: page, Size: SEARCH_PAGE_SIZE})
+
+	if err != nil {
+		return nil, articlesPage{}, err
+	}
+
+	results := make([]searchResult, 0, len(found))
+
+	for _, a := range found {
+		result := searchResult{
+			Slug:    a.Slug,
+			Title:   highlightMatches(a.TitleHighlight),
+			Snippet: highlightMatches(a.Snippet),
+			TagList: []string{},
+		}
+
+		if a.Tags != "" {
+			result.TagList = strings.Split(a.Tags, ",")
+		}
+
+		if a.PublishedAt != nil {
+			result.PublishedAt = date.FormatTime(*a.PublishedAt)
+		}
+
+		results = append(results, result)
+	}
+
+	return results, articlesPage{
+		listPage: listPage{page, totalPages},
+		Path:     "/search",
+		Params:   "q=" + url.QueryEscape(query),
+		PerPage:  SEARCH_PAGE_SIZE,
+		Numbers:  pagination.Window(page, totalPages, ARTICLES_PAGE_LINKS_RADIUS),
+	}, nil
+}
+
+// cutting the query can split a character in half, which is dropped
+func searchTerms(query string) string {
+	query = strings.ToValidUTF8(strings.TrimSpace(query), "")
+
+	if len(query) > MAX_SEARCH_LENGTH {
+		query = strings.ToValidUTF8(query[:MAX_SEARCH_LENGTH], "")
+	}
+
+	return query
+}
+
+func searchURL(query string) string {
+	if query == "" {
+		return "/search"
+	}
+
+	return "/search?q=" + url.QueryEscape(query)
+}
+
+// escapes the highlighted text and only then turns the match markers into html, so the article can't inject markup
+func highlightMatches(highlighted string) template.HTML {
+	escaped := template.HTMLEscapeString(highlighted)
+	escaped = strings.ReplaceAll(escaped, types.SEARCH_MATCH_START, "<mark>")
+	escaped = strings.ReplaceAll(escaped, types.SEARCH_MATCH_END, "</mark>")
+
+	return template.HTML(escaped)
+}
@@ -0,0 +1,45 @@
+package routes
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/samluiz/blog/pkg/types"
+)
+
+func TestHighlightMatches(t *testing.T) {
+	highlighted := "<script>" + types.SEARCH_MATCH_START + "go" + types.SEARCH_MATCH_END + " & sql"
+
+	want := "&lt;script&gt;<mark>go</mark> &amp; sql"
+
+	if got := string(highlightMatches(highlighted)); got != want {
+		t.Errorf("highlightMatches() = %q, want %q", got, want)
+	}
+}
+
+func TestSearchTerms(t *testing.T) {
+	if got := searchTerms("  go  "); got != "go" {
+		t.Errorf("searchTerms() = %q, want the query trimmed", got)
+	}
+
+	long := strings.Repeat("a", MAX_SEARCH_LENGTH-1) + "é"
+
+	got := searchTerms(long)
+
+	if len(got) > MAX_SEARCH_LENGTH || !utf8.ValidString(got) {
+		t.Errorf("expected the query to be cut without splitting a character, got %d bytes", len(got))
+	}
+}
+
+func TestSearchPageURL(t *testing.T) {
+	page := articlesPage{listPage: listPage{1, 3}, Path: "/search", Params: "q=go+sql", PerPage: SEARCH_PAGE_SIZE}
+
+	if got := page.URL(2); got != "/search?q=go+sql&page=2" {
+		t.Errorf("URL() = %q", got)
+	}
+
+	if got := searchURL("go sql"); got != "/search?q=go+sql" {
+		t.Errorf("searchURL() = %q", got)
+	}
+}
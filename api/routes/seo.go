@@ -0,0 +1,139 @@
+package routes
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/samluiz/blog/api/seo"
+	"github.com/samluiz/blog/pkg/config"
+)
+
+const (
+	// the protocol allows 50000, smaller sitemaps are cheaper to refetch when one article changes
+	SITEMAP_SIZE          = 1000
+	SITEMAP_ARTICLES_PAGE = 100
+	SITEMAP_CONTENT_TYPE  = "application/xml; charset=utf-8"
+	ROBOTS_CONTENT_TYPE   = "text/plain; charset=utf-8"
+)
+
+// lists every page when they fit in one sitemap, otherwise the sitemaps they were split into
+func (r *router) Sitemap(c *fiber.Ctx) error {
+	urls, err := r.sitemapURLs()
+
+	if err != nil {
+		LOGGER.Error("error listing the articles of the sitemap: %v", err)
+		return c.SendStatus(fiber.StatusServiceUnavailable)
+	}
+
+	chunks := seo.Split(urls, SITEMAP_SIZE)
+
+	var body []byte
+
+	if len(chunks) <= 1 {
+		body, err = seo.Sitemap(urls)
+	} else {
+		siteURL := config.SiteURL()
+		sitemaps := make([]seo.URL, 0, len(chunks))
+
+		for i, chunk := range chunks {
+			sitemaps = append(sitemaps, seo.URL{Loc: siteURL + "/sitemaps/" + strconv.Itoa(i+1) + ".xml", LastMod: seo.LastMod(chunk)})
+		}
+
+		body, err = seo.SitemapIndex(sitemaps)
+	}
+
+	if err != nil {
+		LOGGER.Error("error encoding the sitemap: %v", err)
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+
+	return sendConditional(c, body, SITEMAP_CONTENT_TYPE, seo.LastMod(urls))
+}
+
+// one of the sitemaps listed by the index
+func (r *router) SitemapPage(c *fiber.Ctx) error {
+	page, err := strconv.Atoi(c.Params("page"))
+
+	if err != nil || page < 1 {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+
+	urls, err := r.sitemapURLs()
+
+	if err != nil {
+		LOGGER.Error("error listing the articles of the sitemap: %v", err)
+		return c.SendStatus(fiber.StatusServiceUnavailable)
+	}
+
+	chunks := seo.Split(urls, SITEMAP_SIZE)
+
+	if page > len(chunks) {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+
+	body, err := seo.Sitemap(chunks[page-1])
+
+	if err != nil {
+		LOGGER.Error("error encoding the sitemap: %v", err)
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+
+	return sendConditional(c, body, SITEMAP_CONTENT_TYPE, seo.LastMod(chunks[page-1]))
+}
+
+func (r *router) Robots(c *fiber.Ctx) error {
+	robots := config.LoadRobotsConfig()
+
+	c.Set(fiber.HeaderContentType, ROBOTS_CONTENT_TYPE)
+
+	return c.Send(seo.Robots(robots.Disallow, config.SiteURL()+"/sitemap.xml", robots.BlockAll))
+}
+
+// the home page, the articles and tags indexes, every tag and every published article. the index pages change
+// with the newest article they list
+func (r *router) sitemapURLs() ([]seo.URL, error) {
+	siteURL := config.SiteURL()
+	all, err := r.allArticles()
+
+	if err != nil {
+		return nil, err
+	}
+
+	articles := make([]seo.URL, 0, len(all))
+	tagsLastMod := map[string]time.Time{}
+
+	for _, a := range all {
+		lastMod := a.UpdatedAtTime
+
+		if lastMod.Before(a.PublishedAtTime) {
+			lastMod = a.PublishedAtTime
+		}
+
+		articles = append(articles, seo.URL{Loc: siteURL + "/articles/" + a.Slug, LastMod: lastMod})
+
+		for _, t := range a.TagList {
+			name := strings.ToLower(strings.TrimSpace(t))
+
+			if name != "" && lastMod.After(tagsLastMod[name]) {
+				tagsLastMod[name] = lastMod
+			}
+		}
+	}
+
+	newest := seo.LastMod(articles)
+
+	urls := []seo.URL{
+		{Loc: siteURL + "/", LastMod: newest},
+		{Loc: siteURL + "/articles", LastMod: newest},
+		{Loc: siteURL + "/tags", LastMod: newest},
+	}
+
+	for _, tag := range countTags(all) {
+		urls = append(urls, seo.URL{Loc: siteURL + "/tags/" + url.PathEscape(tag.Name), LastMod: tagsLastMod[tag.Name]})
+	}
+
+	return append(urls, articles...), nil
+}
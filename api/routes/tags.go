@@ -0,0 +1,145 @@
+package routes
+
+import (
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/samluiz/blog/api/content"
+	apiTypes "github.com/samluiz/blog/api/types"
+	"github.com/samluiz/blog/common/pagination"
+)
+
+type tagCount struct {
+	Name  string
+	Count int
+}
+
+func (r *router) TagsPage(c *fiber.Ctx) error {
+	session, err := r.store.Get(c)
+
+	if err != nil {
+		LOGGER.Error("error getting session: %v", err)
+	}
+
+	all, err := r.allArticles()
+
+	if err != nil {
+		LOGGER.Error(err.Error())
+	}
+
+	return c.Render("pages/tags", fiber.Map{
+		"Tags":        countTags(all),
+		"IsLogged":    session.Get(IS_LOGGED),
+		"User":        session.Get("user"),
+		"PageTitle":   "tags",
+		"Description": "Every tag of the articles, with how many articles have it.",
+		"Route":       "tags",
+		"Error":       err,
+	})
+}
+
+func (r *router) TagPage(c *fiber.Ctx) error {
+	tag, err := url.PathUnescape(c.Params("tag"))
+	tag = strings.ToLower(strings.TrimSpace(tag))
+
+	if err != nil || tag == "" {
+		return r.renderNotFound(c)
+	}
+
+	session, err := r.store.Get(c)
+
+	if err != nil {
+		LOGGER.Error("error getting session: %v", err)
+	}
+
+	p := pagination.Pagination{Page: c.QueryInt("page", 1), Size: c.QueryInt("per_page", ARTICLES_PER_PAGE)}
+
+	all, err := r.allArticlesByTag(tag)
+
+	if err != nil {
+		LOGGER.Error(err.Error())
+	}
+
+	// unknown tags don't have a page, but a failing source shouldn't look like one
+	if err == nil && len(all) == 0 {
+		return r.renderNotFound(c)
+	}
+
+	articles, page, pageErr := paginateArticles(all, p, "/tags/"+url.PathEscape(tag))
+
+	if pageErr != nil {
+		if errors.Is(pageErr, pagination.ErrPageOutOfRange) {
+			return r.renderNotFound(c)
+		}
+		return c.Status(fiber.StatusBadRequest).SendString(pageErr.Error())
+	}
+
+	if c.Query("mode") == LOAD_MORE_MODE {
+		return c.Render("partials/articles-more", fiber.Map{
+			"Articles": articles,
+			"Page":     page,
+		}, "")
+	}
+
+	return c.Render("pages/tag", fiber.Map{
+		"Tag":         tag,
+		"Articles":    articles,
+		"Page":        page,
+		"IsLogged":    session.Get(IS_LOGGED),
+		"User":        session.Get("user"),
+		"PageTitle":   "#" + tag,
+		"Description": "Articles tagged with #" + tag + ".",
+		"Route":       "tags",
+		"Error":       err,
+	})
+}
+
+// same as allArticles, only with the articles tagged with the tag. they are filtered from the cached list of every article,
+// so a tag nobody uses, typed in the url, doesn't make the sources scan their articles again
+func (r *router) allArticlesByTag(tag string) ([]apiTypes.ArticleResponse, error) {
+	all, err := r.allArticles()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return content.FilterByTag(all, tag), nil
+}
+
+// the tags of the articles with how many articles have them, most used first
+func countTags(articles []apiTypes.ArticleResponse) []tagCount {
+	counts := map[string]int{}
+
+	for _, a := range articles {
+		seen := map[string]bool{}
+
+		for _, t := range a.TagList {
+			name := strings.ToLower(strings.TrimSpace(t))
+
+			if name == "" || seen[name] {
+				continue
+			}
+
+			seen[name] = true
+			counts[name]++
+		}
+	}
+
+	tags := make([]tagCount, 0, len(counts))
+
+	for name, count := range counts {
+		tags = append(tags, tagCount{name, count})
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Name < tags[j].Name
+	})
+
+	return tags
+}
@@ -0,0 +1,36 @@
+package routes
+
+import (
+	"testing"
+
+	apiTypes "github.com/samluiz/blog/api/types"
+)
+
+func TestCountTags(t *testing.T) {
+	articles := []apiTypes.ArticleResponse{
+		{Slug: "a", TagList: []string{"go", "sql"}},
+		{Slug: "b", TagList: []string{"Go", " web", "go"}},
+		{Slug: "c", TagList: []string{"web", ""}},
+		{Slug: "d", TagList: []string{"sql"}},
+		{Slug: "e"},
+	}
+
+	got := countTags(articles)
+	want := []tagCount{{"go", 2}, {"sql", 2}, {"web", 2}}
+
+	if len(got) != len(want) {
+		t.Fatalf("countTags() = %v, want %v", got, want)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("countTags()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	articles = append(articles, apiTypes.ArticleResponse{Slug: "f", TagList: []string{"web"}})
+
+	if got := countTags(articles); got[0] != (tagCount{"web", 3}) {
+		t.Errorf("expected the most used tag first, got %v", got)
+	}
+}
@@ -0,0 +1,115 @@
+package routes
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/samluiz/blog/pkg/types"
+)
+
+// an article in the trash, with when the purge job deletes it for good
+type trashedArticle struct {
+	*types.GetArticleOutput
+	PurgeAt time.Time
+}
+
+type trashedComment struct {
+	*types.Comment
+	PurgeAt time.Time
+}
+
+func (r *router) AdminTrashPage(c *fiber.Ctx) error {
+	session, err := r.store.Get(c)
+
+	if err != nil {
+		LOGGER.Error("error getting session: %v", err)
+	}
+
+	return c.Render("pages/admin-trash", fiber.Map{
+		"IsLogged":  session.Get(IS_LOGGED),
+		"User":      session.Get("user"),
+		"PageTitle": "trash",
+	})
+}
+
+// lists the deleted articles and comments. it's loaded by htmx inside the trash page
+func (r *router) AdminTrashPartial(c *fiber.Ctx) error {
+	return r.renderAdminTrash(c, "")
+}
+
+func (r *router) AdminRestoreTrashedArticle(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+
+	if err := r.articleService.RestoreArticle(id); err != nil {
+		if errors.Is(err, types.ErrArticleNotFound) {
+			return r.renderAdminTrash(c, "This article isn't in the trash anymore.")
+		}
+		LOGGER.Error(err.Error())
+		return r.renderAdminTrash(c, "Error while restoring the article. Please try again.")
+	}
+
+	r.invalidateLocalContent()
+
+	return r.renderAdminTrash(c, "")
+}
+
+func (r *router) AdminRestoreTrashedComment(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+
+	if err := r.commentService.RestoreComment(id); err != nil {
+		if errors.Is(err, types.ErrCommentNotFound) {
+			return r.renderAdminTrash(c, "This comment isn't in the trash anymore.")
+		}
+		if errors.Is(err, types.ErrParentCommentTrashed) {
+			return r.renderAdminTrash(c, "Restore the comment this one answers first.")
+		}
+		LOGGER.Error(err.Error())
+		return r.renderAdminTrash(c, "Error while restoring the comment. Please try again.")
+	}
+
+	return r.renderAdminTrash(c, "")
+}
+
+func (r *router) renderAdminTrash(c *fiber.Ctx, message string) error {
+	articles, err := r.articleService.FindTrashedArticles()
+
+	if err != nil {
+		LOGGER.Error(err.Error())
+	}
+
+	comments, commentsErr := r.commentService.FindTrashedComments()
+
+	if commentsErr != nil {
+		LOGGER.Error(commentsErr.Error())
+		err = commentsErr
+	}
+
+	trashedArticles := make([]trashedArticle, 0, len(articles))
+
+	for _, a := range articles {
+		trashedArticles = append(trashedArticles, trashedArticle{a, a.DeletedAt.Add(r.trashRetention)})
+	}
+
+	trashedComments := make([]trashedComment, 0, len(comments))
+
+	for _, comment := range comments {
+		trashedComments = append(trashedComments, trashedComment{comment, comment.DeletedAt.Add(r.trashRetention)})
+	}
+
+	return c.Render("partials/admin-trash", fiber.Map{
+		"Articles":  trashedArticles,
+		"Comments":  trashedComments,
+		"Retention": int(r.trashRetention.Hours() / 24),
+		"Message":   message,
+		"Error":     err,
+	}, "")
+}
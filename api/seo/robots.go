@@ -0,0 +1,24 @@
+package seo
+
+import "strings"
+
+// builds a robots.txt for every crawler. blocking everything is meant for staging deploys
+func Robots(disallow []string, sitemapURL string, blockAll bool) []byte {
+	var b strings.Builder
+
+	b.WriteString("User-agent: *\n")
+
+	if blockAll {
+		b.WriteString("Disallow: /\n")
+	} else {
+		for _, path := range disallow {
+			b.WriteString("Disallow: " + path + "\n")
+		}
+	}
+
+	if sitemapURL != "" {
+		b.WriteString("\nSitemap: " + sitemapURL + "\n")
+	}
+
+	return []byte(b.String())
+}
@@ -0,0 +1,115 @@
+package seo
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testURLs(n int) []URL {
+	urls := make([]URL, 0, n)
+
+	for i := 0; i < n; i++ {
+		urls = append(urls, URL{Loc: "https://example.com/" + string(rune('a'+i)), LastMod: time.Date(2024, time.January, i+1, 0, 0, 0, 0, time.UTC)})
+	}
+
+	return urls
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		urls int
+		want []int
+	}{
+		{0, nil},
+		{2, []int{2}},
+		{5, []int{2, 2, 1}},
+		{4, []int{2, 2}},
+	}
+
+	for _, tt := range tests {
+		chunks := Split(testURLs(tt.urls), 2)
+
+		if len(chunks) != len(tt.want) {
+			t.Errorf("%d urls: expected %d chunks, got %d", tt.urls, len(tt.want), len(chunks))
+			continue
+		}
+
+		for i, chunk := range chunks {
+			if len(chunk) != tt.want[i] {
+				t.Errorf("%d urls: expected chunk %d to have %d urls, got %d", tt.urls, i, tt.want[i], len(chunk))
+			}
+		}
+	}
+}
+
+func TestSitemap(t *testing.T) {
+	urls := append(testURLs(2), URL{Loc: "https://example.com/?a=1&b=2"})
+
+	body, err := Sitemap(urls)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var parsed struct {
+		XMLName xml.Name `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+		URLs    []struct {
+			Loc     string `xml:"loc"`
+			LastMod string `xml:"lastmod"`
+		} `xml:"url"`
+	}
+
+	if err := xml.Unmarshal(body, &parsed); err != nil {
+		t.Fatalf("expected valid xml, got %v:\n%s", err, body)
+	}
+
+	if len(parsed.URLs) != 3 || parsed.URLs[1].LastMod != "2024-01-02T00:00:00Z" {
+		t.Fatalf("unexpected urls %+v", parsed.URLs)
+	}
+
+	if parsed.URLs[2].Loc != "https://example.com/?a=1&b=2" || parsed.URLs[2].LastMod != "" {
+		t.Errorf("expected the url to be escaped and to have no lastmod, got %+v", parsed.URLs[2])
+	}
+}
+
+func TestSitemapIndex(t *testing.T) {
+	body, err := SitemapIndex([]URL{{Loc: "https://example.com/sitemaps/1.xml", LastMod: LastMod(testURLs(3))}})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var parsed struct {
+		XMLName  xml.Name `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 sitemapindex"`
+		Sitemaps []struct {
+			Loc     string `xml:"loc"`
+			LastMod string `xml:"lastmod"`
+		} `xml:"sitemap"`
+	}
+
+	if err := xml.Unmarshal(body, &parsed); err != nil {
+		t.Fatalf("expected valid xml, got %v:\n%s", err, body)
+	}
+
+	if len(parsed.Sitemaps) != 1 || parsed.Sitemaps[0].LastMod != "2024-01-03T00:00:00Z" {
+		t.Errorf("expected the sitemap with its newest change, got %+v", parsed.Sitemaps)
+	}
+}
+
+func TestRobots(t *testing.T) {
+	robots := string(Robots([]string{"/dashboard", "/auth"}, "https://example.com/sitemap.xml", false))
+
+	for _, line := range []string{"User-agent: *", "Disallow: /dashboard", "Disallow: /auth", "Sitemap: https://example.com/sitemap.xml"} {
+		if !strings.Contains(robots, line+"\n") {
+			t.Errorf("expected %q in:\n%s", line, robots)
+		}
+	}
+
+	blocked := string(Robots([]string{"/dashboard"}, "", true))
+
+	if blocked != "User-agent: *\nDisallow: /\n" {
+		t.Errorf("expected the whole site to be blocked, got:\n%s", blocked)
+	}
+}
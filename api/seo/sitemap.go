@@ -0,0 +1,96 @@
+package seo
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+const SITEMAP_NAMESPACE = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// a page, or a sitemap when building the index, and when it last changed
+type URL struct {
+	Loc     string
+	LastMod time.Time
+}
+
+type urlSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	XMLNS    string       `xml:"xmlns,attr"`
+	Sitemaps []sitemapURL `xml:"sitemap"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+func Sitemap(urls []URL) ([]byte, error) {
+	return encode(urlSet{XMLNS: SITEMAP_NAMESPACE, URLs: toSitemapURLs(urls)})
+}
+
+// lists the sitemaps the urls were split into, see Split
+func SitemapIndex(sitemaps []URL) ([]byte, error) {
+	return encode(sitemapIndex{XMLNS: SITEMAP_NAMESPACE, Sitemaps: toSitemapURLs(sitemaps)})
+}
+
+// splits the urls in chunks of at most size urls, one for each sitemap of the index
+func Split(urls []URL, size int) [][]URL {
+	var chunks [][]URL
+
+	for start := 0; start < len(urls); start += size {
+		end := start + size
+
+		if end > len(urls) {
+			end = len(urls)
+		}
+
+		chunks = append(chunks, urls[start:end])
+	}
+
+	return chunks
+}
+
+// the most recent change of the urls, which is when a sitemap listing them last changed
+func LastMod(urls []URL) time.Time {
+	var last time.Time
+
+	for _, u := range urls {
+		if u.LastMod.After(last) {
+			last = u.LastMod
+		}
+	}
+
+	return last
+}
+
+func toSitemapURLs(urls []URL) []sitemapURL {
+	result := make([]sitemapURL, 0, len(urls))
+
+	for _, u := range urls {
+		entry := sitemapURL{Loc: u.Loc}
+
+		if !u.LastMod.IsZero() {
+			entry.LastMod = u.LastMod.UTC().Format(time.RFC3339)
+		}
+
+		result = append(result, entry)
+	}
+
+	return result
+}
+
+func encode(v any) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), body...), nil
+}
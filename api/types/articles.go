@@ -1,5 +1,12 @@
 package types
 
+import "time"
+
+const (
+	SOURCE_DEVTO = "devto"
+	SOURCE_LOCAL = "local"
+)
+
 type ArticleResponse struct {
 	ID                 int
 	Title              string
@@ -7,8 +14,12 @@ type ArticleResponse struct {
 	Slug               string
 	TagList            []string
 	PublishedAt        string
+	PublishedAtTime    time.Time
+	UpdatedAtTime      time.Time
 	ReadingTimeMinutes int
 	BodyMarkdown       string
+	Source             string
+	DevToID            int
 }
 
 type GetArticlesResponse struct {
@@ -18,6 +29,7 @@ type GetArticlesResponse struct {
 	Slug               string   `json:"slug"`
 	TagList            []string `json:"tag_list"`
 	PublishedAt        string   `json:"published_at"`
+	EditedAt           string   `json:"edited_at"`
 	ReadingTimeMinutes int      `json:"reading_time_minutes"`
 	BodyMarkdown       string   `json:"body_markdown"`
 }
@@ -29,6 +41,63 @@ type GetArticleByPathResponse struct {
 	Slug               string   `json:"slug"`
 	TagList            []string `json:"tags"`
 	PublishedAt        string   `json:"published_at"`
+	EditedAt           string   `json:"edited_at"`
 	ReadingTimeMinutes int      `json:"reading_time_minutes"`
 	BodyMarkdown       string   `json:"body_markdown"`
 }
+
+type NativeArticleResponse struct {
+	ID          int        `json:"id"`
+	Title       string     `json:"title"`
+	Slug        string     `json:"slug"`
+	Content     string     `json:"content"`
+	Tags        []string   `json:"tags"`
+	AuthorID    int        `json:"author_id"`
+	Visibility  string     `json:"visibility"`
+	IsPublished bool       `json:"is_published"`
+	PublishedAt *time.Time `json:"published_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	DevToID         *int   `json:"devto_id"`
+	DevToURL        string `json:"devto_url"`
+	CrossPostStatus string `json:"crosspost_status"`
+	CrossPostError  string `json:"crosspost_error"`
+}
+
+type CreateArticleRequest struct {
+	Title       string   `json:"title"`
+	Content     string   `json:"content"`
+	Tags        []string `json:"tags"`
+	IsPublished bool     `json:"is_published"`
+	CrossPost   bool     `json:"crosspost"`
+}
+
+type PublishArticleRequest struct {
+	CrossPost bool `json:"crosspost"`
+}
+
+type UpdateArticleRequest struct {
+	Title   string   `json:"title"`
+	Content string   `json:"content"`
+	Tags    []string `json:"tags"`
+}
+
+type DevToArticleRequest struct {
+	Article DevToArticleBody `json:"article"`
+}
+
+type DevToArticleBody struct {
+	Title        string   `json:"title"`
+	BodyMarkdown string   `json:"body_markdown"`
+	Published    bool     `json:"published"`
+	Tags         []string `json:"tags"`
+	CanonicalURL string   `json:"canonical_url"`
+	Description  string   `json:"description"`
+}
+
+type DevToArticleResponse struct {
+	ID   int    `json:"id"`
+	URL  string `json:"url"`
+	Slug string `json:"slug"`
+}
@@ -0,0 +1,19 @@
+package types
+
+const (
+	ERR_VALIDATION     = "validation_error"
+	ERR_NOT_FOUND      = "not_found"
+	ERR_BAD_REQUEST    = "bad_request"
+	ERR_UNAUTHORIZED   = "unauthorized"
+	ERR_FORBIDDEN      = "forbidden"
+	ERR_CONFLICT       = "conflict"
+	ERR_UPSTREAM       = "upstream_error"
+	ERR_INTERNAL_ERROR = "internal_error"
+)
+
+type ErrorResponse struct {
+	Status  int               `json:"status"`
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
@@ -11,5 +11,6 @@ type GithubUserResponse struct {
 	ID        int    `json:"id"`
 	Login     string `json:"login"`
 	AvatarURL string `json:"avatar_url"`
+	HTMLURL   string `json:"html_url"`
 	Bio       string `json:"bio"`
 }
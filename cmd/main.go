@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/gob"
+	"errors"
 	"log"
 	"os"
 	"strconv"
@@ -11,11 +12,20 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/gofiber/fiber/v2/middleware/session"
 	"github.com/gofiber/template/html/v2"
+	"github.com/samluiz/blog/api/content"
+	"github.com/samluiz/blog/api/jobs"
+	"github.com/samluiz/blog/api/middlewares/isadmin"
 	"github.com/samluiz/blog/api/middlewares/isinternal"
 	"github.com/samluiz/blog/api/middlewares/islogged"
 	"github.com/samluiz/blog/api/routes"
 	"github.com/samluiz/blog/api/types"
+	"github.com/samluiz/blog/common/cache"
+	"github.com/samluiz/blog/pkg/article"
+	"github.com/samluiz/blog/pkg/comment"
 	"github.com/samluiz/blog/pkg/config"
+	"github.com/samluiz/blog/pkg/migrations"
+	"github.com/samluiz/blog/pkg/preview"
+	pkgTypes "github.com/samluiz/blog/pkg/types"
 	"github.com/samluiz/blog/pkg/user"
 )
 
@@ -29,8 +39,72 @@ func main() {
 	}
 	defer db.Close()
 
+	// Migrate subcommand
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrations.Run(db, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if err := config.InitDatabase(db); err != nil {
+		log.Fatal(err)
+	}
+
 	// Services
 	userService := user.NewService(user.NewRepository(db))
+	articleService := article.NewService(article.NewRepository(db))
+	moderationConfig := config.LoadModerationConfig()
+	commentService := comment.NewService(comment.NewRepository(db), comment.ModerationRules{
+		TrustedAfter: moderationConfig.TrustedAfter,
+		MaxLinks:     moderationConfig.MaxLinks,
+		Blocklist:    moderationConfig.Blocklist,
+	})
+
+	previewConfig := config.LoadPreviewConfig()
+	previewService := preview.NewService(preview.NewRepository(db), previewConfig.Secret, previewConfig.TTL)
+
+	// Cache
+	cacheConfig := config.LoadCacheConfig()
+	appCache := cache.New(cacheConfig.TTL, cacheConfig.IdleTimeout)
+	appCache.CacheMisses(cacheConfig.MissTTL, func(err error) bool {
+		return errors.Is(err, pkgTypes.ErrArticleNotFound)
+	})
+	appCache.Start(cacheConfig.RefreshInterval)
+	defer appCache.Stop()
+
+	// Dev.to sync job. imported articles belong to the admin user
+	var syncAuthorId int
+
+	if admin, err := userService.FindUserByUsername(os.Getenv("ADMIN_USERNAME")); err == nil {
+		syncAuthorId = admin.ID
+	} else {
+		log.Printf("error finding the admin user for the dev.to sync: %v", err)
+	}
+
+	devToSync := jobs.NewDevToSync(articleService, syncAuthorId, appCache)
+
+	if syncConfig := config.LoadDevToSyncConfig(); syncConfig.Enabled {
+		devToSync.Start(syncConfig.Interval)
+		defer devToSync.Stop()
+	}
+
+	crossPostConfig := config.LoadCrossPostConfig()
+	crossPoster := jobs.NewCrossPoster(articleService, appCache, config.SiteURL(), crossPostConfig.MaxAttempts)
+	crossPoster.Start(crossPostConfig.RetryInterval)
+	defer crossPoster.Stop()
+
+	publisher := jobs.NewPublisher(articleService, appCache)
+	publisher.Start(config.LoadPublisherConfig().Interval)
+	defer publisher.Stop()
+
+	trashConfig := config.LoadTrashConfig()
+	trashPurger := jobs.NewTrashPurger(articleService, commentService, trashConfig.Retention)
+	trashPurger.Start(trashConfig.PurgeInterval)
+	defer trashPurger.Stop()
+
+	// Content sources
+	contentSource := content.NewFromConfig(config.LoadContentConfig(), articleService, appCache)
 
 	// Session
 	store := session.New()
@@ -84,19 +158,34 @@ func main() {
 	// Middleware that checks if request is internal
 	isinternal := isinternal.New()
 
+	// Middleware that checks if the logged user is an admin, answering with json errors
+	isadminAPI := isadmin.New(isadmin.Config{
+		Session: store,
+		JSON:    true,
+	})
+
+	// Middleware that checks if the logged user is an admin
+	isadmin := isadmin.New(isadmin.Config{
+		Session: store,
+	})
+
 	// Internal routes
 	internal := app.Group("/internal")
 	internal.Use(isinternal)
 
 	// Protected routes
 	protected := app.Group("/dashboard")
-	protected.Use(islogged)
+	protected.Use(islogged, isadmin)
 
 	// Error routes
 	errors := app.Group("/error")
 
+	// API routes
+	api := app.Group("/api/v1")
+	api.Use(isadminAPI)
+
 	// Router
-	router := routes.NewRouter(app, store, userService)
+	router := routes.NewRouter(app, store, userService, articleService, commentService, contentSource, appCache, devToSync, crossPoster, previewService, trashConfig.Retention)
 
 	// App root routes
 	app.Get("/", router.HomePage)
@@ -105,7 +194,24 @@ func main() {
 	app.Get("/auth/login", router.LoginPage)
 	app.Get("/auth/github/callback", router.GithubCallback)
 	app.Get("/articles/:slug", router.ArticlePage)
+	app.Get("/articles/:slug/comments", router.CommentsPartial)
+	app.Post("/articles/:slug/comments", router.CreateComment)
+	app.Put("/articles/:slug/comments/:id", router.UpdateComment)
+	app.Delete("/articles/:slug/comments/:id", router.DeleteComment)
 	app.Get("/articles", router.ArticlesPage)
+	app.Get("/feed.xml", router.RSSFeed)
+	app.Get("/atom.xml", router.AtomFeed)
+	app.Get("/feed.json", router.JSONFeed)
+	app.Get("/search", router.SearchPage)
+	app.Get("/search/results", router.SearchResults)
+	app.Get("/tags", router.TagsPage)
+	app.Get("/tags/:tag", router.TagPage)
+	app.Get("/tags/:tag/feed.xml", router.TagRSSFeed)
+	app.Get("/sitemap.xml", router.Sitemap)
+	app.Get("/sitemaps/:page.xml", router.SitemapPage)
+	app.Get("/robots.txt", router.Robots)
+	app.Get("/highlight.css", router.HighlightCSS)
+	app.Get("/preview/:token", router.PreviewPage)
 
 	// Error routes
 	errors.Get("/", router.ErrorPage)
@@ -113,6 +219,45 @@ func main() {
 
 	// Protected routes
 	protected.Get("/", router.AdminDashboardPage)
+	protected.Get("/articles", router.AdminArticlesPartial)
+	protected.Get("/articles/new", router.AdminNewArticlePage)
+	protected.Post("/articles", router.AdminCreateArticle)
+	protected.Post("/articles/preview", router.AdminPreviewArticle)
+	protected.Get("/articles/:id/edit", router.AdminEditArticlePage)
+	protected.Put("/articles/:id", router.AdminUpdateArticle)
+	protected.Post("/articles/:id/publish", router.AdminPublishArticle)
+	protected.Post("/articles/:id/unpublish", router.AdminUnpublishArticle)
+	protected.Post("/articles/:id/schedule", router.AdminScheduleArticle)
+	protected.Post("/articles/:id/unschedule", router.AdminUnscheduleArticle)
+	protected.Delete("/articles/:id", router.AdminDeleteArticle)
+	protected.Post("/articles/:id/crosspost", router.AdminCrossPostArticle)
+	protected.Get("/articles/:id/previews", router.AdminArticlePreviewsPartial)
+	protected.Post("/articles/:id/previews", router.AdminCreateArticlePreview)
+	protected.Post("/articles/:id/previews/:previewId/revoke", router.AdminRevokeArticlePreview)
+	protected.Get("/articles/:id/revisions", router.AdminArticleRevisionsPage)
+	protected.Get("/articles/:id/revisions/diff", router.AdminArticleRevisionsDiffPartial)
+	protected.Post("/articles/:id/revisions/:revisionId/restore", router.AdminRestoreArticleRevision)
+	protected.Get("/comments", router.AdminCommentsPage)
+	protected.Get("/comments/list", router.AdminCommentsPartial)
+	protected.Post("/comments/moderate", router.AdminModerateComments)
+	protected.Get("/trash", router.AdminTrashPage)
+	protected.Get("/trash/list", router.AdminTrashPartial)
+	protected.Post("/trash/articles/:id/restore", router.AdminRestoreTrashedArticle)
+	protected.Post("/trash/comments/:id/restore", router.AdminRestoreTrashedComment)
+	protected.Post("/cache/invalidate", router.AdminInvalidateCache)
+	protected.Post("/sync/devto", router.AdminSyncDevTo)
+
+	// Article API routes
+	api.Get("/articles", router.APIListArticles)
+	api.Get("/articles/:slug", router.APIGetArticle)
+	api.Post("/articles", router.APICreateArticle)
+	api.Put("/articles/:id", router.APIUpdateArticle)
+	api.Post("/articles/:id/publish", router.APIPublishArticle)
+	api.Post("/articles/:id/unpublish", router.APIUnpublishArticle)
+	api.Delete("/articles/:id", router.APIDeleteArticle)
+
+	// Sync API routes
+	api.Post("/sync/devto", router.APISyncDevTo)
 
 	// Auth routes
 	internal.Post("/auth/login", router.Authenticate)
@@ -2,13 +2,32 @@ package article
 
 import (
 	"regexp"
-
-	"github.com/samluiz/blog/api/types"
+	"strings"
 )
 
-func getReadTime(article types.ArticleResponse) int {
-	re := regexp.MustCompile(`/([^A-Za-z0-9])+/g`)
-	formattedContent := re.ReplaceAllString(article.BodyMarkdown, "")
+const WORDS_PER_MINUTE = 200
+
+var markdownSymbols = regexp.MustCompile("[#*_>`~\\[\\]()!|-]+")
+
+// returns the estimated minutes needed to read a markdown text, at least one
+func ReadTime(markdown string) int {
+	minutes := len(strings.Fields(markdown)) / WORDS_PER_MINUTE
+
+	if minutes < 1 {
+		return 1
+	}
+
+	return minutes
+}
+
+// returns the first characters of a markdown text without the markdown symbols, to be used as a description
+func Excerpt(markdown string, length int) string {
+	text := strings.Join(strings.Fields(markdownSymbols.ReplaceAllString(markdown, " ")), " ")
+	runes := []rune(text)
+
+	if len(runes) <= length {
+		return text
+	}
 
-	return len(formattedContent) / 200
+	return strings.TrimSpace(string(runes[:length])) + "..."
 }
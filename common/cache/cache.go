@@ -0,0 +1,321 @@
+package cache
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samluiz/blog/common/logger"
+)
+
+var LOGGER = logger.New(os.Stdout, logger.DebugLevel, "[CACHE]")
+
+// what the callers waiting on a load get when the loader panics
+var ErrLoaderPanicked = errors.New("cache loader panicked")
+
+type Loader func() (any, error)
+
+type entry struct {
+	value any
+	// set for cached misses, which are never refreshed, only dropped when they expire
+	err         error
+	loader      Loader
+	nextRefresh time.Time
+	lastAccess  time.Time
+	refreshing  bool
+}
+
+type call struct {
+	done  chan struct{}
+	value any
+	err   error
+	// set when the key is invalidated while loading, so the result, possibly read before the change, isn't cached
+	invalidated bool
+}
+
+// in memory ttl cache that serves stale values while they are refreshed in the background.
+// values are only replaced by successful loads, so the last known good value survives upstream failures
+type Cache struct {
+	mu          sync.Mutex
+	ttl         time.Duration
+	retryDelay  time.Duration
+	idleTimeout time.Duration
+	missTTL     time.Duration
+	isMiss      func(error) bool
+	entries     map[string]*entry
+	calls       map[string]*call
+	stop        chan struct{}
+}
+
+func New(ttl time.Duration, idleTimeout time.Duration) *Cache {
+	retryDelay := ttl / 10
+
+	if retryDelay < time.Second {
+		retryDelay = time.Second
+	}
+
+	return &Cache{
+		ttl:         ttl,
+		retryDelay:  retryDelay,
+		idleTimeout: idleTimeout,
+		entries:     map[string]*entry{},
+		calls:       map[string]*call{},
+	}
+}
+
+// remembers the errors matching isMiss, like not found errors, for ttl.
+// asking again for something that doesn't exist is then answered without reaching the upstream
+func (c *Cache) CacheMisses(ttl time.Duration, isMiss func(error) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.missTTL = ttl
+	c.isMiss = isMiss
+}
+
+// returns the cached value for the key, loading it if it's not cached yet.
+// expired values are returned right away and refreshed in the background
+func (c *Cache) Get(key string, loader Loader) (any, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+
+	e, ok := c.entries[key]
+
+	// cached misses aren't refreshed, they are loaded again once they expire
+	if ok && e.err != nil && !now.Before(e.nextRefresh) {
+		delete(c.entries, key)
+		ok = false
+	}
+
+	if ok && e.err != nil {
+		c.mu.Unlock()
+		return nil, e.err
+	}
+
+	if ok {
+		e.lastAccess = now
+		e.loader = loader
+
+		if !now.Before(e.nextRefresh) && !e.refreshing {
+			e.refreshing = true
+			go c.refresh(key, e)
+		}
+
+		value := e.value
+		c.mu.Unlock()
+
+		return value, nil
+	}
+
+	// someone is already loading this key, so we wait for their result
+	if cl, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+		<-cl.done
+		return cl.value, cl.err
+	}
+
+	// the error is replaced by the loader's result, so it's only left there when the loader panics
+	cl := &call{done: make(chan struct{}), err: ErrLoaderPanicked}
+	c.calls[key] = cl
+	c.mu.Unlock()
+
+	// deferred so the waiters are released even if the loader panics
+	defer close(cl.done)
+	defer c.finishCall(key, cl, loader, now)
+
+	cl.value, cl.err = loader()
+
+	return cl.value, cl.err
+}
+
+// forgets the in flight load and caches its result, or the miss when it's one worth remembering
+func (c *Cache) finishCall(key string, cl *call, loader Loader, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.calls[key] == cl {
+		delete(c.calls, key)
+	}
+
+	if cl.invalidated {
+		return
+	}
+
+	if cl.err == nil {
+		c.entries[key] = &entry{
+			value:       cl.value,
+			loader:      loader,
+			nextRefresh: time.Now().Add(c.ttl),
+			lastAccess:  now,
+		}
+	} else if c.isMiss != nil && c.missTTL > 0 && c.isMiss(cl.err) {
+		c.entries[key] = &entry{
+			err:         cl.err,
+			nextRefresh: time.Now().Add(c.missTTL),
+			lastAccess:  now,
+		}
+	}
+}
+
+// typed version of Get
+func GetAs[T any](c *Cache, key string, loader func() (T, error)) (T, error) {
+	value, err := c.Get(key, func() (any, error) {
+		return loader()
+	})
+
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return value.(T), nil
+}
+
+func (c *Cache) Invalidate(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, ok := c.entries[key]
+	delete(c.entries, key)
+
+	if cl, loading := c.calls[key]; loading {
+		c.dropCall(key, cl)
+	}
+
+	return ok
+}
+
+// removes every key starting with the prefix and returns how many were removed. an empty prefix clears the whole cache
+func (c *Cache) InvalidatePrefix(prefix string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+
+	for key := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.entries, key)
+			removed++
+		}
+	}
+
+	for key, cl := range c.calls {
+		if strings.HasPrefix(key, prefix) {
+			c.dropCall(key, cl)
+		}
+	}
+
+	if removed > 0 {
+		LOGGER.Info("invalidated %d entries with prefix %q", removed, prefix)
+	}
+
+	return removed
+}
+
+// makes an in flight load forget its result. callers already waiting on it still get it,
+// but new calls for the key start a fresh load. must be called with the lock held
+func (c *Cache) dropCall(key string, cl *call) {
+	cl.invalidated = true
+	delete(c.calls, key)
+}
+
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.entries)
+}
+
+// starts the goroutine that keeps expired entries fresh and evicts the ones nobody asked for in a while
+func (c *Cache) Start(interval time.Duration) {
+	c.mu.Lock()
+
+	if c.stop != nil {
+		c.mu.Unlock()
+		return
+	}
+
+	stop := make(chan struct{})
+	c.stop = stop
+	c.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				c.refreshExpired()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+func (c *Cache) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stop != nil {
+		close(c.stop)
+		c.stop = nil
+	}
+}
+
+func (c *Cache) refreshExpired() {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, e := range c.entries {
+		if c.idleTimeout > 0 && now.Sub(e.lastAccess) > c.idleTimeout {
+			delete(c.entries, key)
+			continue
+		}
+
+		if e.err != nil {
+			if !now.Before(e.nextRefresh) {
+				delete(c.entries, key)
+			}
+			continue
+		}
+
+		if !now.Before(e.nextRefresh) && !e.refreshing {
+			e.refreshing = true
+			go c.refresh(key, e)
+		}
+	}
+}
+
+func (c *Cache) refresh(key string, e *entry) {
+	c.mu.Lock()
+	loader := e.loader
+	c.mu.Unlock()
+
+	value, err := loader()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e.refreshing = false
+
+	// the entry was invalidated while we were loading it
+	if c.entries[key] != e {
+		return
+	}
+
+	if err != nil {
+		LOGGER.Warning("error refreshing %s, serving the stale value: %v", key, err)
+		e.nextRefresh = time.Now().Add(c.retryDelay)
+		return
+	}
+
+	e.value = value
+	e.nextRefresh = time.Now().Add(c.ttl)
+}
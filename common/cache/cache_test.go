@@ -0,0 +1,390 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var errUpstream = errors.New("upstream is down")
+
+// loader returning the values in order, repeating the last one, and counting how many times it ran
+type stubLoader struct {
+	calls  atomic.Int32
+	values []any
+	errs   []error
+}
+
+func (l *stubLoader) load() (any, error) {
+	i := int(l.calls.Add(1)) - 1
+
+	if i >= len(l.values) {
+		i = len(l.values) - 1
+	}
+
+	var err error
+
+	if i < len(l.errs) {
+		err = l.errs[i]
+	}
+
+	return l.values[i], err
+}
+
+// waits for the background refresh to run, failing the test if it doesn't happen in time
+func eventually(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestGet(t *testing.T) {
+	tests := []struct {
+		name   string
+		loader *stubLoader
+		want   []any
+		err    error
+		cached bool
+	}{
+		{
+			name:   "caches the loaded value",
+			loader: &stubLoader{values: []any{"a", "b"}},
+			want:   []any{"a", "a", "a"},
+			cached: true,
+		},
+		{
+			name:   "doesn't cache errors",
+			loader: &stubLoader{values: []any{nil, "b"}, errs: []error{errUpstream}},
+			want:   []any{nil, "b", "b"},
+			cached: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(time.Hour, 0)
+
+			for i, want := range tt.want {
+				got, _ := c.Get("key", tt.loader.load)
+
+				if got != want {
+					t.Errorf("Get() #%d = %v, want %v", i, got, want)
+				}
+			}
+
+			if got := c.Len() == 1; got != tt.cached {
+				t.Errorf("cached = %v, want %v", got, tt.cached)
+			}
+		})
+	}
+}
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		loader    *stubLoader
+		wantStale any
+		wantAfter any
+	}{
+		{
+			name:      "refreshes expired values in the background",
+			loader:    &stubLoader{values: []any{"old", "new"}},
+			wantStale: "old",
+			wantAfter: "new",
+		},
+		{
+			name:      "keeps the old value when the refresh fails",
+			loader:    &stubLoader{values: []any{"old", nil}, errs: []error{nil, errUpstream}},
+			wantStale: "old",
+			wantAfter: "old",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(10*time.Millisecond, 0)
+
+			c.Get("key", tt.loader.load)
+
+			time.Sleep(20 * time.Millisecond)
+
+			// the expired value is served right away, while the refresh runs
+			if got, err := c.Get("key", tt.loader.load); got != tt.wantStale || err != nil {
+				t.Fatalf("Get() on an expired value = %v, %v, want %v, nil", got, err, tt.wantStale)
+			}
+
+			eventually(t, func() bool { return tt.loader.calls.Load() == 2 })
+
+			eventually(t, func() bool {
+				c.mu.Lock()
+				defer c.mu.Unlock()
+				return !c.entries["key"].refreshing
+			})
+
+			if got, err := c.Get("key", tt.loader.load); got != tt.wantAfter || err != nil {
+				t.Errorf("Get() after the refresh = %v, %v, want %v, nil", got, err, tt.wantAfter)
+			}
+		})
+	}
+}
+
+func TestSingleFlight(t *testing.T) {
+	c := New(time.Hour, 0)
+
+	release := make(chan struct{})
+	var calls atomic.Int32
+
+	loader := func() (any, error) {
+		calls.Add(1)
+		<-release
+		return "value", nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]any, 10)
+
+	for i := range results {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = c.Get("key", loader)
+		}(i)
+	}
+
+	// lets every goroutine reach Get before the load finishes
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("loader calls = %d, want 1", got)
+	}
+
+	for i, got := range results {
+		if got != "value" {
+			t.Errorf("results[%d] = %v, want value", i, got)
+		}
+	}
+}
+
+func count(removed bool) int {
+	if removed {
+		return 1
+	}
+	return 0
+}
+
+func TestInvalidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		invalidate func(c *Cache) int
+		removed    int
+		remaining  []string
+	}{
+		{
+			name:       "single key",
+			invalidate: func(c *Cache) int { return count(c.Invalidate("content:local:list")) },
+			removed:    1,
+			remaining:  []string{"content:local:article:a", "content:devto:list", "github:bio"},
+		},
+		{
+			name:       "missing key",
+			invalidate: func(c *Cache) int { return count(c.Invalidate("missing")) },
+			removed:    0,
+			remaining:  []string{"content:local:list", "content:local:article:a", "content:devto:list", "github:bio"},
+		},
+		{
+			name:       "prefix",
+			invalidate: func(c *Cache) int { return c.InvalidatePrefix("content:local") },
+			removed:    2,
+			remaining:  []string{"content:devto:list", "github:bio"},
+		},
+		{
+			name:       "empty prefix clears everything",
+			invalidate: func(c *Cache) int { return c.InvalidatePrefix("") },
+			removed:    4,
+			remaining:  []string{},
+		},
+	}
+
+	keys := []string{"content:local:list", "content:local:article:a", "content:devto:list", "github:bio"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(time.Hour, 0)
+
+			for _, key := range keys {
+				c.Get(key, func() (any, error) { return key, nil })
+			}
+
+			if removed := tt.invalidate(c); removed != tt.removed {
+				t.Errorf("removed = %d, want %d", removed, tt.removed)
+			}
+
+			if c.Len() != len(tt.remaining) {
+				t.Errorf("Len() = %d, want %d", c.Len(), len(tt.remaining))
+			}
+
+			for _, key := range tt.remaining {
+				if _, ok := c.entries[key]; !ok {
+					t.Errorf("%s was removed", key)
+				}
+			}
+		})
+	}
+}
+
+// a load that started before the invalidation may have read the old data, so its result must not be cached
+func TestInvalidateDuringLoad(t *testing.T) {
+	tests := []struct {
+		name       string
+		invalidate func(c *Cache)
+	}{
+		{name: "key", invalidate: func(c *Cache) { c.Invalidate("content:local:list") }},
+		{name: "prefix", invalidate: func(c *Cache) { c.InvalidatePrefix("content:local") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(time.Hour, 0)
+
+			started := make(chan struct{})
+			release := make(chan struct{})
+			finished := make(chan struct{})
+
+			go func() {
+				defer close(finished)
+
+				c.Get("content:local:list", func() (any, error) {
+					close(started)
+					<-release
+					return "before the change", nil
+				})
+			}()
+
+			<-started
+			tt.invalidate(c)
+
+			// a new request after the invalidation must not wait for the old load
+			got, _ := c.Get("content:local:list", func() (any, error) { return "after the change", nil })
+
+			if got != "after the change" {
+				t.Errorf("Get() after the invalidation = %v, want after the change", got)
+			}
+
+			close(release)
+			<-finished
+
+			got, _ = c.Get("content:local:list", func() (any, error) { return "reloaded", nil })
+
+			if got != "after the change" {
+				t.Errorf("Get() after the old load finished = %v, want after the change", got)
+			}
+		})
+	}
+}
+
+func TestIdleEntriesAreEvicted(t *testing.T) {
+	c := New(time.Hour, 10*time.Millisecond)
+
+	c.Get("key", func() (any, error) { return "value", nil })
+
+	time.Sleep(20 * time.Millisecond)
+	c.refreshExpired()
+
+	if c.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", c.Len())
+	}
+}
+
+func TestPanickingLoaderReleasesWaiters(t *testing.T) {
+	c := New(time.Hour, 0)
+
+	release := make(chan struct{})
+	waiting := make(chan error)
+
+	go func() {
+		defer func() { recover() }()
+
+		c.Get("key", func() (any, error) {
+			<-release
+			panic("boom")
+		})
+	}()
+
+	eventually(t, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.calls["key"] != nil
+	})
+
+	go func() {
+		_, err := c.Get("key", func() (any, error) { return "unused", nil })
+		waiting <- err
+	}()
+
+	// lets the waiter reach Get before the load panics
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	select {
+	case err := <-waiting:
+		if !errors.Is(err, ErrLoaderPanicked) {
+			t.Errorf("expected ErrLoaderPanicked, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiter still blocked after the loader panicked")
+	}
+
+	value, err := c.Get("key", func() (any, error) { return "value", nil })
+
+	if err != nil || value != "value" {
+		t.Errorf("expected a fresh load after the panic, got %v, %v", value, err)
+	}
+}
+
+func TestCacheMisses(t *testing.T) {
+	errNotFound := errors.New("not found")
+
+	c := New(time.Hour, 0)
+	c.CacheMisses(50*time.Millisecond, func(err error) bool {
+		return errors.Is(err, errNotFound)
+	})
+
+	missing := &stubLoader{values: []any{nil, "found"}, errs: []error{errNotFound}}
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.Get("missing", missing.load); !errors.Is(err, errNotFound) {
+			t.Fatalf("expected the cached miss, got %v", err)
+		}
+	}
+
+	if got := missing.calls.Load(); got != 1 {
+		t.Errorf("loader calls = %d, want 1", got)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if value, err := c.Get("missing", missing.load); err != nil || value != "found" {
+		t.Errorf("expected the miss to expire, got %v, %v", value, err)
+	}
+
+	// other errors are still retried right away
+	failing := &stubLoader{values: []any{nil, "ok"}, errs: []error{errUpstream}}
+
+	c.Get("failing", failing.load)
+
+	if value, err := c.Get("failing", failing.load); err != nil || value != "ok" {
+		t.Errorf("expected upstream errors not to be cached, got %v, %v", value, err)
+	}
+}
@@ -0,0 +1,25 @@
+package constraint
+
+import (
+	"errors"
+	"strings"
+)
+
+// extended sqlite result code of a foreign key violation
+const SQLITE_CONSTRAINT_FOREIGNKEY = 787
+
+// reports whether the error is a write refused by a foreign key, so repositories can tell which row was missing.
+// the local driver gives the sqlite code, libsql only the message
+func IsForeignKey(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var coded interface{ Code() int }
+
+	if errors.As(err, &coded) {
+		return coded.Code() == SQLITE_CONSTRAINT_FOREIGNKEY
+	}
+
+	return strings.Contains(err.Error(), "FOREIGN KEY constraint failed")
+}
@@ -0,0 +1,49 @@
+package constraint
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "modernc.org/sqlite"
+)
+
+func TestIsForeignKey(t *testing.T) {
+	db, err := sqlx.Open("sqlite", ":memory:?_pragma=foreign_keys(1)")
+
+	if err != nil {
+		t.Fatalf("error opening the database: %v", err)
+	}
+
+	defer db.Close()
+
+	db.SetMaxOpenConns(1)
+	db.MustExec("CREATE TABLE parents (id INTEGER PRIMARY KEY, name TEXT UNIQUE)")
+	db.MustExec("CREATE TABLE children (id INTEGER PRIMARY KEY, parent_id INTEGER REFERENCES parents (id))")
+	db.MustExec("INSERT INTO parents (id, name) VALUES (1, 'parent')")
+
+	_, fkErr := db.Exec("INSERT INTO children (parent_id) VALUES (2)")
+	_, uniqueErr := db.Exec("INSERT INTO parents (name) VALUES ('parent')")
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "foreign key", err: fkErr, want: true},
+		{name: "wrapped foreign key", err: fmt.Errorf("error saving: %w", fkErr), want: true},
+		{name: "other constraint", err: uniqueErr},
+		{name: "libsql message", err: errors.New("SQLITE_CONSTRAINT_FOREIGNKEY: FOREIGN KEY constraint failed"), want: true},
+		{name: "other error", err: errors.New("no such table: children")},
+		{name: "no error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsForeignKey(tt.err); got != tt.want {
+				t.Errorf("IsForeignKey(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
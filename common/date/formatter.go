@@ -6,11 +6,20 @@ import (
 )
 
 const DATE_LAYOUT = "2006-01-02T15:04:05.999Z"
+const DISPLAY_LAYOUT = "2006.01.02"
 
 func FormatDate(date string) string {
+	return FormatTime(ParseDate(date))
+}
+
+func ParseDate(date string) time.Time {
 	parsedDate, err := time.Parse(DATE_LAYOUT, date)
 	if err != nil {
 		log.Default().Printf("Error parsing time: %v", err)
 	}
-	return parsedDate.Format("2006.01.02")
+	return parsedDate
+}
+
+func FormatTime(date time.Time) string {
+	return date.Format(DISPLAY_LAYOUT)
 }
@@ -0,0 +1,111 @@
+package diff
+
+import "strings"
+
+// texts with more lines than this, once their common start and end are removed, are shown as fully replaced
+// instead of being compared line by line, which takes memory for every pair of lines
+const MAX_COMPARED_LINES = 2000
+
+type Op string
+
+const (
+	EQUAL  Op = "equal"
+	INSERT Op = "insert"
+	DELETE Op = "delete"
+)
+
+type Line struct {
+	Op   Op
+	Text string
+}
+
+// the lines to delete from a and insert into it to get b, in order, with the unchanged lines between them
+func Lines(a, b string) []Line {
+	return diff(splitLines(a), splitLines(b))
+}
+
+func diff(a, b []string) []Line {
+	var lines []Line
+
+	prefix := 0
+
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		lines = append(lines, Line{EQUAL, a[prefix]})
+		prefix++
+	}
+
+	a, b = a[prefix:], b[prefix:]
+
+	suffix := 0
+
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	lines = append(lines, changed(a[:len(a)-suffix], b[:len(b)-suffix])...)
+
+	for _, text := range a[len(a)-suffix:] {
+		lines = append(lines, Line{EQUAL, text})
+	}
+
+	return lines
+}
+
+// the changed middle of the texts, from their longest common subsequence of lines
+func changed(a, b []string) []Line {
+	var lines []Line
+
+	if len(a) > MAX_COMPARED_LINES || len(b) > MAX_COMPARED_LINES {
+		for _, text := range a {
+			lines = append(lines, Line{DELETE, text})
+		}
+		for _, text := range b {
+			lines = append(lines, Line{INSERT, text})
+		}
+		return lines
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, Line{EQUAL, a[i]})
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			lines = append(lines, Line{INSERT, b[j]})
+			j++
+		default:
+			lines = append(lines, Line{DELETE, a[i]})
+			i++
+		}
+	}
+
+	return lines
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(strings.ReplaceAll(s, "\r\n", "\n"), "\n"), "\n")
+}
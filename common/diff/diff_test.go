@@ -0,0 +1,60 @@
+package diff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want []Line
+	}{
+		{
+			name: "same text",
+			a:    "a\nb\n",
+			b:    "a\nb",
+			want: []Line{{EQUAL, "a"}, {EQUAL, "b"}},
+		},
+		{
+			name: "changed line",
+			a:    "# title\nold\nend",
+			b:    "# title\nnew\nend",
+			want: []Line{{EQUAL, "# title"}, {DELETE, "old"}, {INSERT, "new"}, {EQUAL, "end"}},
+		},
+		{
+			name: "moved and added lines",
+			a:    "a\nb\nc\nd",
+			b:    "b\nc\na\nd\ne",
+			want: []Line{{DELETE, "a"}, {EQUAL, "b"}, {EQUAL, "c"}, {INSERT, "a"}, {EQUAL, "d"}, {INSERT, "e"}},
+		},
+		{
+			name: "from empty",
+			a:    "",
+			b:    "a\r\nb",
+			want: []Line{{INSERT, "a"}, {INSERT, "b"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Lines(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lines() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinesReplacesLongTexts(t *testing.T) {
+	a := strings.Repeat("a\n", MAX_COMPARED_LINES+1)
+	b := strings.Repeat("b\n", MAX_COMPARED_LINES+1)
+
+	lines := Lines("same\n"+a, "same\n"+b)
+
+	if len(lines) != 2*MAX_COMPARED_LINES+3 || lines[0].Op != EQUAL || lines[1].Op != DELETE || lines[len(lines)-1].Op != INSERT {
+		t.Errorf("expected the common line then every line deleted and inserted, got %d lines", len(lines))
+	}
+}
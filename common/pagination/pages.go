@@ -0,0 +1,25 @@
+package pagination
+
+// returns the page numbers to link around the current page, with 0 where pages were skipped.
+// the first and the last pages are always there, e.g. 1 0 4 5 6 7 8 0 20 for page 6 of 20 with a radius of 2
+func Window(page, totalPages, radius int) []int {
+	pages := []int{}
+
+	for p := 1; p <= totalPages; p++ {
+		if p == 1 || p == totalPages || (p >= page-radius && p <= page+radius) {
+			pages = append(pages, p)
+			continue
+		}
+
+		// a gap of a single page is shown as the page itself, since the gap marker would take the same space
+		if len(pages) > 0 && pages[len(pages)-1] != 0 {
+			if (p == page-radius-1 && p == 2) || (p == page+radius+1 && p == totalPages-1) {
+				pages = append(pages, p)
+			} else {
+				pages = append(pages, 0)
+			}
+		}
+	}
+
+	return pages
+}
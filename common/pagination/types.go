@@ -5,6 +5,11 @@ import (
 	"math"
 )
 
+const (
+	DEFAULT_SIZE = 10
+	MAX_SIZE     = 100
+)
+
 type Pagination struct {
 	Page    int
 	Size    int
@@ -12,18 +17,30 @@ type Pagination struct {
 	SortBy  string
 }
 
+// the page size used in the queries, falling back to DEFAULT_SIZE when none was given
+func (p *Pagination) size() int {
+	if p.Size == 0 {
+		return DEFAULT_SIZE
+	}
+	return p.Size
+}
+
 // this function is used to validate the pagination values and return the total pages with an error if there is one
 func (p *Pagination) validate(totalItems int) (int, error) {
-	totalPages := int(math.Round(float64(totalItems) / float64(p.Size)))
+	if p.Size < 0 || p.Size > MAX_SIZE {
+		return 0, ErrSizeOutOfRange
+	}
+	totalPages := int(math.Ceil(float64(totalItems) / float64(p.size())))
 	if p.Page < 0 {
 		return totalPages, ErrPageOutOfRange
 	}
-	if p.Size < 0 {
-		return totalPages, ErrSizeOutOfRange
-	}
-	if totalPages < p.Page {
+	// the first page always exists, even if it is empty
+	if p.Page > 1 && totalPages < p.Page {
 		return totalPages, ErrPageOutOfRange
 	}
+	if p.SortBy != "" && p.SortBy != "ASC" && p.SortBy != "DESC" {
+		return totalPages, ErrInvalidSortBy
+	}
 	return totalPages, nil
 }
 
@@ -41,18 +58,14 @@ func (p *Pagination) GetValues(totalItems int) (int, int, int, string, string, e
 		return 0, 0, totalPages, "", "", err
 	}
 
+	limit = p.size()
+
 	if p.Page > 0 {
-		offset = (p.Page - 1) * p.Size
+		offset = (p.Page - 1) * limit
 	} else {
 		offset = 0
 	}
 
-	if p.Size > 0 {
-		limit = p.Size
-	} else {
-		limit = 10
-	}
-
 	if p.OrderBy == "" {
 		orderBy = "id"
 	} else {
@@ -70,4 +83,5 @@ func (p *Pagination) GetValues(totalItems int) (int, int, int, string, string, e
 var (
 	ErrPageOutOfRange = errors.New("Page out of range.")
 	ErrSizeOutOfRange = errors.New("Size out of range.")
+	ErrInvalidSortBy  = errors.New("Sort must be ASC or DESC.")
 )
\ No newline at end of file
@@ -0,0 +1,155 @@
+package pagination
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGetValues(t *testing.T) {
+	tests := []struct {
+		name       string
+		pagination Pagination
+		totalItems int
+		offset     int
+		limit      int
+		totalPages int
+		orderBy    string
+		sortBy     string
+		err        error
+	}{
+		{
+			name:       "defaults",
+			pagination: Pagination{},
+			totalItems: 25,
+			offset:     0, limit: DEFAULT_SIZE, totalPages: 3, orderBy: "id", sortBy: "ASC",
+		},
+		{
+			name:       "default size on a later page",
+			pagination: Pagination{Page: 3},
+			totalItems: 25,
+			offset:     20, limit: DEFAULT_SIZE, totalPages: 3, orderBy: "id", sortBy: "ASC",
+		},
+		{
+			name:       "custom size and order",
+			pagination: Pagination{Page: 2, Size: 5, OrderBy: "updated_at", SortBy: "DESC"},
+			totalItems: 12,
+			offset:     5, limit: 5, totalPages: 3, orderBy: "updated_at", sortBy: "DESC",
+		},
+		{
+			name:       "last partial page",
+			pagination: Pagination{Page: 3, Size: 5},
+			totalItems: 12,
+			offset:     10, limit: 5, totalPages: 3, orderBy: "id", sortBy: "ASC",
+		},
+		{
+			name:       "first page of an empty list",
+			pagination: Pagination{Page: 1, Size: 5},
+			totalItems: 0,
+			offset:     0, limit: 5, totalPages: 0, orderBy: "id", sortBy: "ASC",
+		},
+		{
+			name:       "page zero is the first page",
+			pagination: Pagination{Page: 0, Size: 5},
+			totalItems: 12,
+			offset:     0, limit: 5, totalPages: 3, orderBy: "id", sortBy: "ASC",
+		},
+		{
+			name:       "page after the last one",
+			pagination: Pagination{Page: 4, Size: 5},
+			totalItems: 12,
+			totalPages: 3,
+			err:        ErrPageOutOfRange,
+		},
+		{
+			name:       "second page of an empty list",
+			pagination: Pagination{Page: 2},
+			totalItems: 0,
+			err:        ErrPageOutOfRange,
+		},
+		{
+			name:       "negative page",
+			pagination: Pagination{Page: -1, Size: 5},
+			totalItems: 12,
+			totalPages: 3,
+			err:        ErrPageOutOfRange,
+		},
+		{
+			name:       "negative size",
+			pagination: Pagination{Size: -1},
+			totalItems: 12,
+			err:        ErrSizeOutOfRange,
+		},
+		{
+			name:       "size over the maximum",
+			pagination: Pagination{Size: MAX_SIZE + 1},
+			totalItems: 12,
+			err:        ErrSizeOutOfRange,
+		},
+		{
+			name:       "invalid sort",
+			pagination: Pagination{SortBy: "UP"},
+			totalItems: 12,
+			totalPages: 2,
+			err:        ErrInvalidSortBy,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offset, limit, totalPages, orderBy, sortBy, err := tt.pagination.GetValues(tt.totalItems)
+
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+
+			if totalPages != tt.totalPages {
+				t.Errorf("totalPages = %d, want %d", totalPages, tt.totalPages)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if offset != tt.offset || limit != tt.limit {
+				t.Errorf("offset, limit = %d, %d, want %d, %d", offset, limit, tt.offset, tt.limit)
+			}
+
+			if orderBy != tt.orderBy || sortBy != tt.sortBy {
+				t.Errorf("orderBy, sortBy = %q, %q, want %q, %q", orderBy, sortBy, tt.orderBy, tt.sortBy)
+			}
+		})
+	}
+}
+
+func TestWindow(t *testing.T) {
+	tests := []struct {
+		page       int
+		totalPages int
+		want       []int
+	}{
+		{1, 0, []int{}},
+		{1, 1, []int{1}},
+		{1, 5, []int{1, 2, 3, 4, 5}},
+		{6, 20, []int{1, 0, 4, 5, 6, 7, 8, 0, 20}},
+		{1, 20, []int{1, 2, 3, 0, 20}},
+		{20, 20, []int{1, 0, 18, 19, 20}},
+		{4, 20, []int{1, 2, 3, 4, 5, 6, 0, 20}},
+		{17, 20, []int{1, 0, 15, 16, 17, 18, 19, 20}},
+	}
+
+	for _, tt := range tests {
+		got := Window(tt.page, tt.totalPages, 2)
+
+		if len(got) != len(tt.want) {
+			t.Errorf("page %d of %d: expected %v, got %v", tt.page, tt.totalPages, tt.want, got)
+			continue
+		}
+
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("page %d of %d: expected %v, got %v", tt.page, tt.totalPages, tt.want, got)
+				break
+			}
+		}
+	}
+}
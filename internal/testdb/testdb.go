@@ -0,0 +1,44 @@
+package testdb
+
+import (
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/samluiz/blog/pkg/config"
+	"github.com/samluiz/blog/pkg/migrations"
+)
+
+// opens an in memory database with every migration applied. it's closed when the test ends
+func Open(t testing.TB) *sqlx.DB {
+	t.Helper()
+
+	db, err := config.Open(config.DatabaseConfig{Driver: config.DRIVER_MEMORY})
+
+	if err != nil {
+		t.Fatalf("error opening the database: %v", err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	if err := migrations.Up(db); err != nil {
+		t.Fatalf("error migrating the database: %v", err)
+	}
+
+	return db
+}
+
+// same as Open, with an admin user to own the articles. returns the id of the user
+func OpenWithAdmin(t testing.TB) (*sqlx.DB, int) {
+	t.Helper()
+
+	db := Open(t)
+
+	result := db.MustExec("INSERT INTO users (name, username, password, is_admin) VALUES ('Admin', 'admin', 'secret', 1)")
+	adminId, err := result.LastInsertId()
+
+	if err != nil {
+		t.Fatalf("error creating the admin user: %v", err)
+	}
+
+	return db, int(adminId)
+}
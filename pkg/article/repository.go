@@ -2,10 +2,12 @@ package article
 
 import (
 	"database/sql"
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/samluiz/blog/common/constraint"
 	"github.com/samluiz/blog/common/pagination"
 	"github.com/samluiz/blog/common/slug"
 	"github.com/samluiz/blog/pkg/types"
@@ -14,14 +16,59 @@ import (
 
 type Repository interface {
 	FindArticleById(id int) (*types.GetArticleOutput, error)
+	FindArticleBySlug(slug string) (*types.GetArticleOutput, error)
 	FindArticlesByUserId(userId int, pagination pagination.Pagination) ([]*types.GetArticleOutput, int, error)
+	FindArticlesByUserIdAndPublished(userId int, isPublished bool, pagination pagination.Pagination) ([]*types.GetArticleOutput, int, error)
+	FindPublishedArticles(pagination pagination.Pagination) ([]*types.GetArticleOutput, int, error)
+	FindPublishedArticlesByTag(tag string, pagination pagination.Pagination) ([]*types.GetArticleOutput, int, error)
+	SearchPublishedArticles(query string, pagination pagination.Pagination) ([]*types.ArticleSearchResult, int, error)
+	FindDevToLinkedArticles() ([]*types.GetArticleOutput, error)
+	FindPendingCrossPosts(maxAttempts int) ([]*types.GetArticleOutput, error)
+	FindScheduledArticles() ([]*types.GetArticleOutput, error)
+	CreateImportedArticle(input *types.ImportArticleInput) error
+	UpdateImportedArticle(id int, input *types.ImportArticleInput) error
+	SoftDeleteArticle(id int) error
+	FindTrashedArticles() ([]*types.GetArticleOutput, error)
+	RestoreArticle(id int) error
+	PurgeArticle(id int) (bool, error)
+	SetCrossPostPending(id int) error
+	LinkDevToArticle(id int, devToId int, devToUrl string) error
+	MarkCrossPosted(id int) error
+	MarkCrossPostFailed(id int, reason string) error
 	CreateArticle(input *types.CreateArticleInput) (*types.GetArticleOutput, error)
 	UpdateArticle(id int, input *types.UpdateArticleInput) (*types.GetArticleOutput, error)
 	PublishArticle(id int, input *types.PublishArticleInput) (*types.GetArticleOutput, error)
+	ScheduleArticle(id int, publishAt *time.Time) (*types.GetArticleOutput, error)
+	FindRevisionsByArticleId(articleId int) ([]*types.ArticleRevision, error)
+	FindRevisionById(articleId int, revisionId int) (*types.ArticleRevision, error)
+	PublishScheduledArticle(id int, publishAt time.Time) (bool, error)
 	DeleteArticle(id int) error
 	ArticleExists(id int) error
 }
 
+// columns that can be used to order paginated queries. they can't be sent as query parameters
+var orderableColumns = map[string]bool{
+	"id":           true,
+	"title":        true,
+	"published_at": true,
+	"created_at":   true,
+	"updated_at":   true,
+}
+
+// tags live in their own table, so they are joined back into the comma separated list the article outputs carry
+const articleColumns = `articles.*, COALESCE((
+	SELECT group_concat(name, ',') FROM (
+		SELECT tags.name FROM article_tags JOIN tags ON tags.id = article_tags.tag_id WHERE article_tags.article_id = articles.id ORDER BY article_tags.position
+	)
+), '') AS tags`
+
+const selectArticlesStatement = "SELECT " + articleColumns + " FROM articles "
+
+const selectRevisionsStatement = "SELECT article_revisions.*, COALESCE(users.username, '') AS author_username FROM article_revisions LEFT JOIN users ON users.id = article_revisions.author_id "
+
+// ids of the articles with a tag, looked up through the tags name and the article_tags tag_id indexes
+const taggedArticlesStatement = "(SELECT article_tags.article_id FROM article_tags JOIN tags ON tags.id = article_tags.tag_id WHERE tags.name = ?)"
+
 type repository struct {
 	db *sqlx.DB
 }
@@ -30,15 +77,28 @@ func NewRepository(db *sqlx.DB) Repository {
 	return &repository{db}
 }
 
+// articles in the trash aren't found by any finder but FindTrashedArticles and FindDevToLinkedArticles
 func (r *repository) FindArticleById(id int) (*types.GetArticleOutput, error) {
 	var article types.GetArticleOutput
-	err := r.db.Get(&article, "SELECT * FROM articles WHERE id = ?", id)
+	err := r.db.Get(&article, selectArticlesStatement+"WHERE id = ? AND deleted_at IS NULL", id)
 	if err != nil {
 		return nil, types.ErrArticleNotFound
 	}
 	return &article, nil
 }
 
+func (r *repository) FindArticleBySlug(slug string) (*types.GetArticleOutput, error) {
+	var article types.GetArticleOutput
+	err := r.db.Get(&article, selectArticlesStatement+"WHERE slug = ? AND deleted_at IS NULL", slug)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, types.ErrArticleNotFound
+		}
+		return nil, err
+	}
+	return &article, nil
+}
+
 func (r *repository) FindArticlesByUserId(userId int, pagination pagination.Pagination) ([]*types.GetArticleOutput, int, error) {
 
 	// Paginated requests will return the total pages to be sent as a response header in the API
@@ -53,19 +113,128 @@ func (r *repository) FindArticlesByUserId(userId int, pagination pagination.Pagi
 
 	var totalItems int
 
-	err := r.db.Get(&totalItems, "SELECT COUNT(*) FROM articles WHERE author_id = ?", userId)
+	err := r.db.Get(&totalItems, "SELECT COUNT(*) FROM articles WHERE author_id = ? AND deleted_at IS NULL", userId)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	offset, limit, totalPages, orderBy, sortBy, err := pagination.GetValues(totalItems)
+
+	if err != nil {
+		return nil, totalPages, err
+	}
+
+	if !orderableColumns[orderBy] {
+		return nil, totalPages, types.ErrInvalidOrderBy
+	}
+
+	query := fmt.Sprintf(selectArticlesStatement+"WHERE author_id = ? AND deleted_at IS NULL ORDER BY %s %s LIMIT ? OFFSET ?", orderBy, sortBy)
+
+	err = r.db.Select(&articles, query, userId, limit, offset)
+
+	if err != nil {
+		return nil, 0, err
+	}
+	return articles, totalPages, nil
+}
+
+// same as FindArticlesByUserId, only returning the drafts or the published articles of the user
+func (r *repository) FindArticlesByUserIdAndPublished(userId int, isPublished bool, pagination pagination.Pagination) ([]*types.GetArticleOutput, int, error) {
+	userRepo := user.NewRepository(r.db)
+
+	if err := userRepo.UserExistsById(userId); err != nil {
+		return nil, 0, err
+	}
+
+	var articles []*types.GetArticleOutput
+
+	var totalItems int
+
+	err := r.db.Get(&totalItems, "SELECT COUNT(*) FROM articles WHERE author_id = ? AND is_published = ? AND deleted_at IS NULL", userId, isPublished)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	offset, limit, totalPages, orderBy, sortBy, err := pagination.GetValues(totalItems)
+
+	if err != nil {
+		return nil, totalPages, err
+	}
+
+	if !orderableColumns[orderBy] {
+		return nil, totalPages, types.ErrInvalidOrderBy
+	}
+
+	query := fmt.Sprintf(selectArticlesStatement+"WHERE author_id = ? AND is_published = ? AND deleted_at IS NULL ORDER BY %s %s LIMIT ? OFFSET ?", orderBy, sortBy)
+
+	err = r.db.Select(&articles, query, userId, isPublished, limit, offset)
+
+	if err != nil {
+		return nil, 0, err
+	}
+	return articles, totalPages, nil
+}
+
+func (r *repository) FindPublishedArticles(pagination pagination.Pagination) ([]*types.GetArticleOutput, int, error) {
+	var articles []*types.GetArticleOutput
+
+	var totalItems int
+
+	err := r.db.Get(&totalItems, "SELECT COUNT(*) FROM articles WHERE is_published = TRUE AND deleted_at IS NULL")
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	offset, limit, totalPages, orderBy, sortBy, err := pagination.GetValues(totalItems)
+
+	if err != nil {
+		return nil, totalPages, err
+	}
+
+	if !orderableColumns[orderBy] {
+		return nil, totalPages, types.ErrInvalidOrderBy
+	}
+
+	query := fmt.Sprintf(selectArticlesStatement+"WHERE is_published = TRUE AND deleted_at IS NULL ORDER BY %s %s LIMIT ? OFFSET ?", orderBy, sortBy)
+
+	err = r.db.Select(&articles, query, limit, offset)
+
+	if err != nil {
+		return nil, 0, err
+	}
+	return articles, totalPages, nil
+}
+
+// same as FindPublishedArticles, only returning the articles with the given tag
+func (r *repository) FindPublishedArticlesByTag(tag string, pagination pagination.Pagination) ([]*types.GetArticleOutput, int, error) {
+	var articles []*types.GetArticleOutput
+
+	var totalItems int
+
+	tag = strings.ToLower(strings.TrimSpace(tag))
+
+	err := r.db.Get(&totalItems, "SELECT COUNT(*) FROM articles WHERE is_published = TRUE AND deleted_at IS NULL AND id IN "+taggedArticlesStatement, tag)
 
 	if err != nil {
 		return nil, 0, err
 	}
 
-	limit, offset, totalPages, orderBy, sortBy, err := pagination.GetValues(totalItems)
+	offset, limit, totalPages, orderBy, sortBy, err := pagination.GetValues(totalItems)
 
 	if err != nil {
 		return nil, totalPages, err
 	}
 
-	err = r.db.Select(&articles, "SELECT * FROM articles WHERE author_id = ? ORDER BY ? ? LIMIT ? OFFSET ?", userId, orderBy, sortBy, limit, offset)
+	if !orderableColumns[orderBy] {
+		return nil, totalPages, types.ErrInvalidOrderBy
+	}
+
+	query := fmt.Sprintf(selectArticlesStatement+"WHERE is_published = TRUE AND deleted_at IS NULL AND id IN "+taggedArticlesStatement+" ORDER BY %s %s LIMIT ? OFFSET ?", orderBy, sortBy)
+
+	err = r.db.Select(&articles, query, tag, limit, offset)
 
 	if err != nil {
 		return nil, 0, err
@@ -73,6 +242,216 @@ func (r *repository) FindArticlesByUserId(userId int, pagination pagination.Pagi
 	return articles, totalPages, nil
 }
 
+// published articles matching the query, best matches first. matches in the title weigh the most, then the
+// description and tags, then the content. imported dev.to articles are in the table, so they are found too
+func (r *repository) SearchPublishedArticles(search string, pagination pagination.Pagination) ([]*types.ArticleSearchResult, int, error) {
+	results := []*types.ArticleSearchResult{}
+
+	match := searchQuery(search)
+
+	if match == "" {
+		return results, 0, nil
+	}
+
+	var totalItems int
+
+	err := r.db.Get(&totalItems, "SELECT COUNT(*) FROM articles_fts JOIN articles ON articles.id = articles_fts.rowid WHERE articles_fts MATCH ? AND is_published = TRUE AND deleted_at IS NULL", match)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	offset, limit, totalPages, _, _, err := pagination.GetValues(totalItems)
+
+	if err != nil {
+		return nil, totalPages, err
+	}
+
+	query := fmt.Sprintf(`SELECT %s,
+	highlight(articles_fts, 0, ?, ?) AS title_highlight,
+	snippet(articles_fts, 3, ?, ?, '...', %d) AS snippet
+FROM articles_fts JOIN articles ON articles.id = articles_fts.rowid
+WHERE articles_fts MATCH ? AND is_published = TRUE AND deleted_at IS NULL
+ORDER BY bm25(articles_fts, 10.0, 5.0, 5.0, 1.0), published_at DESC LIMIT ? OFFSET ?`, articleColumns, SEARCH_SNIPPET_WORDS)
+
+	err = r.db.Select(&results, query, types.SEARCH_MATCH_START, types.SEARCH_MATCH_END, types.SEARCH_MATCH_START, types.SEARCH_MATCH_END, match, limit, offset)
+
+	if err != nil {
+		return nil, 0, err
+	}
+	return results, totalPages, nil
+}
+
+// returns every article that has a dev.to post, imported or cross posted, including the ones removed upstream
+func (r *repository) FindDevToLinkedArticles() ([]*types.GetArticleOutput, error) {
+	var articles []*types.GetArticleOutput
+	err := r.db.Select(&articles, selectArticlesStatement+"WHERE devto_id IS NOT NULL")
+	if err != nil {
+		return nil, err
+	}
+	return articles, nil
+}
+
+// returns the published articles waiting to be cross posted to dev.to that can still be retried
+func (r *repository) FindPendingCrossPosts(maxAttempts int) ([]*types.GetArticleOutput, error) {
+	var articles []*types.GetArticleOutput
+	err := r.db.Select(&articles, selectArticlesStatement+"WHERE crosspost_status IN (?, ?) AND crosspost_attempts < ? AND is_published = TRUE AND source = ? AND deleted_at IS NULL", types.CROSSPOST_PENDING, types.CROSSPOST_FAILED, maxAttempts, types.SOURCE_LOCAL)
+	if err != nil {
+		return nil, err
+	}
+	return articles, nil
+}
+
+// drafts waiting for the publisher, the next to be published first
+func (r *repository) FindScheduledArticles() ([]*types.GetArticleOutput, error) {
+	var articles []*types.GetArticleOutput
+	err := r.db.Select(&articles, selectArticlesStatement+"WHERE publish_at IS NOT NULL AND is_published = FALSE AND deleted_at IS NULL ORDER BY publish_at ASC")
+	if err != nil {
+		return nil, err
+	}
+	return articles, nil
+}
+
+// the dev.to sync imports articles as one of the users, which must exist
+func (r *repository) CreateImportedArticle(input *types.ImportArticleInput) error {
+	err := r.inTransaction(func(tx *sqlx.Tx) error {
+		res, err := tx.Exec("INSERT INTO articles (title, slug, description, content, author_id, visibility, is_published, published_at, source, devto_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", input.Title, input.Slug, input.Description, input.Content, input.AuthorID, types.PUBLIC, true, input.PublishedAt, types.SOURCE_DEVTO, input.DevToID)
+
+		if err != nil {
+			return err
+		}
+
+		id, err := res.LastInsertId()
+
+		if err != nil {
+			return err
+		}
+
+		return setArticleTags(tx, int(id), input.Tags)
+	})
+
+	if constraint.IsForeignKey(err) {
+		return types.ErrUserNotFound
+	}
+
+	return err
+}
+
+// overwrites an imported article with its dev.to version, restoring it if it was removed before
+func (r *repository) UpdateImportedArticle(id int, input *types.ImportArticleInput) error {
+	err := r.inTransaction(func(tx *sqlx.Tx) error {
+		_, err := tx.Exec("UPDATE articles SET title = ?, slug = ?, description = ?, content = ?, published_at = ?, is_published = ?, visibility = ?, deleted_at = NULL, updated_at = ? WHERE id = ?", input.Title, input.Slug, input.Description, input.Content, input.PublishedAt, true, types.PUBLIC, time.Now(), id)
+
+		if err != nil {
+			return err
+		}
+
+		return setArticleTags(tx, id, input.Tags)
+	})
+
+	// purged since it was read
+	if constraint.IsForeignKey(err) {
+		return types.ErrArticleNotFound
+	}
+
+	return err
+}
+
+// moves the article to the trash. a scheduled draft isn't published from there, nor after being restored
+func (r *repository) SoftDeleteArticle(id int) error {
+	now := time.Now().UTC()
+
+	_, err := r.db.Exec("UPDATE articles SET deleted_at = ?, publish_at = NULL, updated_at = ? WHERE id = ?", now, now, id)
+
+	return err
+}
+
+// the articles in the trash, the last deleted first. imported articles removed from dev.to are there too
+func (r *repository) FindTrashedArticles() ([]*types.GetArticleOutput, error) {
+	var articles []*types.GetArticleOutput
+	err := r.db.Select(&articles, selectArticlesStatement+"WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC, id DESC")
+	if err != nil {
+		return nil, err
+	}
+	return articles, nil
+}
+
+// takes a native article out of the trash as it was. imported articles come back with the dev.to sync
+func (r *repository) RestoreArticle(id int) error {
+	res, err := r.db.Exec("UPDATE articles SET deleted_at = NULL, updated_at = ? WHERE id = ? AND source = ? AND deleted_at IS NOT NULL", time.Now(), id, types.SOURCE_LOCAL)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return types.ErrArticleNotFound
+	}
+
+	return nil
+}
+
+// deletes a trashed article for good, its tags, revisions, previews and comments going with it. reports whether it
+// was still in the trash, so an article restored meanwhile is kept
+func (r *repository) PurgeArticle(id int) (bool, error) {
+	res, err := r.db.Exec("DELETE FROM articles WHERE id = ? AND deleted_at IS NOT NULL", id)
+
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return n > 0, err
+}
+
+// only published native articles can be cross posted. drafts would go live on dev.to with a canonical url
+// that doesn't exist yet, and imported articles would overwrite their original dev.to post
+func (r *repository) SetCrossPostPending(id int) error {
+	article, err := r.FindArticleById(id)
+
+	if err != nil {
+		return err
+	}
+
+	if article.Source == types.SOURCE_DEVTO {
+		return types.ErrImportedArticle
+	}
+
+	if !article.IsPublished || article.DeletedAt != nil {
+		return types.ErrNotPublished
+	}
+
+	_, err = r.db.Exec("UPDATE articles SET crosspost_status = ?, crosspost_error = '', crosspost_attempts = 0 WHERE id = ?", types.CROSSPOST_PENDING, id)
+
+	return err
+}
+
+// stores the dev.to post created for the article, so later attempts update it instead of creating another one
+func (r *repository) LinkDevToArticle(id int, devToId int, devToUrl string) error {
+	_, err := r.db.Exec("UPDATE articles SET devto_id = ?, devto_url = ? WHERE id = ?", devToId, devToUrl, id)
+
+	return err
+}
+
+func (r *repository) MarkCrossPosted(id int) error {
+	_, err := r.db.Exec("UPDATE articles SET crosspost_status = ?, crosspost_error = '' WHERE id = ?", types.CROSSPOST_SYNCED, id)
+
+	return err
+}
+
+func (r *repository) MarkCrossPostFailed(id int, reason string) error {
+	_, err := r.db.Exec("UPDATE articles SET crosspost_status = ?, crosspost_error = ?, crosspost_attempts = crosspost_attempts + 1 WHERE id = ?", types.CROSSPOST_FAILED, reason, id)
+
+	return err
+}
+
 func (r *repository) CreateArticle(input *types.CreateArticleInput) (*types.GetArticleOutput, error) {
 
 	userRepo := user.NewRepository(r.db)
@@ -82,7 +461,6 @@ func (r *repository) CreateArticle(input *types.CreateArticleInput) (*types.GetA
 	}
 
 	var article types.GetArticleOutput
-	tagsString := strings.Join(input.Tags, ",")
 
 	var published_at interface{} = nil
 	var isPublishedAtInt int
@@ -97,15 +475,38 @@ func (r *repository) CreateArticle(input *types.CreateArticleInput) (*types.GetA
 	slug_id := slug.GenerateSlugId()
 	slug := slug.GenerateSlug(input.Title, slug_id)
 
-	res := r.db.MustExec("INSERT INTO articles (title, slug, slug_id, content, tags, author_id, visibility, is_published, published_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING *", input.Title, slug, slug_id, input.Content, tagsString, input.AuthorID, visibility, isPublishedAtInt, published_at)
+	var idCreated int64
+
+	err := r.inTransaction(func(tx *sqlx.Tx) error {
+		res, err := tx.Exec("INSERT INTO articles (title, slug, slug_id, content, author_id, visibility, is_published, published_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", input.Title, slug, slug_id, input.Content, input.AuthorID, visibility, isPublishedAtInt, published_at)
+
+		if err != nil {
+			return err
+		}
+
+		idCreated, err = res.LastInsertId()
+
+		if err != nil {
+			return err
+		}
+
+		if err := setArticleTags(tx, int(idCreated), input.Tags); err != nil {
+			return err
+		}
+
+		return insertRevision(tx, int(idCreated), input.Title, input.Content, input.Tags, input.AuthorID)
+	})
 
-	idCreated, err := res.LastInsertId()
+	// the author was removed after being checked
+	if constraint.IsForeignKey(err) {
+		return nil, types.ErrUserNotFound
+	}
 
 	if err != nil {
 		return nil, err
 	}
 
-	err = r.db.Get(&article, "SELECT * from articles WHERE id = ?", idCreated)
+	err = r.db.Get(&article, selectArticlesStatement+"WHERE id = ?", idCreated)
 
 	if err != nil {
 		return nil, err
@@ -122,16 +523,50 @@ func (r *repository) UpdateArticle(id int, input *types.UpdateArticleInput) (*ty
 		return nil, err
 	}
 
+	if articleToBeUpdated.Source == types.SOURCE_DEVTO {
+		return nil, types.ErrImportedArticle
+	}
+
 	slug := slug.GenerateSlug(input.Title, articleToBeUpdated.SlugID)
 
-	tagsString := strings.Join(input.Tags, ",")
+	editorId := input.EditorID
+
+	if editorId == 0 {
+		editorId = articleToBeUpdated.AuthorID
+	}
+
+	err = r.inTransaction(func(tx *sqlx.Tx) error {
+		res, err := tx.Exec("UPDATE articles SET title = ?, slug = ?, content = ?, updated_at = ? WHERE id = ? AND deleted_at IS NULL", input.Title, slug, input.Content, time.Now(), id)
+
+		if err != nil {
+			return err
+		}
+
+		// deleted since it was read
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			if err == nil {
+				err = types.ErrArticleNotFound
+			}
+			return err
+		}
+
+		if err := setArticleTags(tx, id, input.Tags); err != nil {
+			return err
+		}
+
+		return insertRevision(tx, id, input.Title, input.Content, input.Tags, editorId)
+	})
+
+	// the article is there, so the missing row is the editor
+	if constraint.IsForeignKey(err) {
+		return nil, types.ErrUserNotFound
+	}
 
-	_, err = r.db.Exec("UPDATE articles SET title = ?, slug = ?, content = ?, tags = ?, updated_at = ? WHERE id = ?", input.Title, slug, input.Content, tagsString, time.Now(), id)
 	if err != nil {
 		return nil, err
 	}
 
-	err = r.db.Get(&article, "SELECT * FROM articles WHERE id = ?", id)
+	err = r.db.Get(&article, selectArticlesStatement+"WHERE id = ?", id)
 	if err != nil {
 		return nil, types.ErrArticleNotFound
 	}
@@ -141,48 +576,204 @@ func (r *repository) UpdateArticle(id int, input *types.UpdateArticleInput) (*ty
 func (r *repository) PublishArticle(id int, input *types.PublishArticleInput) (*types.GetArticleOutput, error) {
 	var article types.GetArticleOutput
 
-	if err := r.ArticleExists(id); err != nil {
+	if err := r.nativeArticleExists(id); err != nil {
 		return nil, err
 	}
 
-	visibility := types.PUBLIC
-
 	now := time.Now()
 
-	_, err := r.db.Exec("UPDATE articles SET is_published = ?, published_at = ?, visibility = ?, updated_at = ? WHERE id = ?", input.IsPublished, now, visibility, now, id)
+	var err error
+
+	// publishing or unpublishing by hand replaces any schedule the article had
+	if input.IsPublished {
+		_, err = r.db.Exec("UPDATE articles SET is_published = ?, published_at = ?, visibility = ?, publish_at = NULL, updated_at = ? WHERE id = ?", true, now, types.PUBLIC, now, id)
+	} else {
+		_, err = r.db.Exec("UPDATE articles SET is_published = ?, visibility = ?, publish_at = NULL, updated_at = ? WHERE id = ?", false, types.PRIVATE, now, id)
+	}
+
 	if err != nil {
 		return nil, err
 	}
-	err = r.db.Get(&article, "SELECT * FROM articles WHERE id = ?", id)
+	err = r.db.Get(&article, selectArticlesStatement+"WHERE id = ?", id)
 	if err != nil {
 		return nil, types.ErrArticleNotFound
 	}
 	return &article, nil
 }
 
-func (r *repository) DeleteArticle(id int) error {
+// sets when a draft is published, or cancels its schedule when publishAt is nil.
+// times are kept in utc so the publisher can match them exactly
+func (r *repository) ScheduleArticle(id int, publishAt *time.Time) (*types.GetArticleOutput, error) {
+	if err := r.nativeArticleExists(id); err != nil {
+		return nil, err
+	}
+
+	var scheduled interface{} = nil
 
-	if err := r.ArticleExists(id); err != nil {
+	if publishAt != nil {
+		scheduled = publishAt.UTC().Truncate(time.Second)
+	}
+
+	res, err := r.db.Exec("UPDATE articles SET publish_at = ?, updated_at = ? WHERE id = ? AND is_published = FALSE", scheduled, time.Now(), id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, types.ErrAlreadyPublished
+	}
+
+	return r.FindArticleById(id)
+}
+
+// publishes the draft only if it's still scheduled for publishAt, so a run that read it before it was published,
+// rescheduled or cancelled does nothing. reports whether this call published it
+func (r *repository) PublishScheduledArticle(id int, publishAt time.Time) (bool, error) {
+	now := time.Now()
+
+	res, err := r.db.Exec("UPDATE articles SET is_published = ?, published_at = ?, visibility = ?, publish_at = NULL, updated_at = ? WHERE id = ? AND is_published = FALSE AND deleted_at IS NULL AND publish_at = ?", true, now, types.PUBLIC, now, id, publishAt.UTC())
+
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+// newest first
+func (r *repository) FindRevisionsByArticleId(articleId int) ([]*types.ArticleRevision, error) {
+	var revisions []*types.ArticleRevision
+	err := r.db.Select(&revisions, selectRevisionsStatement+"WHERE article_revisions.article_id = ? ORDER BY article_revisions.id DESC", articleId)
+	if err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// revisions of other articles aren't found, so ids taken from the url can't mix articles
+func (r *repository) FindRevisionById(articleId int, revisionId int) (*types.ArticleRevision, error) {
+	var revision types.ArticleRevision
+	err := r.db.Get(&revision, selectRevisionsStatement+"WHERE article_revisions.id = ? AND article_revisions.article_id = ?", revisionId, articleId)
+	if err == sql.ErrNoRows {
+		return nil, types.ErrRevisionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// moves the article to the trash, PurgeArticle deletes it
+func (r *repository) DeleteArticle(id int) error {
+	if err := r.nativeArticleExists(id); err != nil {
 		return err
 	}
 
-	_, err := r.db.Exec("DELETE FROM articles WHERE id = ? CASCADE", id)
+	return r.SoftDeleteArticle(id)
+}
+
+// imported articles mirror their dev.to post, which is the source of truth, so they can't be changed here.
+// local changes would be overwritten by the next sync and deleted rows would be imported again
+func (r *repository) nativeArticleExists(id int) error {
+	var source string
+	err := r.db.Get(&source, "SELECT source FROM articles WHERE id = ? AND deleted_at IS NULL", id)
+
+	if err == sql.ErrNoRows {
+		return types.ErrArticleNotFound
+	}
+
 	if err != nil {
 		return err
 	}
+
+	if source == types.SOURCE_DEVTO {
+		return types.ErrImportedArticle
+	}
+
 	return nil
 }
 
 func (r *repository) ArticleExists(id int) error {
 	var count int
-	err := r.db.Get(&count, "SELECT COUNT(*) FROM articles WHERE id = ?", id)
+	err := r.db.Get(&count, "SELECT COUNT(*) FROM articles WHERE id = ? AND deleted_at IS NULL", id)
 
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return types.ErrArticleNotFound
-		}
 		return err
 	}
 
+	if count == 0 {
+		return types.ErrArticleNotFound
+	}
+
+	return nil
+}
+
+func (r *repository) inTransaction(fn func(tx *sqlx.Tx) error) error {
+	tx, err := r.db.Beginx()
+
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// replaces the tags of the article, creating the ones that don't exist yet. tags are lowercased and keep
+// the order they were given in
+func setArticleTags(tx *sqlx.Tx, articleId int, tags []string) error {
+	if _, err := tx.Exec("DELETE FROM article_tags WHERE article_id = ?", articleId); err != nil {
+		return err
+	}
+
+	for position, name := range tagNames(tags) {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO tags (name) VALUES (?)", name); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec("INSERT INTO article_tags (article_id, tag_id, position) SELECT ?, id, ? FROM tags WHERE name = ?", articleId, position, name); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
+
+// lowercased and trimmed, without empty or repeated ones, in the order they were given
+func tagNames(tags []string) []string {
+	names := []string{}
+	seen := map[string]bool{}
+
+	for _, tag := range tags {
+		name := strings.ToLower(strings.TrimSpace(tag))
+
+		if name == "" || seen[name] {
+			continue
+		}
+
+		seen[name] = true
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// keeps the state the article was saved with, tags as the same comma separated list articles are read with
+func insertRevision(tx *sqlx.Tx, articleId int, title string, content string, tags []string, authorId int) error {
+	_, err := tx.Exec("INSERT INTO article_revisions (article_id, title, content, tags, author_id, created_at) VALUES (?, ?, ?, ?, ?, ?)", articleId, title, content, strings.Join(tagNames(tags), ","), authorId, time.Now())
+
+	return err
+}
@@ -0,0 +1,196 @@
+package article
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/samluiz/blog/common/pagination"
+	"github.com/samluiz/blog/internal/testdb"
+	"github.com/samluiz/blog/pkg/types"
+)
+
+func newTestRepository(t *testing.T) (Repository, int) {
+	t.Helper()
+
+	db, authorId := testdb.OpenWithAdmin(t)
+
+	return NewRepository(db), authorId
+}
+
+func newestFirst(page, size int) pagination.Pagination {
+	return pagination.Pagination{Page: page, Size: size, OrderBy: "published_at", SortBy: "DESC"}
+}
+
+func TestArticleTags(t *testing.T) {
+	repo, authorId := newTestRepository(t)
+
+	created, err := repo.CreateArticle(&types.CreateArticleInput{Title: "Go", Content: "go", Tags: []string{"Go", " sql", "go", ""}, AuthorID: authorId, IsPublished: true})
+
+	if err != nil {
+		t.Fatalf("CreateArticle() error = %v", err)
+	}
+
+	if created.Tags != "go,sql" {
+		t.Errorf("created tags = %q, want lowercased and without duplicates", created.Tags)
+	}
+
+	updated, err := repo.UpdateArticle(created.ID, &types.UpdateArticleInput{Title: "Go", Content: "go", Tags: []string{"web", "go"}})
+
+	if err != nil {
+		t.Fatalf("UpdateArticle() error = %v", err)
+	}
+
+	if updated.Tags != "web,go" {
+		t.Errorf("updated tags = %q, want them replaced in the given order", updated.Tags)
+	}
+}
+
+func TestFindPublishedArticlesByTag(t *testing.T) {
+	repo, authorId := newTestRepository(t)
+
+	create := func(title string, published bool, tags ...string) *types.GetArticleOutput {
+		a, err := repo.CreateArticle(&types.CreateArticleInput{Title: title, Content: title, Tags: tags, AuthorID: authorId, IsPublished: published})
+
+		if err != nil {
+			t.Fatalf("CreateArticle() error = %v", err)
+		}
+
+		return a
+	}
+
+	create("first", true, "go")
+	create("draft", false, "go")
+	create("web", true, "web")
+	removed := create("removed", true, "go")
+	create("second", true, "go", "sql")
+
+	if err := repo.SoftDeleteArticle(removed.ID); err != nil {
+		t.Fatalf("SoftDeleteArticle() error = %v", err)
+	}
+
+	articles, totalPages, err := repo.FindPublishedArticlesByTag("GO", newestFirst(1, 10))
+
+	if err != nil {
+		t.Fatalf("FindPublishedArticlesByTag() error = %v", err)
+	}
+
+	if totalPages != 1 || len(articles) != 2 {
+		t.Fatalf("got %d articles in %d pages, want only the 2 published ones", len(articles), totalPages)
+	}
+
+	for _, a := range articles {
+		if a.Title != "first" && a.Title != "second" {
+			t.Errorf("unexpected article %q", a.Title)
+		}
+	}
+
+	if _, _, err := repo.FindPublishedArticlesByTag("rust", newestFirst(2, 10)); !errors.Is(err, pagination.ErrPageOutOfRange) {
+		t.Errorf("error = %v, want %v for a page of an unknown tag", err, pagination.ErrPageOutOfRange)
+	}
+}
+
+func TestDeleteArticleMovesItToTheTrash(t *testing.T) {
+	repo, authorId := newTestRepository(t)
+
+	a, err := repo.CreateArticle(&types.CreateArticleInput{Title: "Go", Content: "go", Tags: []string{"go"}, AuthorID: authorId, IsPublished: true})
+
+	if err != nil {
+		t.Fatalf("CreateArticle() error = %v", err)
+	}
+
+	if err := repo.DeleteArticle(a.ID); err != nil {
+		t.Fatalf("DeleteArticle() error = %v", err)
+	}
+
+	if _, err := repo.FindArticleById(a.ID); !errors.Is(err, types.ErrArticleNotFound) {
+		t.Errorf("error = %v, want trashed articles not found", err)
+	}
+
+	if _, err := repo.FindArticleBySlug(a.Slug); !errors.Is(err, types.ErrArticleNotFound) {
+		t.Errorf("error = %v, want trashed articles not found by slug", err)
+	}
+
+	articles, _, err := repo.FindPublishedArticlesByTag("go", newestFirst(1, 10))
+
+	if err != nil && !errors.Is(err, pagination.ErrPageOutOfRange) {
+		t.Fatalf("FindPublishedArticlesByTag() error = %v", err)
+	}
+
+	if len(articles) != 0 {
+		t.Errorf("expected the deleted article to be gone, got %d", len(articles))
+	}
+
+	trashed, err := repo.FindTrashedArticles()
+
+	if err != nil || len(trashed) != 1 || trashed[0].ID != a.ID || trashed[0].DeletedAt == nil {
+		t.Fatalf("expected the article in the trash, got %+v, %v", trashed, err)
+	}
+
+	if err := repo.RestoreArticle(a.ID); err != nil {
+		t.Fatalf("RestoreArticle() error = %v", err)
+	}
+
+	if restored, err := repo.FindArticleById(a.ID); err != nil || !restored.IsPublished || restored.Tags != "go" {
+		t.Errorf("expected the article back as it was, got %+v, %v", restored, err)
+	}
+
+	if purged, err := repo.PurgeArticle(a.ID); purged || err != nil {
+		t.Errorf("PurgeArticle() = %v, %v, want articles outside the trash kept", purged, err)
+	}
+}
+
+func TestPurgeArticleRemovesWhatBelongsToIt(t *testing.T) {
+	repo, authorId := newTestRepository(t)
+
+	a, err := repo.CreateArticle(&types.CreateArticleInput{Title: "Go", Content: "go", Tags: []string{"go"}, AuthorID: authorId})
+
+	if err != nil {
+		t.Fatalf("CreateArticle() error = %v", err)
+	}
+
+	if err := repo.DeleteArticle(a.ID); err != nil {
+		t.Fatalf("DeleteArticle() error = %v", err)
+	}
+
+	if purged, err := repo.PurgeArticle(a.ID); !purged || err != nil {
+		t.Fatalf("PurgeArticle() = %v, %v, want it purged", purged, err)
+	}
+
+	if trashed, _ := repo.FindTrashedArticles(); len(trashed) != 0 {
+		t.Errorf("expected the trash to be empty, got %d", len(trashed))
+	}
+
+	if revisions, _ := repo.FindRevisionsByArticleId(a.ID); len(revisions) != 0 {
+		t.Errorf("expected the revisions purged, got %d", len(revisions))
+	}
+
+	if err := repo.RestoreArticle(a.ID); !errors.Is(err, types.ErrArticleNotFound) {
+		t.Errorf("error = %v, want %v", err, types.ErrArticleNotFound)
+	}
+}
+
+func TestWritesOfMissingUsers(t *testing.T) {
+	repo, authorId := newTestRepository(t)
+
+	if _, err := repo.CreateArticle(&types.CreateArticleInput{Title: "Go", Content: "go", AuthorID: 42}); !errors.Is(err, types.ErrUserNotFound) {
+		t.Errorf("CreateArticle() error = %v, want %v", err, types.ErrUserNotFound)
+	}
+
+	if err := repo.CreateImportedArticle(&types.ImportArticleInput{Title: "Go", Slug: "go", AuthorID: 42, DevToID: 1}); !errors.Is(err, types.ErrUserNotFound) {
+		t.Errorf("CreateImportedArticle() error = %v, want %v", err, types.ErrUserNotFound)
+	}
+
+	a, err := repo.CreateArticle(&types.CreateArticleInput{Title: "Go", Content: "go", AuthorID: authorId})
+
+	if err != nil {
+		t.Fatalf("CreateArticle() error = %v", err)
+	}
+
+	if _, err := repo.UpdateArticle(a.ID, &types.UpdateArticleInput{Title: "Go", Content: "changed", EditorID: 42}); !errors.Is(err, types.ErrUserNotFound) {
+		t.Errorf("UpdateArticle() error = %v, want %v", err, types.ErrUserNotFound)
+	}
+
+	if revisions, _ := repo.FindRevisionsByArticleId(a.ID); len(revisions) != 1 {
+		t.Errorf("got %d revisions, want the update rolled back", len(revisions))
+	}
+}
@@ -0,0 +1,39 @@
+package article
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	// longer queries are cut, so a pasted paragraph doesn't turn into a huge fts5 query
+	MAX_SEARCH_TERMS     = 8
+	SEARCH_SNIPPET_WORDS = 24
+)
+
+// turns what the reader typed into an fts5 query where every word must match. the last word matches as a prefix,
+// so results show up while typing. words are quoted and only letters and numbers are kept, so the fts5 syntax
+// can't be used to break the query
+func searchQuery(query string) string {
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	if len(words) > MAX_SEARCH_TERMS {
+		words = words[:MAX_SEARCH_TERMS]
+	}
+
+	terms := make([]string, 0, len(words))
+
+	for i, word := range words {
+		term := `"` + word + `"`
+
+		if i == len(words)-1 {
+			term += "*"
+		}
+
+		terms = append(terms, term)
+	}
+
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,122 @@
+package article
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samluiz/blog/pkg/types"
+)
+
+func TestSearchQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "", want: ""},
+		{query: "  ?! ", want: ""},
+		{query: "go", want: `"go"*`},
+		{query: "Go SQL", want: `"Go" "SQL"*`},
+		{query: `go" OR title:*`, want: `"go" "OR" "title"*`},
+		{query: "ação-rápida", want: `"ação" "rápida"*`},
+		{query: "a b c d e f g h i j", want: `"a" "b" "c" "d" "e" "f" "g" "h"*`},
+	}
+
+	for _, tt := range tests {
+		if got := searchQuery(tt.query); got != tt.want {
+			t.Errorf("searchQuery(%q) = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSearchPublishedArticles(t *testing.T) {
+	repo, authorId := newTestRepository(t)
+
+	create := func(title, content string, published bool, tags ...string) *types.GetArticleOutput {
+		a, err := repo.CreateArticle(&types.CreateArticleInput{Title: title, Content: content, Tags: tags, AuthorID: authorId, IsPublished: published})
+
+		if err != nil {
+			t.Fatalf("CreateArticle() error = %v", err)
+		}
+
+		return a
+	}
+
+	inContent := create("Databases", "how to use sqlite with go", true)
+	inTitle := create("Sqlite in practice", "a few tips", true)
+	create("Sqlite draft", "not ready", false)
+	tagged := create("Notes", "nothing to see", true, "sqlite")
+
+	if err := repo.CreateImportedArticle(&types.ImportArticleInput{DevToID: 1, Title: "Imported", Slug: "imported", Content: "sqlite on dev.to", AuthorID: authorId, PublishedAt: time.Now()}); err != nil {
+		t.Fatalf("CreateImportedArticle() error = %v", err)
+	}
+
+	search := func(query string) []*types.ArticleSearchResult {
+		t.Helper()
+
+		results, _, err := repo.SearchPublishedArticles(query, newestFirst(1, 10))
+
+		if err != nil {
+			t.Fatalf("SearchPublishedArticles(%q) error = %v", query, err)
+		}
+
+		return results
+	}
+
+	results := search("SQLite")
+
+	if len(results) != 4 {
+		t.Fatalf("got %d results, want the 4 published articles", len(results))
+	}
+
+	if results[0].ID != inTitle.ID {
+		t.Errorf("expected the title match first, got %q", results[0].Title)
+	}
+
+	if want := types.SEARCH_MATCH_START + "Sqlite" + types.SEARCH_MATCH_END + " in practice"; results[0].TitleHighlight != want {
+		t.Errorf("title highlight = %q, want %q", results[0].TitleHighlight, want)
+	}
+
+	for _, r := range results {
+		if r.ID == inContent.ID && !strings.Contains(r.Snippet, types.SEARCH_MATCH_START+"sqlite"+types.SEARCH_MATCH_END) {
+			t.Errorf("expected the match to be marked in the snippet, got %q", r.Snippet)
+		}
+
+		if r.ID == tagged.ID && r.Tags != "sqlite" {
+			t.Errorf("expected the tags of the result, got %q", r.Tags)
+		}
+	}
+
+	if got := search("sqli"); len(got) != 4 {
+		t.Errorf("got %d results for a prefix, want 4", len(got))
+	}
+
+	if got := search("sqlite practice"); len(got) != 1 || got[0].ID != inTitle.ID {
+		t.Errorf("expected every word to be required, got %d results", len(got))
+	}
+
+	if got := search(`" OR *`); len(got) != 0 {
+		t.Errorf("expected no results for a query without words, got %d", len(got))
+	}
+
+	// the index follows the changes made to the articles and their tags
+	if _, err := repo.UpdateArticle(inContent.ID, &types.UpdateArticleInput{Title: "Databases", Content: "postgres only", Tags: []string{"postgres"}}); err != nil {
+		t.Fatalf("UpdateArticle() error = %v", err)
+	}
+
+	if _, err := repo.UpdateArticle(tagged.ID, &types.UpdateArticleInput{Title: "Notes", Content: "nothing to see"}); err != nil {
+		t.Fatalf("UpdateArticle() error = %v", err)
+	}
+
+	if err := repo.DeleteArticle(inTitle.ID); err != nil {
+		t.Fatalf("DeleteArticle() error = %v", err)
+	}
+
+	if got := search("sqlite"); len(got) != 1 || got[0].Title != "Imported" {
+		t.Errorf("expected only the imported article to be left, got %d results", len(got))
+	}
+
+	if got := search("postgres"); len(got) != 1 || got[0].ID != inContent.ID {
+		t.Errorf("expected the updated article, got %d results", len(got))
+	}
+}
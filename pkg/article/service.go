@@ -1,16 +1,42 @@
 package article
 
 import (
+	"strings"
+	"time"
+
 	"github.com/samluiz/blog/common/pagination"
 	"github.com/samluiz/blog/pkg/types"
 )
 
 type Service interface {
 	FindArticleById(id int) (*types.GetArticleOutput, error)
+	FindArticleBySlug(slug string) (*types.GetArticleOutput, error)
 	FindArticlesByUserId(userId int, pagination pagination.Pagination) ([]*types.GetArticleOutput, int, error)
+	FindArticlesByUserIdAndPublished(userId int, isPublished bool, pagination pagination.Pagination) ([]*types.GetArticleOutput, int, error)
+	FindPublishedArticles(pagination pagination.Pagination) ([]*types.GetArticleOutput, int, error)
+	FindPublishedArticlesByTag(tag string, pagination pagination.Pagination) ([]*types.GetArticleOutput, int, error)
+	SearchPublishedArticles(query string, pagination pagination.Pagination) ([]*types.ArticleSearchResult, int, error)
+	FindDevToLinkedArticles() ([]*types.GetArticleOutput, error)
+	FindPendingCrossPosts(maxAttempts int) ([]*types.GetArticleOutput, error)
+	FindScheduledArticles() ([]*types.GetArticleOutput, error)
+	CreateImportedArticle(input *types.ImportArticleInput) error
+	UpdateImportedArticle(id int, input *types.ImportArticleInput) error
+	SoftDeleteArticle(id int) error
+	FindTrashedArticles() ([]*types.GetArticleOutput, error)
+	RestoreArticle(id int) error
+	PurgeArticle(id int) (bool, error)
+	SetCrossPostPending(id int) error
+	LinkDevToArticle(id int, devToId int, devToUrl string) error
+	MarkCrossPosted(id int) error
+	MarkCrossPostFailed(id int, reason string) error
 	CreateArticle(input *types.CreateArticleInput) (*types.GetArticleOutput, error)
 	UpdateArticle(id int, input *types.UpdateArticleInput) (*types.GetArticleOutput, error)
 	PublishArticle(id int, input *types.PublishArticleInput) (*types.GetArticleOutput, error)
+	ScheduleArticle(id int, publishAt *time.Time) (*types.GetArticleOutput, error)
+	PublishScheduledArticle(id int, publishAt time.Time) (bool, error)
+	FindRevisionsByArticleId(articleId int) ([]*types.ArticleRevision, error)
+	FindRevisionById(articleId int, revisionId int) (*types.ArticleRevision, error)
+	RestoreRevision(articleId int, revisionId int, editorId int) (*types.GetArticleOutput, error)
 	DeleteArticle(id int) error
 }
 
@@ -26,10 +52,82 @@ func (s *service) FindArticleById(id int) (*types.GetArticleOutput, error) {
 	return s.repo.FindArticleById(id)
 }
 
+func (s *service) FindArticleBySlug(slug string) (*types.GetArticleOutput, error) {
+	return s.repo.FindArticleBySlug(slug)
+}
+
 func (s *service) FindArticlesByUserId(userId int, pagination pagination.Pagination) ([]*types.GetArticleOutput, int, error) {
 	return s.repo.FindArticlesByUserId(userId, pagination)
 }
 
+func (s *service) FindArticlesByUserIdAndPublished(userId int, isPublished bool, pagination pagination.Pagination) ([]*types.GetArticleOutput, int, error) {
+	return s.repo.FindArticlesByUserIdAndPublished(userId, isPublished, pagination)
+}
+
+func (s *service) FindPublishedArticles(pagination pagination.Pagination) ([]*types.GetArticleOutput, int, error) {
+	return s.repo.FindPublishedArticles(pagination)
+}
+
+func (s *service) FindPublishedArticlesByTag(tag string, pagination pagination.Pagination) ([]*types.GetArticleOutput, int, error) {
+	return s.repo.FindPublishedArticlesByTag(tag, pagination)
+}
+
+func (s *service) SearchPublishedArticles(query string, pagination pagination.Pagination) ([]*types.ArticleSearchResult, int, error) {
+	return s.repo.SearchPublishedArticles(query, pagination)
+}
+
+func (s *service) FindDevToLinkedArticles() ([]*types.GetArticleOutput, error) {
+	return s.repo.FindDevToLinkedArticles()
+}
+
+func (s *service) FindPendingCrossPosts(maxAttempts int) ([]*types.GetArticleOutput, error) {
+	return s.repo.FindPendingCrossPosts(maxAttempts)
+}
+
+func (s *service) FindScheduledArticles() ([]*types.GetArticleOutput, error) {
+	return s.repo.FindScheduledArticles()
+}
+
+func (s *service) CreateImportedArticle(input *types.ImportArticleInput) error {
+	return s.repo.CreateImportedArticle(input)
+}
+
+func (s *service) UpdateImportedArticle(id int, input *types.ImportArticleInput) error {
+	return s.repo.UpdateImportedArticle(id, input)
+}
+
+func (s *service) SoftDeleteArticle(id int) error {
+	return s.repo.SoftDeleteArticle(id)
+}
+
+func (s *service) FindTrashedArticles() ([]*types.GetArticleOutput, error) {
+	return s.repo.FindTrashedArticles()
+}
+
+func (s *service) RestoreArticle(id int) error {
+	return s.repo.RestoreArticle(id)
+}
+
+func (s *service) PurgeArticle(id int) (bool, error) {
+	return s.repo.PurgeArticle(id)
+}
+
+func (s *service) SetCrossPostPending(id int) error {
+	return s.repo.SetCrossPostPending(id)
+}
+
+func (s *service) LinkDevToArticle(id int, devToId int, devToUrl string) error {
+	return s.repo.LinkDevToArticle(id, devToId, devToUrl)
+}
+
+func (s *service) MarkCrossPosted(id int) error {
+	return s.repo.MarkCrossPosted(id)
+}
+
+func (s *service) MarkCrossPostFailed(id int, reason string) error {
+	return s.repo.MarkCrossPostFailed(id, reason)
+}
+
 func (s *service) CreateArticle(input *types.CreateArticleInput) (*types.GetArticleOutput, error) {
 	return s.repo.CreateArticle(input)
 }
@@ -42,6 +140,49 @@ func (s *service) PublishArticle(id int, input *types.PublishArticleInput) (*typ
 	return s.repo.PublishArticle(id, input)
 }
 
+// only future times can be set, a nil time cancels the schedule
+func (s *service) ScheduleArticle(id int, publishAt *time.Time) (*types.GetArticleOutput, error) {
+	if publishAt != nil && !publishAt.After(time.Now()) {
+		return nil, types.ErrScheduleInPast
+	}
+
+	return s.repo.ScheduleArticle(id, publishAt)
+}
+
+func (s *service) PublishScheduledArticle(id int, publishAt time.Time) (bool, error) {
+	return s.repo.PublishScheduledArticle(id, publishAt)
+}
+
+func (s *service) FindRevisionsByArticleId(articleId int) ([]*types.ArticleRevision, error) {
+	return s.repo.FindRevisionsByArticleId(articleId)
+}
+
+func (s *service) FindRevisionById(articleId int, revisionId int) (*types.ArticleRevision, error) {
+	return s.repo.FindRevisionById(articleId, revisionId)
+}
+
+// saves the article again as it was in the revision, so the restore is a new revision and the history is kept
+func (s *service) RestoreRevision(articleId int, revisionId int, editorId int) (*types.GetArticleOutput, error) {
+	revision, err := s.repo.FindRevisionById(articleId, revisionId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+
+	if revision.Tags != "" {
+		tags = strings.Split(revision.Tags, ",")
+	}
+
+	return s.repo.UpdateArticle(articleId, &types.UpdateArticleInput{
+		Title:    revision.Title,
+		Content:  revision.Content,
+		Tags:     tags,
+		EditorID: editorId,
+	})
+}
+
 func (s *service) DeleteArticle(id int) error {
 	return s.repo.DeleteArticle(id)
 }
@@ -0,0 +1,307 @@
+package comment
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/samluiz/blog/internal/testdb"
+	"github.com/samluiz/blog/pkg/types"
+)
+
+// readers are trusted right away, so comments are approved unless they look like spam
+var trustEveryone = ModerationRules{MaxLinks: 2}
+
+type fixture struct {
+	service   Service
+	adminId   int
+	readerId  int
+	articleId int
+	otherId   int
+}
+
+func newFixture(t *testing.T, rules ModerationRules) fixture {
+	t.Helper()
+
+	db, adminId := testdb.OpenWithAdmin(t)
+
+	insert := func(query string, args ...any) int {
+		result := db.MustExec(query, args...)
+		id, _ := result.LastInsertId()
+		return int(id)
+	}
+
+	f := fixture{service: NewService(NewRepository(db), rules), adminId: adminId}
+	// same id as the admin on purpose, so mixing up the author tables would show
+	f.readerId = insert("INSERT INTO external_users (id, provider_id, name, username, provider) VALUES (?, 42, 'Reader', 'reader', 'github')", f.adminId)
+	f.articleId = insert("INSERT INTO articles (title, slug, author_id, is_published) VALUES ('First', 'first', ?, 1)", f.adminId)
+	f.otherId = insert("INSERT INTO articles (title, slug, author_id, is_published) VALUES ('Second', 'second', ?, 1)", f.adminId)
+
+	return f
+}
+
+func (f fixture) comment(t *testing.T, articleId int, parentId *int, authorId int, authorType string) *types.Comment {
+	t.Helper()
+
+	return f.commentWith(t, "hello", articleId, parentId, authorId, authorType)
+}
+
+func (f fixture) commentWith(t *testing.T, content string, articleId int, parentId *int, authorId int, authorType string) *types.Comment {
+	t.Helper()
+
+	c, err := f.service.CreateComment(&types.CreateCommentInput{
+		Content:    content,
+		ArticleID:  articleId,
+		ParentID:   parentId,
+		AuthorID:   authorId,
+		AuthorType: authorType,
+	})
+
+	if err != nil {
+		t.Fatalf("error creating the comment: %v", err)
+	}
+
+	return c
+}
+
+func TestCreateComment(t *testing.T) {
+	f := newFixture(t, trustEveryone)
+
+	c := f.comment(t, f.articleId, nil, f.readerId, types.AUTHOR_EXTERNAL)
+
+	if c.AuthorUsername != "reader" {
+		t.Errorf("expected the external author to be joined, got %q", c.AuthorUsername)
+	}
+
+	reply := f.comment(t, f.articleId, &c.ID, f.adminId, types.AUTHOR_USER)
+
+	if reply.AuthorUsername != "admin" || reply.ParentID == nil || *reply.ParentID != c.ID {
+		t.Errorf("expected a reply by admin to %d, got %+v", c.ID, reply)
+	}
+}
+
+func TestCreateCommentValidation(t *testing.T) {
+	f := newFixture(t, trustEveryone)
+	c := f.comment(t, f.articleId, nil, f.readerId, types.AUTHOR_EXTERNAL)
+	missing := 999
+
+	tests := []struct {
+		name  string
+		input types.CreateCommentInput
+		err   error
+	}{
+		{"reply on another article", types.CreateCommentInput{ArticleID: f.otherId, ParentID: &c.ID, AuthorID: f.readerId, AuthorType: types.AUTHOR_EXTERNAL}, types.ErrInvalidParentComment},
+		{"deleted parent", types.CreateCommentInput{ArticleID: f.articleId, ParentID: &missing, AuthorID: f.readerId, AuthorType: types.AUTHOR_EXTERNAL}, types.ErrCommentNotFound},
+		{"missing author", types.CreateCommentInput{ArticleID: f.articleId, AuthorID: missing, AuthorType: types.AUTHOR_EXTERNAL}, types.ErrUserNotFound},
+		{"missing article", types.CreateCommentInput{ArticleID: missing, AuthorID: f.readerId, AuthorType: types.AUTHOR_EXTERNAL}, types.ErrArticleNotFound},
+		{"unknown author type", types.CreateCommentInput{ArticleID: f.articleId, AuthorID: f.readerId, AuthorType: "robot"}, types.ErrInvalidAuthorType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.input.Content = "hello"
+
+			if _, err := f.service.CreateComment(&tt.input); !errors.Is(err, tt.err) {
+				t.Errorf("expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestFindCommentThreads(t *testing.T) {
+	f := newFixture(t, trustEveryone)
+
+	first := f.comment(t, f.articleId, nil, f.readerId, types.AUTHOR_EXTERNAL)
+	second := f.comment(t, f.articleId, nil, f.adminId, types.AUTHOR_USER)
+	reply := f.comment(t, f.articleId, &first.ID, f.adminId, types.AUTHOR_USER)
+	f.comment(t, f.articleId, &reply.ID, f.readerId, types.AUTHOR_EXTERNAL)
+	f.comment(t, f.otherId, nil, f.readerId, types.AUTHOR_EXTERNAL)
+
+	threads, err := f.service.FindCommentThreadsByArticleId(f.articleId)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(threads) != 2 || threads[0].ID != first.ID || threads[1].ID != second.ID {
+		t.Fatalf("expected the two top level comments in order, got %+v", threads)
+	}
+
+	if len(threads[0].Replies) != 1 || len(threads[0].Replies[0].Replies) != 1 {
+		t.Errorf("expected a reply with a nested reply, got %+v", threads[0].Replies)
+	}
+}
+
+func TestUpdateAndDeleteAuthorization(t *testing.T) {
+	f := newFixture(t, trustEveryone)
+
+	c := f.comment(t, f.articleId, nil, f.readerId, types.AUTHOR_EXTERNAL)
+	f.comment(t, f.articleId, &c.ID, f.readerId, types.AUTHOR_EXTERNAL)
+
+	reader := types.CommentActor{ID: f.readerId, Type: types.AUTHOR_EXTERNAL}
+	// a user with the same id as the author, but from the other table
+	impostor := types.CommentActor{ID: f.readerId, Type: types.AUTHOR_USER}
+	admin := types.CommentActor{ID: f.adminId, Type: types.AUTHOR_USER, IsAdmin: true}
+
+	if _, err := f.service.UpdateComment(c.ID, &types.UpdateCommentInput{Content: "changed"}, impostor); !errors.Is(err, types.ErrUserUnauthorized) {
+		t.Errorf("expected ErrUserUnauthorized, got %v", err)
+	}
+
+	updated, err := f.service.UpdateComment(c.ID, &types.UpdateCommentInput{Content: "changed"}, reader)
+
+	if err != nil || updated.Content != "changed" {
+		t.Fatalf("expected the author to edit the comment, got %+v, %v", updated, err)
+	}
+
+	if err := f.service.DeleteComment(c.ID, impostor); !errors.Is(err, types.ErrUserUnauthorized) {
+		t.Errorf("expected ErrUserUnauthorized, got %v", err)
+	}
+
+	if err := f.service.DeleteComment(c.ID, admin); err != nil {
+		t.Fatalf("expected the admin to delete the comment, got %v", err)
+	}
+
+	comments, err := f.service.FindCommentsByArticleId(f.articleId)
+
+	if err != nil || len(comments) != 0 {
+		t.Errorf("expected the replies to be deleted with the comment, got %d, %v", len(comments), err)
+	}
+
+	if err := f.service.DeleteComment(c.ID, admin); !errors.Is(err, types.ErrCommentNotFound) {
+		t.Errorf("expected ErrCommentNotFound, got %v", err)
+	}
+}
+
+func TestBuildThreadsHidesOrphans(t *testing.T) {
+	missing := 99
+	one := 1
+	two := 2
+
+	threads := BuildThreads([]*types.Comment{
+		{ID: 1},
+		{ID: 2, ParentID: &missing},
+		{ID: 3, ParentID: &one},
+		{ID: 4, ParentID: &two},
+	})
+
+	if len(threads) != 1 || threads[0].ID != 1 {
+		t.Fatalf("expected only comment 1 at the top level, got %+v", threads)
+	}
+
+	if len(threads[0].Replies) != 1 || threads[0].Replies[0].ID != 3 {
+		t.Errorf("expected comment 3 under comment 1, got %+v", threads[0].Replies)
+	}
+}
+
+func TestThreadsHideRepliesToRejectedComments(t *testing.T) {
+	f := newFixture(t, trustEveryone)
+
+	c := f.comment(t, f.articleId, nil, f.readerId, types.AUTHOR_EXTERNAL)
+	f.comment(t, f.articleId, &c.ID, f.adminId, types.AUTHOR_USER)
+
+	if _, err := f.service.ModerateComments([]int{c.ID}, types.MODERATE_REJECT); err != nil {
+		t.Fatalf("ModerateComments() error = %v", err)
+	}
+
+	threads, err := f.service.FindCommentThreadsByArticleId(f.articleId)
+
+	if err != nil {
+		t.Fatalf("FindCommentThreadsByArticleId() error = %v", err)
+	}
+
+	if len(threads) != 0 {
+		t.Errorf("expected the reply to be hidden with the rejected comment, got %+v", threads)
+	}
+}
+
+func TestCommentTrash(t *testing.T) {
+	f := newFixture(t, trustEveryone)
+
+	admin := types.CommentActor{ID: f.adminId, Type: types.AUTHOR_USER, IsAdmin: true}
+
+	c := f.comment(t, f.articleId, nil, f.readerId, types.AUTHOR_EXTERNAL)
+	reply := f.comment(t, f.articleId, &c.ID, f.adminId, types.AUTHOR_USER)
+	kept := f.comment(t, f.articleId, nil, f.adminId, types.AUTHOR_USER)
+
+	if err := f.service.DeleteComment(c.ID, admin); err != nil {
+		t.Fatalf("DeleteComment() error = %v", err)
+	}
+
+	if _, err := f.service.FindCommentById(reply.ID); !errors.Is(err, types.ErrCommentNotFound) {
+		t.Errorf("error = %v, want the reply trashed with the comment", err)
+	}
+
+	trashed, err := f.service.FindTrashedComments()
+
+	if err != nil || len(trashed) != 1 || trashed[0].ID != c.ID || trashed[0].DeletedAt == nil {
+		t.Fatalf("expected only the deleted comment in the trash, got %+v, %v", trashed, err)
+	}
+
+	if err := f.service.RestoreComment(c.ID); err != nil {
+		t.Fatalf("RestoreComment() error = %v", err)
+	}
+
+	if comments, _ := f.service.FindCommentsByArticleId(f.articleId); len(comments) != 3 {
+		t.Errorf("expected the comment restored with its reply, got %d comments", len(comments))
+	}
+
+	if err := f.service.RestoreComment(kept.ID); !errors.Is(err, types.ErrCommentNotFound) {
+		t.Errorf("error = %v, want %v restoring a comment that isn't in the trash", err, types.ErrCommentNotFound)
+	}
+
+	if purged, err := f.service.PurgeComment(kept.ID); purged || err != nil {
+		t.Errorf("PurgeComment() = %v, %v, want comments outside the trash kept", purged, err)
+	}
+
+	if err := f.service.DeleteComment(c.ID, admin); err != nil {
+		t.Fatalf("DeleteComment() error = %v", err)
+	}
+
+	if purged, err := f.service.PurgeComment(c.ID); !purged || err != nil {
+		t.Fatalf("PurgeComment() = %v, %v, want it purged", purged, err)
+	}
+
+	if err := f.service.RestoreComment(reply.ID); !errors.Is(err, types.ErrCommentNotFound) {
+		t.Errorf("error = %v, want the reply purged with the comment", err)
+	}
+
+	if comments, _ := f.service.FindCommentsByArticleId(f.articleId); len(comments) != 1 || comments[0].ID != kept.ID {
+		t.Errorf("expected only the other comment left, got %d", len(comments))
+	}
+}
+
+func TestRestoreReplyWithTrashedParent(t *testing.T) {
+	f := newFixture(t, trustEveryone)
+
+	admin := types.CommentActor{ID: f.adminId, Type: types.AUTHOR_USER, IsAdmin: true}
+
+	c := f.comment(t, f.articleId, nil, f.readerId, types.AUTHOR_EXTERNAL)
+	reply := f.comment(t, f.articleId, &c.ID, f.adminId, types.AUTHOR_USER)
+
+	if err := f.service.DeleteComment(reply.ID, admin); err != nil {
+		t.Fatalf("DeleteComment() error = %v", err)
+	}
+
+	if err := f.service.DeleteComment(c.ID, admin); err != nil {
+		t.Fatalf("DeleteComment() error = %v", err)
+	}
+
+	if err := f.service.RestoreComment(reply.ID); !errors.Is(err, types.ErrParentCommentTrashed) {
+		t.Fatalf("error = %v, want %v restoring a reply under a trashed comment", err, types.ErrParentCommentTrashed)
+	}
+
+	if err := f.service.RestoreComment(c.ID); err != nil {
+		t.Fatalf("RestoreComment() error = %v", err)
+	}
+
+	if comments, _ := f.service.FindCommentsByArticleId(f.articleId); len(comments) != 1 || comments[0].ID != c.ID {
+		t.Fatalf("expected only the comment restored, got %+v", comments)
+	}
+
+	if err := f.service.RestoreComment(reply.ID); err != nil {
+		t.Fatalf("RestoreComment() error = %v", err)
+	}
+
+	if comments, _ := f.service.FindCommentsByArticleId(f.articleId); len(comments) != 2 {
+		t.Errorf("expected the reply restored, got %d comments", len(comments))
+	}
+}
@@ -0,0 +1,55 @@
+package comment
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/samluiz/blog/pkg/types"
+)
+
+var linkPattern = regexp.MustCompile(`(?i)https?://|www\.`)
+
+// decides which comments of readers are published right away and which wait in the moderation queue
+type ModerationRules struct {
+	// authors with this many approved comments are trusted and skip the queue. 0 trusts everyone
+	TrustedAfter int
+	// comments with more links than this are marked as spam
+	MaxLinks int
+	// comments containing any of these words, ignoring case, are marked as spam
+	Blocklist []string
+}
+
+// returns the status of a new comment, given how many comments of its author were already approved
+func (r ModerationRules) Status(content string, approvedComments int) string {
+	if r.IsSpam(content) {
+		return types.COMMENT_SPAM
+	}
+
+	if approvedComments >= r.TrustedAfter {
+		return types.COMMENT_APPROVED
+	}
+
+	return types.COMMENT_PENDING
+}
+
+func (r ModerationRules) IsSpam(content string) bool {
+	if CountLinks(content) > r.MaxLinks {
+		return true
+	}
+
+	lower := strings.ToLower(content)
+
+	for _, word := range r.Blocklist {
+		word = strings.ToLower(strings.TrimSpace(word))
+
+		if word != "" && strings.Contains(lower, word) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func CountLinks(content string) int {
+	return len(linkPattern.FindAllStringIndex(content, -1))
+}
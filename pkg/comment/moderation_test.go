@@ -0,0 +1,119 @@
+package comment
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/samluiz/blog/common/pagination"
+	"github.com/samluiz/blog/pkg/types"
+)
+
+func TestModerationRulesStatus(t *testing.T) {
+	rules := ModerationRules{TrustedAfter: 2, MaxLinks: 1, Blocklist: []string{"Casino", " "}}
+
+	tests := []struct {
+		name     string
+		content  string
+		approved int
+		want     string
+	}{
+		{"new author", "nice post", 0, types.COMMENT_PENDING},
+		{"trusted author", "nice post", 2, types.COMMENT_APPROVED},
+		{"one link", "see https://example.com", 2, types.COMMENT_APPROVED},
+		{"too many links", "see http://a.com and www.b.com", 5, types.COMMENT_SPAM},
+		{"blocklisted word", "best CASINO bonus", 5, types.COMMENT_SPAM},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rules.Status(tt.content, tt.approved); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewReadersWaitForModeration(t *testing.T) {
+	f := newFixture(t, ModerationRules{TrustedAfter: 1, MaxLinks: 2})
+
+	first := f.comment(t, f.articleId, nil, f.readerId, types.AUTHOR_EXTERNAL)
+
+	if first.Status != types.COMMENT_PENDING {
+		t.Fatalf("expected the first comment of a reader to be pending, got %s", first.Status)
+	}
+
+	if admin := f.comment(t, f.articleId, nil, f.adminId, types.AUTHOR_USER); admin.Status != types.COMMENT_APPROVED {
+		t.Errorf("expected comments of admins to be approved, got %s", admin.Status)
+	}
+
+	public, _ := f.service.FindCommentsByArticleId(f.articleId)
+
+	if len(public) != 1 {
+		t.Fatalf("expected only the approved comment to be public, got %d", len(public))
+	}
+
+	if _, err := f.service.CreateComment(&types.CreateCommentInput{Content: "reply", ArticleID: f.articleId, ParentID: &first.ID, AuthorID: f.adminId, AuthorType: types.AUTHOR_USER}); !errors.Is(err, types.ErrCommentNotFound) {
+		t.Errorf("expected replies to pending comments to be refused, got %v", err)
+	}
+
+	if n, err := f.service.ModerateComments([]int{first.ID}, types.MODERATE_APPROVE); err != nil || n != 1 {
+		t.Fatalf("expected one comment approved, got %d, %v", n, err)
+	}
+
+	if second := f.comment(t, f.articleId, nil, f.readerId, types.AUTHOR_EXTERNAL); second.Status != types.COMMENT_APPROVED {
+		t.Errorf("expected the reader to be trusted after an approved comment, got %s", second.Status)
+	}
+}
+
+func TestEditsAreModerated(t *testing.T) {
+	f := newFixture(t, trustEveryone)
+	reader := types.CommentActor{ID: f.readerId, Type: types.AUTHOR_EXTERNAL}
+
+	c := f.comment(t, f.articleId, nil, f.readerId, types.AUTHOR_EXTERNAL)
+
+	updated, err := f.service.UpdateComment(c.ID, &types.UpdateCommentInput{Content: "http://a.com http://b.com http://c.com"}, reader)
+
+	if err != nil || updated.Status != types.COMMENT_SPAM {
+		t.Fatalf("expected the edit to be marked as spam, got %+v, %v", updated, err)
+	}
+}
+
+func TestBanCommentAuthors(t *testing.T) {
+	f := newFixture(t, ModerationRules{TrustedAfter: 1, MaxLinks: 2})
+
+	selected := f.comment(t, f.articleId, nil, f.readerId, types.AUTHOR_EXTERNAL)
+	f.comment(t, f.otherId, nil, f.readerId, types.AUTHOR_EXTERNAL)
+	admin := f.comment(t, f.articleId, nil, f.adminId, types.AUTHOR_USER)
+
+	banned, err := f.service.ModerateComments([]int{selected.ID, admin.ID}, types.MODERATE_BAN)
+
+	if err != nil || banned != 1 {
+		t.Fatalf("expected only the reader to be banned, got %d, %v", banned, err)
+	}
+
+	spam, _, err := f.service.FindCommentsByStatus(types.COMMENT_SPAM, pagination.Pagination{})
+
+	if err != nil || len(spam) != 2 || !spam[0].AuthorBanned {
+		t.Fatalf("expected both comments of the banned reader to be spam, got %+v, %v", spam, err)
+	}
+
+	if c, _ := f.service.FindCommentById(admin.ID); c.Status != types.COMMENT_APPROVED {
+		t.Errorf("expected the comment of the admin to stay approved, got %s", c.Status)
+	}
+
+	if _, err := f.service.CreateComment(&types.CreateCommentInput{Content: "again", ArticleID: f.articleId, AuthorID: f.readerId, AuthorType: types.AUTHOR_EXTERNAL}); !errors.Is(err, types.ErrUserBanned) {
+		t.Errorf("expected ErrUserBanned, got %v", err)
+	}
+}
+
+func TestModerateCommentsValidation(t *testing.T) {
+	f := newFixture(t, trustEveryone)
+
+	if _, err := f.service.ModerateComments([]int{1}, "delete"); !errors.Is(err, types.ErrInvalidModeration) {
+		t.Errorf("expected ErrInvalidModeration, got %v", err)
+	}
+
+	if _, _, err := f.service.FindCommentsByStatus("hidden", pagination.Pagination{}); !errors.Is(err, types.ErrInvalidCommentStatus) {
+		t.Errorf("expected ErrInvalidCommentStatus, got %v", err)
+	}
+}
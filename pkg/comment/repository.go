@@ -2,8 +2,12 @@ package comment
 
 import (
 	"database/sql"
+	"fmt"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/samluiz/blog/common/constraint"
+	"github.com/samluiz/blog/common/pagination"
 	"github.com/samluiz/blog/pkg/article"
 	"github.com/samluiz/blog/pkg/types"
 	"github.com/samluiz/blog/pkg/user"
@@ -18,6 +22,51 @@ type Repository interface {
 	DeleteComment(id int) error
 	DeleteCommentsByArticleId(articleId int) error
 	CommentExists(id int) error
+	FindCommentsByStatus(status string, pagination pagination.Pagination) ([]*types.Comment, int, error)
+	CountApprovedCommentsByAuthor(authorId int, authorType string) (int, error)
+	IsAuthorBanned(authorId int, authorType string) (bool, error)
+	SetCommentsStatus(ids []int, status string) (int, error)
+	BanCommentAuthors(ids []int) (int, error)
+	FindTrashedComments() ([]*types.Comment, error)
+	RestoreComment(id int) error
+	PurgeComment(id int) (bool, error)
+}
+
+// comments joined with the username and avatar of their author, which can be a user or an external user,
+// and with the article they belong to, which the moderation queue links to
+const selectCommentsStatement = `
+SELECT c.id, c.content, c.article_id, c.parent_id, c.author_id, c.author_type, c.status, c.created_at, c.updated_at, c.deleted_at,
+    COALESCE(u.username, e.username, '') AS author_username,
+    COALESCE(u.avatar, e.avatar, '') AS author_avatar,
+    e.banned_at IS NOT NULL AS author_banned,
+    COALESCE(a.title, '') AS article_title,
+    COALESCE(a.slug, '') AS article_slug
+FROM comments c
+LEFT JOIN users u ON c.author_type = 'user' AND u.id = c.author_id
+LEFT JOIN external_users e ON c.author_type = 'external' AND e.id = c.author_id
+LEFT JOIN articles a ON a.id = c.article_id
+`
+
+// the comment and every reply below it, for statements taking the comment id
+const threadStatement = `
+WITH RECURSIVE thread(id) AS (
+    SELECT id FROM comments WHERE id = ?
+    UNION ALL
+    SELECT c.id FROM comments c JOIN thread t ON c.parent_id = t.id
+)
+`
+
+var orderableColumns = map[string]bool{
+	"id":         true,
+	"created_at": true,
+	"updated_at": true,
+}
+
+var commentStatuses = map[string]bool{
+	types.COMMENT_PENDING:  true,
+	types.COMMENT_APPROVED: true,
+	types.COMMENT_REJECTED: true,
+	types.COMMENT_SPAM:     true,
 }
 
 type repository struct {
@@ -28,9 +77,11 @@ func NewRepository(db *sqlx.DB) Repository {
 	return &repository{db}
 }
 
+// only approved comments are returned, since these are the ones readers can see.
+// comments in the trash aren't found by any finder but FindTrashedComments
 func (r *repository) FindCommentsByArticleId(articleId int) ([]*types.Comment, error) {
 	var comments []*types.Comment
-	err := r.db.Select(&comments, "SELECT * FROM comments WHERE article_id = ?", articleId)
+	err := r.db.Select(&comments, selectCommentsStatement+"WHERE c.article_id = ? AND c.status = ? AND c.deleted_at IS NULL ORDER BY c.created_at, c.id", articleId, types.COMMENT_APPROVED)
 	if err != nil {
 		return nil, err
 	}
@@ -39,7 +90,7 @@ func (r *repository) FindCommentsByArticleId(articleId int) ([]*types.Comment, e
 
 func (r *repository) FindCommentById(id int) (*types.Comment, error) {
 	var comment types.Comment
-	err := r.db.Get(&comment, "SELECT * FROM comments WHERE id = ?", id)
+	err := r.db.Get(&comment, selectCommentsStatement+"WHERE c.id = ? AND c.deleted_at IS NULL", id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, types.ErrCommentNotFound
@@ -58,7 +109,7 @@ func (r *repository) FindCommentsByUserId(userId int) ([]*types.Comment, error)
 	}
 
 	var comments []*types.Comment
-	err := r.db.Select(&comments, "SELECT * FROM comments WHERE author_id = ?", userId)
+	err := r.db.Select(&comments, selectCommentsStatement+"WHERE c.author_id = ? AND c.author_type = ? AND c.deleted_at IS NULL ORDER BY c.created_at, c.id", userId, types.AUTHOR_USER)
 	if err != nil {
 		return nil, err
 	}
@@ -67,56 +118,86 @@ func (r *repository) FindCommentsByUserId(userId int) ([]*types.Comment, error)
 
 func (r *repository) CreateComment(input *types.CreateCommentInput) (*types.Comment, error) {
 
-	userRepo := user.NewRepository(r.db)
+	if err := r.authorExists(input.AuthorID, input.AuthorType); err != nil {
+		return nil, err
+	}
 
-	if err := userRepo.UserExistsById(input.AuthorID); err != nil {
+	if !commentStatuses[input.Status] {
+		return nil, types.ErrInvalidCommentStatus
+	}
+
+	articleRepo := article.NewRepository(r.db)
+
+	if err := articleRepo.ArticleExists(input.ArticleID); err != nil {
 		return nil, err
 	}
 
-	var comment types.Comment
-	res := r.db.MustExec("INSERT INTO comments (author_id, article_id, content) VALUES (?, ?, ?) RETURNING *", input.AuthorID, input.ArticleID, input.Content)
+	if input.ParentID != nil {
+		parent, err := r.FindCommentById(*input.ParentID)
 
-	idCreated, err := res.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+
+		// readers can't see comments waiting for moderation, so they can't be answered either
+		if !parent.IsApproved() {
+			return nil, types.ErrCommentNotFound
+		}
+
+		if parent.ArticleID != input.ArticleID {
+			return nil, types.ErrInvalidParentComment
+		}
+	}
+
+	res, err := r.db.Exec("INSERT INTO comments (author_id, author_type, article_id, parent_id, content, status) VALUES (?, ?, ?, ?, ?, ?)", input.AuthorID, input.AuthorType, input.ArticleID, input.ParentID, input.Content, input.Status)
+
+	// the article or the parent comment was purged after being checked
+	if constraint.IsForeignKey(err) {
+		if input.ParentID != nil && r.CommentExists(*input.ParentID) != nil {
+			return nil, types.ErrCommentNotFound
+		}
+		return nil, types.ErrArticleNotFound
+	}
 
 	if err != nil {
 		return nil, err
 	}
 
-	err = r.db.Get(&comment, "SELECT * FROM comments WHERE id = ?", idCreated)
+	idCreated, err := res.LastInsertId()
 
 	if err != nil {
 		return nil, err
 	}
 
-	return &comment, nil
+	return r.FindCommentById(int(idCreated))
 }
 
 func (r *repository) UpdateComment(id int, input *types.UpdateCommentInput) (*types.Comment, error) {
-	var comment types.Comment
-	res := r.db.MustExec("UPDATE comments SET content = ? WHERE id = ? RETURNING *", input.Content, id)
-
-	_, err := res.RowsAffected()
-
-	if err != nil {
+	if err := r.CommentExists(id); err != nil {
 		return nil, err
 	}
 
-	err = r.db.Get(&comment, "SELECT * FROM comments WHERE id = ?", id)
+	if !commentStatuses[input.Status] {
+		return nil, types.ErrInvalidCommentStatus
+	}
+
+	_, err := r.db.Exec("UPDATE comments SET content = ?, status = ?, updated_at = ? WHERE id = ?", input.Content, input.Status, time.Now(), id)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return &comment, nil
+	return r.FindCommentById(id)
 }
 
+// moves the comment to the trash together with every reply below it, since a reply makes no sense without what it answers
 func (r *repository) DeleteComment(id int) error {
 
 	if err := r.CommentExists(id); err != nil {
 		return err
 	}
 
-	_, err := r.db.Exec("DELETE FROM comments WHERE id = ?", id)
+	_, err := r.db.Exec(threadStatement+"UPDATE comments SET deleted_at = ? WHERE id IN (SELECT id FROM thread) AND deleted_at IS NULL", id, time.Now().UTC())
 	if err != nil {
 		return err
 	}
@@ -128,13 +209,10 @@ func (r *repository) DeleteCommentsByArticleId(articleId int) error {
 	articleRepo := article.NewRepository(r.db)
 
 	if err := articleRepo.ArticleExists(articleId); err != nil {
-		if err == sql.ErrNoRows {
-			return types.ErrArticleNotFound
-		}
 		return err
 	}
 
-	_, err := r.db.Exec("DELETE FROM comments WHERE article_id = ?", articleId)
+	_, err := r.db.Exec("UPDATE comments SET deleted_at = ? WHERE article_id = ? AND deleted_at IS NULL", time.Now().UTC(), articleId)
 	if err != nil {
 		return err
 	}
@@ -143,14 +221,212 @@ func (r *repository) DeleteCommentsByArticleId(articleId int) error {
 
 func (r *repository) CommentExists(id int) error {
 	var count int
-	err := r.db.Get(&count, "SELECT COUNT(*) FROM comments WHERE id = ?", id)
+	err := r.db.Get(&count, "SELECT COUNT(*) FROM comments WHERE id = ? AND deleted_at IS NULL", id)
 
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return types.ErrCommentNotFound
-		}
 		return err
 	}
 
+	if count == 0 {
+		return types.ErrCommentNotFound
+	}
+
 	return nil
 }
+
+// comments of trashed articles wait in the trash with them, so they're left out of the moderation queue
+const visibleInQueue = "c.deleted_at IS NULL AND a.deleted_at IS NULL"
+
+func (r *repository) FindCommentsByStatus(status string, pagination pagination.Pagination) ([]*types.Comment, int, error) {
+	if !commentStatuses[status] {
+		return nil, 0, types.ErrInvalidCommentStatus
+	}
+
+	var totalItems int
+
+	err := r.db.Get(&totalItems, "SELECT COUNT(*) FROM comments c LEFT JOIN articles a ON a.id = c.article_id WHERE c.status = ? AND "+visibleInQueue, status)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	offset, limit, totalPages, orderBy, sortBy, err := pagination.GetValues(totalItems)
+
+	if err != nil {
+		return nil, totalPages, err
+	}
+
+	if !orderableColumns[orderBy] {
+		return nil, totalPages, types.ErrInvalidOrderBy
+	}
+
+	var comments []*types.Comment
+
+	query := fmt.Sprintf(selectCommentsStatement+"WHERE c.status = ? AND "+visibleInQueue+" ORDER BY c.%s %s, c.id %s LIMIT ? OFFSET ?", orderBy, sortBy, sortBy)
+
+	err = r.db.Select(&comments, query, status, limit, offset)
+
+	if err != nil {
+		return nil, 0, err
+	}
+	return comments, totalPages, nil
+}
+
+func (r *repository) CountApprovedCommentsByAuthor(authorId int, authorType string) (int, error) {
+	var count int
+	err := r.db.Get(&count, "SELECT COUNT(*) FROM comments WHERE author_id = ? AND author_type = ? AND status = ? AND deleted_at IS NULL", authorId, authorType, types.COMMENT_APPROVED)
+	return count, err
+}
+
+// only external users can be banned. users are the admins of the blog
+func (r *repository) IsAuthorBanned(authorId int, authorType string) (bool, error) {
+	if authorType != types.AUTHOR_EXTERNAL {
+		return false, nil
+	}
+
+	return user.NewRepository(r.db).IsExternalUserBanned(authorId)
+}
+
+// changes the status of the comments and returns how many of them exist
+func (r *repository) SetCommentsStatus(ids []int, status string) (int, error) {
+	if !commentStatuses[status] {
+		return 0, types.ErrInvalidCommentStatus
+	}
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	query, args, err := sqlx.In("UPDATE comments SET status = ? WHERE id IN (?) AND deleted_at IS NULL", status, ids)
+
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := r.db.Exec(r.db.Rebind(query), args...)
+
+	if err != nil {
+		return 0, err
+	}
+
+	updated, err := res.RowsAffected()
+
+	return int(updated), err
+}
+
+// bans the external authors of the comments and marks the given comments, and every other comment of theirs
+// still waiting for moderation, as spam. returns how many users were banned
+func (r *repository) BanCommentAuthors(ids []int) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	query, args, err := sqlx.In("SELECT DISTINCT author_id FROM comments WHERE author_type = ? AND id IN (?) AND deleted_at IS NULL", types.AUTHOR_EXTERNAL, ids)
+
+	if err != nil {
+		return 0, err
+	}
+
+	var authorIds []int
+
+	if err := r.db.Select(&authorIds, r.db.Rebind(query), args...); err != nil {
+		return 0, err
+	}
+
+	if len(authorIds) == 0 {
+		return 0, nil
+	}
+
+	banned, err := user.NewRepository(r.db).BanExternalUsers(authorIds)
+
+	if err != nil {
+		return 0, err
+	}
+
+	query, args, err = sqlx.In("UPDATE comments SET status = ? WHERE author_type = ? AND author_id IN (?) AND (status = ? OR id IN (?))",
+		types.COMMENT_SPAM, types.AUTHOR_EXTERNAL, authorIds, types.COMMENT_PENDING, ids)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := r.db.Exec(r.db.Rebind(query), args...); err != nil {
+		return 0, err
+	}
+
+	return banned, nil
+}
+
+// the comments deleted on their own, the last deleted first. replies deleted with the comment they answer
+// are restored and purged with it, so they aren't listed
+func (r *repository) FindTrashedComments() ([]*types.Comment, error) {
+	var comments []*types.Comment
+	err := r.db.Select(&comments, selectCommentsStatement+`WHERE c.deleted_at IS NOT NULL
+AND NOT EXISTS (SELECT 1 FROM comments p WHERE p.id = c.parent_id AND p.deleted_at IS NOT NULL)
+ORDER BY c.deleted_at DESC, c.id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// takes the comment out of the trash with the replies that were deleted with it. a reply whose parent is
+// still trashed stays there, it would be restored under a comment nobody can see
+func (r *repository) RestoreComment(id int) error {
+	var trashedParents int
+	err := r.db.Get(&trashedParents, `SELECT COUNT(*) FROM comments c JOIN comments p ON p.id = c.parent_id
+WHERE c.id = ? AND c.deleted_at IS NOT NULL AND p.deleted_at IS NOT NULL`, id)
+
+	if err != nil {
+		return err
+	}
+
+	if trashedParents > 0 {
+		return types.ErrParentCommentTrashed
+	}
+
+	res, err := r.db.Exec(threadStatement+"UPDATE comments SET deleted_at = NULL WHERE id IN (SELECT id FROM thread) AND deleted_at = (SELECT deleted_at FROM comments WHERE id = ?)", id, id)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return types.ErrCommentNotFound
+	}
+
+	return nil
+}
+
+// deletes a trashed comment for good, its replies going with it. reports whether it was still in the trash,
+// so a comment restored meanwhile is kept
+func (r *repository) PurgeComment(id int) (bool, error) {
+	res, err := r.db.Exec("DELETE FROM comments WHERE id = ? AND deleted_at IS NOT NULL", id)
+
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return n > 0, err
+}
+
+func (r *repository) authorExists(id int, authorType string) error {
+	userRepo := user.NewRepository(r.db)
+
+	switch authorType {
+	case types.AUTHOR_USER:
+		return userRepo.UserExistsById(id)
+	case types.AUTHOR_EXTERNAL:
+		return userRepo.ExternalUserExistsById(id)
+	default:
+		return types.ErrInvalidAuthorType
+	}
+}
@@ -1,25 +1,33 @@
 package comment
 
 import (
+	"github.com/samluiz/blog/common/pagination"
 	"github.com/samluiz/blog/pkg/types"
 )
 
 type Service interface {
 	FindCommentsByArticleId(articleId int) ([]*types.Comment, error)
+	FindCommentThreadsByArticleId(articleId int) ([]*types.CommentThread, error)
 	FindCommentById(id int) (*types.Comment, error)
 	FindCommentsByUserId(userId int) ([]*types.Comment, error)
 	CreateComment(input *types.CreateCommentInput) (*types.Comment, error)
-	UpdateComment(id int, input *types.UpdateCommentInput) (*types.Comment, error)
-	DeleteComment(id int) error
+	UpdateComment(id int, input *types.UpdateCommentInput, actor types.CommentActor) (*types.Comment, error)
+	DeleteComment(id int, actor types.CommentActor) error
 	DeleteCommentsByArticleId(articleId int) error
+	FindCommentsByStatus(status string, pagination pagination.Pagination) ([]*types.Comment, int, error)
+	ModerateComments(ids []int, action string) (int, error)
+	FindTrashedComments() ([]*types.Comment, error)
+	RestoreComment(id int) error
+	PurgeComment(id int) (bool, error)
 }
 
 type service struct {
-	repo Repository
+	repo  Repository
+	rules ModerationRules
 }
 
-func NewService(repo Repository) Service {
-	return &service{repo}
+func NewService(repo Repository, rules ModerationRules) Service {
+	return &service{repo, rules}
 }
 
 func (s *service) FindCommentById(id int) (*types.Comment, error) {
@@ -30,22 +38,138 @@ func (s *service) FindCommentsByArticleId(articleId int) ([]*types.Comment, erro
 	return s.repo.FindCommentsByArticleId(articleId)
 }
 
+func (s *service) FindCommentThreadsByArticleId(articleId int) ([]*types.CommentThread, error) {
+	comments, err := s.repo.FindCommentsByArticleId(articleId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return BuildThreads(comments), nil
+}
+
 func (s *service) FindCommentsByUserId(userId int) ([]*types.Comment, error) {
 	return s.repo.FindCommentsByUserId(userId)
 }
 
+// admins' comments are always approved. the ones of readers go through the moderation rules and banned readers can't comment
 func (s *service) CreateComment(input *types.CreateCommentInput) (*types.Comment, error) {
+	input.Status = types.COMMENT_APPROVED
+
+	if input.AuthorType != types.AUTHOR_USER {
+		status, err := s.moderate(input.AuthorID, input.AuthorType, input.Content)
+
+		if err != nil {
+			return nil, err
+		}
+
+		input.Status = status
+	}
+
 	return s.repo.CreateComment(input)
 }
 
-func (s *service) UpdateComment(id int, input *types.UpdateCommentInput) (*types.Comment, error) {
+// only the author of the comment or an admin can edit it
+func (s *service) UpdateComment(id int, input *types.UpdateCommentInput, actor types.CommentActor) (*types.Comment, error) {
+	comment, err := s.authorize(id, actor)
+
+	if err != nil {
+		return nil, err
+	}
+
+	input.Status = comment.Status
+
+	// edits can't be used to sneak spam into a comment that was already approved
+	if comment.AuthorType != types.AUTHOR_USER {
+		status, err := s.moderate(comment.AuthorID, comment.AuthorType, input.Content)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if status == types.COMMENT_SPAM || !comment.IsApproved() {
+			input.Status = status
+		}
+	}
+
 	return s.repo.UpdateComment(id, input)
 }
 
-func (s *service) DeleteComment(id int) error {
+// only the author of the comment or an admin can delete it
+func (s *service) DeleteComment(id int, actor types.CommentActor) error {
+	if _, err := s.authorize(id, actor); err != nil {
+		return err
+	}
+
 	return s.repo.DeleteComment(id)
 }
 
 func (s *service) DeleteCommentsByArticleId(articleId int) error {
 	return s.repo.DeleteCommentsByArticleId(articleId)
 }
+
+func (s *service) FindCommentsByStatus(status string, pagination pagination.Pagination) ([]*types.Comment, int, error) {
+	return s.repo.FindCommentsByStatus(status, pagination)
+}
+
+// applies a moderation action to the comments and returns how many comments, or users when banning, were affected
+func (s *service) ModerateComments(ids []int, action string) (int, error) {
+	switch action {
+	case types.MODERATE_APPROVE:
+		return s.repo.SetCommentsStatus(ids, types.COMMENT_APPROVED)
+	case types.MODERATE_REJECT:
+		return s.repo.SetCommentsStatus(ids, types.COMMENT_REJECTED)
+	case types.MODERATE_SPAM:
+		return s.repo.SetCommentsStatus(ids, types.COMMENT_SPAM)
+	case types.MODERATE_BAN:
+		return s.repo.BanCommentAuthors(ids)
+	default:
+		return 0, types.ErrInvalidModeration
+	}
+}
+
+func (s *service) FindTrashedComments() ([]*types.Comment, error) {
+	return s.repo.FindTrashedComments()
+}
+
+func (s *service) RestoreComment(id int) error {
+	return s.repo.RestoreComment(id)
+}
+
+func (s *service) PurgeComment(id int) (bool, error) {
+	return s.repo.PurgeComment(id)
+}
+
+func (s *service) authorize(id int, actor types.CommentActor) (*types.Comment, error) {
+	comment, err := s.repo.FindCommentById(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !comment.CanBeChangedBy(actor) {
+		return nil, types.ErrUserUnauthorized
+	}
+
+	return comment, nil
+}
+
+func (s *service) moderate(authorId int, authorType string, content string) (string, error) {
+	banned, err := s.repo.IsAuthorBanned(authorId, authorType)
+
+	if err != nil {
+		return "", err
+	}
+
+	if banned {
+		return "", types.ErrUserBanned
+	}
+
+	approved, err := s.repo.CountApprovedCommentsByAuthor(authorId, authorType)
+
+	if err != nil {
+		return "", err
+	}
+
+	return s.rules.Status(content, approved), nil
+}
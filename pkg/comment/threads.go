@@ -0,0 +1,30 @@
+package comment
+
+import "github.com/samluiz/blog/pkg/types"
+
+// nests the replies under the comment they answer, keeping the order of the given comments.
+// replies whose parent isn't in the list, like a rejected or trashed comment, are hidden with it
+func BuildThreads(comments []*types.Comment) []*types.CommentThread {
+	threads := make(map[int]*types.CommentThread, len(comments))
+
+	for _, c := range comments {
+		threads[c.ID] = &types.CommentThread{Comment: c, Replies: []*types.CommentThread{}}
+	}
+
+	roots := []*types.CommentThread{}
+
+	for _, c := range comments {
+		thread := threads[c.ID]
+
+		if c.ParentID == nil {
+			roots = append(roots, thread)
+			continue
+		}
+
+		if parent, ok := threads[*c.ParentID]; ok && parent != thread {
+			parent.Replies = append(parent.Replies, thread)
+		}
+	}
+
+	return roots
+}
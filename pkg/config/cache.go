@@ -0,0 +1,20 @@
+package config
+
+import "time"
+
+type CacheConfig struct {
+	TTL             time.Duration
+	RefreshInterval time.Duration
+	IdleTimeout     time.Duration
+	// how long not found answers are remembered
+	MissTTL time.Duration
+}
+
+func LoadCacheConfig() CacheConfig {
+	return CacheConfig{
+		TTL:             getEnvDuration("CACHE_TTL", 5*time.Minute),
+		RefreshInterval: getEnvDuration("CACHE_REFRESH_INTERVAL", time.Minute),
+		IdleTimeout:     getEnvDuration("CACHE_IDLE_TIMEOUT", time.Hour),
+		MissTTL:         getEnvDuration("CACHE_MISS_TTL", 30*time.Second),
+	}
+}
@@ -0,0 +1,16 @@
+package config
+
+type ModerationConfig struct {
+	TrustedAfter int
+	MaxLinks     int
+	Blocklist    []string
+}
+
+// reads the comment moderation rules from the environment. COMMENTS_BLOCKLIST is a comma separated list of words
+func LoadModerationConfig() ModerationConfig {
+	return ModerationConfig{
+		TrustedAfter: getEnvInt("COMMENTS_TRUSTED_AFTER", 1),
+		MaxLinks:     getEnvInt("COMMENTS_MAX_LINKS", 2),
+		Blocklist:    getEnvList("COMMENTS_BLOCKLIST"),
+	}
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+type ContentConfig struct {
+	DevToEnabled bool
+	LocalEnabled bool
+}
+
+// reads which content sources are enabled from the environment. all of them are enabled by default
+func LoadContentConfig() ContentConfig {
+	return ContentConfig{
+		DevToEnabled: getEnvBool("CONTENT_SOURCE_DEVTO", true),
+		LocalEnabled: getEnvBool("CONTENT_SOURCE_LOCAL", true),
+	}
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+
+	if err != nil {
+		return fallback
+	}
+
+	return value
+}
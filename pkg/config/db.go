@@ -1,84 +1,212 @@
 package config
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/samluiz/blog/common/logger"
+	"github.com/samluiz/blog/pkg/migrations"
 	_ "github.com/tursodatabase/libsql-client-go/libsql"
 	"golang.org/x/crypto/bcrypt"
 	_ "modernc.org/sqlite"
 )
 
-var createTableStatement = `
-CREATE TABLE IF NOT EXISTS users (
-    id INTEGER PRIMARY KEY AUTOINCREMENT,
-	name TEXT NOT NULL,
-    username TEXT NOT NULL,
-    password TEXT NOT NULL,
-    is_admin BOOLEAN DEFAULT 0,
-    avatar TEXT DEFAULT '',
-    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-);
-
-CREATE TABLE IF NOT EXISTS external_users (
-	id INTEGER PRIMARY KEY AUTOINCREMENT,
-	provider_id INTEGER NOT NULL,
-	name TEXT NOT NULL,
-	username TEXT NOT NULL,
-	avatar TEXT DEFAULT '',
-	provider TEXT NOT NULL,
-	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-);
-
-CREATE TABLE IF NOT EXISTS articles (
-    id INTEGER PRIMARY KEY AUTOINCREMENT,
-    title TEXT NOT NULL,
-    slug TEXT NOT NULL UNIQUE,
-    content TEXT DEFAULT '',
-    tags TEXT DEFAULT '',
-    author_id INTEGER NOT NULL,
-    visibility TEXT DEFAULT 'PRIVATE',
-    is_published BOOLEAN DEFAULT FALSE,
-    published_at TEXT DEFAULT NULL,
-    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-);
-
-CREATE TABLE IF NOT EXISTS comments (
-    id INTEGER PRIMARY KEY AUTOINCREMENT,
-    content TEXT DEFAULT '',
-    article_id INTEGER NOT NULL,
-    author_id INTEGER NOT NULL,
-    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-);
-`
+var LOGGER = logger.New(os.Stdout, logger.DebugLevel, "[CONFIG]")
 
-func NewConnection() (*sqlx.DB, error) {
-	url := os.Getenv("DATABASE_URL")
-	authToken := os.Getenv("TURSO_AUTH_TOKEN")
+const (
+	DRIVER_LIBSQL = "libsql"
+	DRIVER_SQLITE = "sqlite"
+	DRIVER_MEMORY = "memory"
+)
+
+type DatabaseConfig struct {
+	Driver          string
+	URL             string
+	AuthToken       string
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnectRetries  int
+	RetryBackoff    time.Duration
+}
+
+var ErrUnknownDriver = errors.New("unknown database driver. valid values are: libsql, sqlite, memory")
 
-	tursoUrl := fmt.Sprintf(url+"?auth_token=%s", authToken)
+// reads the database config from the environment, falling back to the libsql (turso) driver
+func LoadDatabaseConfig() DatabaseConfig {
+	driver := os.Getenv("DATABASE_DRIVER")
 
-	db, err := sqlx.Connect("libsql", tursoUrl)
+	if driver == "" {
+		driver = DRIVER_LIBSQL
+	}
+
+	return DatabaseConfig{
+		Driver:          driver,
+		URL:             os.Getenv("DATABASE_URL"),
+		AuthToken:       os.Getenv("TURSO_AUTH_TOKEN"),
+		MaxOpenConns:    getEnvInt("DATABASE_MAX_OPEN_CONNS", 10),
+		MaxIdleConns:    getEnvInt("DATABASE_MAX_IDLE_CONNS", 5),
+		ConnMaxLifetime: getEnvDuration("DATABASE_CONN_MAX_LIFETIME", 30*time.Minute),
+		ConnectRetries:  getEnvInt("DATABASE_CONNECT_RETRIES", 5),
+		RetryBackoff:    getEnvDuration("DATABASE_RETRY_BACKOFF", 500*time.Millisecond),
+	}
+}
+
+func NewConnection() (*sqlx.DB, error) {
+	return Open(LoadDatabaseConfig())
+}
+
+// opens the database for the configured driver and pings it, retrying with exponential backoff
+func Open(cfg DatabaseConfig) (*sqlx.DB, error) {
+	driverName, dsn, err := cfg.dataSource()
 
 	if err != nil {
 		return nil, err
 	}
 
-	err = initTables(db)
+	connector, err := newForeignKeysConnector(driverName, dsn)
 
 	if err != nil {
 		return nil, err
 	}
 
+	db := sqlx.NewDb(sql.OpenDB(connector), driverName)
+
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
+	if cfg.Driver == DRIVER_MEMORY {
+		// every connection to :memory: is a brand new database, so the pool must hold a single one forever
+		db.SetMaxOpenConns(1)
+		db.SetMaxIdleConns(1)
+		db.SetConnMaxLifetime(0)
+	}
+
+	backoff := cfg.RetryBackoff
+
+	for attempt := 0; ; attempt++ {
+		err = db.Ping()
+
+		if err == nil {
+			break
+		}
+
+		if attempt >= cfg.ConnectRetries {
+			db.Close()
+			return nil, fmt.Errorf("error connecting to the database after %d attempts: %w", attempt+1, err)
+		}
+
+		log.Default().Printf("Error pinging the database: %v. Retrying in %v...", err, backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+
+	log.Default().Printf("Connected to the database using the %s driver", cfg.Driver)
+
 	return db, nil
 }
 
+func (cfg DatabaseConfig) dataSource() (string, string, error) {
+	switch cfg.Driver {
+	case DRIVER_LIBSQL:
+		return "libsql", fmt.Sprintf(cfg.URL+"?auth_token=%s", cfg.AuthToken), nil
+	case DRIVER_SQLITE:
+		path := cfg.URL
+
+		if path == "" {
+			path = "blog.db"
+		}
+
+		// waits for the write lock instead of failing right away, e.g. when two instances migrate the same file at boot
+		separator := "?"
+
+		if strings.Contains(path, "?") {
+			separator = "&"
+		}
+
+		return "sqlite", path + separator + "_pragma=busy_timeout(5000)", nil
+	case DRIVER_MEMORY:
+		return "sqlite", ":memory:", nil
+	default:
+		return "", "", ErrUnknownDriver
+	}
+}
+
+// sqlite only enforces foreign keys on connections that ask for it, so every connection of the pool does it when it's opened
+type foreignKeysConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func newForeignKeysConnector(driverName, dsn string) (*foreignKeysConnector, error) {
+	db, err := sql.Open(driverName, dsn)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// only used to find the driver, it never opens a connection
+	defer db.Close()
+
+	return &foreignKeysConnector{dsn: dsn, driver: db.Driver()}, nil
+}
+
+func (c *foreignKeysConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.driver.Open(c.dsn)
+
+	if err != nil {
+		return nil, err
+	}
+
+	execer, ok := conn.(driver.ExecerContext)
+
+	if !ok {
+		conn.Close()
+		return nil, errors.New("database driver can't enable foreign keys on its connections")
+	}
+
+	if _, err := execer.ExecContext(ctx, "PRAGMA foreign_keys = ON", nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error enabling foreign keys: %w", err)
+	}
+
+	return conn, nil
+}
+
+func (c *foreignKeysConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+func getEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+
+	if err != nil {
+		return fallback
+	}
+
+	return value
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+
+	if err != nil {
+		return fallback
+	}
+
+	return value
+}
+
 func initUser(db *sqlx.DB) error {
 	log.Default().Println("Initializing admin user...")
 	name := os.Getenv("ADMIN_NAME")
@@ -115,12 +243,13 @@ func initUser(db *sqlx.DB) error {
 	return nil
 }
 
-func initTables(db *sqlx.DB) error {
-	log.Default().Println("Initializing tables...")
+// applies pending schema migrations and makes sure the admin user exists
+func InitDatabase(db *sqlx.DB) error {
+	log.Default().Println("Applying migrations...")
 
-	_, err := db.Exec(createTableStatement)
+	err := migrations.Up(db)
 	if err != nil {
-		log.Default().Printf("Error creating tables: %v", err)
+		log.Default().Printf("Error applying migrations: %v", err)
 		return err
 	}
 
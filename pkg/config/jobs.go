@@ -0,0 +1,49 @@
+package config
+
+import "time"
+
+type DevToSyncConfig struct {
+	Enabled  bool
+	Interval time.Duration
+}
+
+func LoadDevToSyncConfig() DevToSyncConfig {
+	return DevToSyncConfig{
+		Enabled:  getEnvBool("DEVTO_SYNC_ENABLED", false),
+		Interval: getEnvDuration("DEVTO_SYNC_INTERVAL", time.Hour),
+	}
+}
+
+type CrossPostConfig struct {
+	RetryInterval time.Duration
+	MaxAttempts   int
+}
+
+func LoadCrossPostConfig() CrossPostConfig {
+	return CrossPostConfig{
+		RetryInterval: getEnvDuration("CROSSPOST_RETRY_INTERVAL", 10*time.Minute),
+		MaxAttempts:   getEnvInt("CROSSPOST_MAX_ATTEMPTS", 5),
+	}
+}
+
+type PublisherConfig struct {
+	Interval time.Duration
+}
+
+func LoadPublisherConfig() PublisherConfig {
+	return PublisherConfig{
+		Interval: getEnvDuration("PUBLISHER_INTERVAL", time.Minute),
+	}
+}
+
+type TrashConfig struct {
+	Retention     time.Duration
+	PurgeInterval time.Duration
+}
+
+func LoadTrashConfig() TrashConfig {
+	return TrashConfig{
+		Retention:     getEnvDuration("TRASH_RETENTION", 30*24*time.Hour),
+		PurgeInterval: getEnvDuration("TRASH_PURGE_INTERVAL", time.Hour),
+	}
+}
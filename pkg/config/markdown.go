@@ -0,0 +1,18 @@
+package config
+
+import "os"
+
+type HighlightConfig struct {
+	Enabled     bool
+	Theme       string
+	LineNumbers bool
+}
+
+// HIGHLIGHT_THEME is one of the themes of parsers.HighlightCSS, the default one is used when it's empty or unknown
+func LoadHighlightConfig() HighlightConfig {
+	return HighlightConfig{
+		Enabled:     getEnvBool("HIGHLIGHT_ENABLED", true),
+		Theme:       os.Getenv("HIGHLIGHT_THEME"),
+		LineNumbers: getEnvBool("HIGHLIGHT_LINE_NUMBERS", true),
+	}
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"crypto/rand"
+	"log"
+	"os"
+	"time"
+)
+
+type PreviewConfig struct {
+	Secret []byte
+	TTL    time.Duration
+}
+
+// PREVIEW_SECRET signs the preview links. without it a random one is used, and the links stop working on restart
+func LoadPreviewConfig() PreviewConfig {
+	secret := []byte(os.Getenv("PREVIEW_SECRET"))
+
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+
+		if _, err := rand.Read(secret); err != nil {
+			log.Fatalf("error generating the preview secret: %v", err)
+		}
+
+		LOGGER.Warning("PREVIEW_SECRET is not set, using a random secret. preview links will stop working when the server restarts")
+	}
+
+	return PreviewConfig{
+		Secret: secret,
+		TTL:    getEnvDuration("PREVIEW_TTL", 7*24*time.Hour),
+	}
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+const DEFAULT_SITE_URL = "https://samluiz.com"
+
+// public url of the blog without the trailing slash, used to build absolute links
+func SiteURL() string {
+	url := os.Getenv("SITE_URL")
+
+	if url == "" {
+		return DEFAULT_SITE_URL
+	}
+
+	return strings.TrimSuffix(url, "/")
+}
+
+// paths crawlers must never visit, whatever ROBOTS_DISALLOW says
+var privatePaths = []string{"/dashboard", "/internal", "/auth"}
+
+type RobotsConfig struct {
+	Disallow []string
+	BlockAll bool
+}
+
+// ROBOTS_DISALLOW is a comma separated list of paths to hide besides the private ones.
+// ROBOTS_BLOCK_ALL hides the whole site, e.g. on staging
+func LoadRobotsConfig() RobotsConfig {
+	return RobotsConfig{
+		Disallow: append(append([]string{}, privatePaths...), getEnvList("ROBOTS_DISALLOW")...),
+		BlockAll: getEnvBool("ROBOTS_BLOCK_ALL", false),
+	}
+}
+
+// splits a comma separated variable, skipping empty values
+func getEnvList(key string) []string {
+	var values []string
+
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
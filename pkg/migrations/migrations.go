@@ -0,0 +1,746 @@
+package migrations
+
+// list of every schema migration, in the order they must be applied.
+// new migrations must always be appended with the next version number, never edited after being released.
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_initial_tables",
+		Up: `
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    username TEXT NOT NULL,
+    password TEXT NOT NULL,
+    is_admin BOOLEAN DEFAULT 0,
+    avatar TEXT DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS external_users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    provider_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    username TEXT NOT NULL,
+    avatar TEXT DEFAULT '',
+    provider TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS articles (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    slug TEXT NOT NULL UNIQUE,
+    content TEXT DEFAULT '',
+    tags TEXT DEFAULT '',
+    author_id INTEGER NOT NULL,
+    visibility TEXT DEFAULT 'PRIVATE',
+    is_published BOOLEAN DEFAULT FALSE,
+    published_at TEXT DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS comments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    content TEXT DEFAULT '',
+    article_id INTEGER NOT NULL,
+    author_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+`,
+		Down: `
+DROP TABLE IF EXISTS comments;
+DROP TABLE IF EXISTS articles;
+DROP TABLE IF EXISTS external_users;
+DROP TABLE IF EXISTS users;
+`,
+	},
+	{
+		Version: 2,
+		Name:    "add_articles_slug_id",
+		Up: `
+ALTER TABLE articles ADD COLUMN slug_id TEXT NOT NULL DEFAULT '';
+`,
+		Down: `
+ALTER TABLE articles DROP COLUMN slug_id;
+`,
+	},
+	{
+		// published_at was declared as TEXT, which the drivers hand back as a string instead of a time
+		Version: 3,
+		Name:    "change_articles_published_at_to_datetime",
+		Up: `
+CREATE TABLE articles_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    slug TEXT NOT NULL UNIQUE,
+    slug_id TEXT NOT NULL DEFAULT '',
+    content TEXT DEFAULT '',
+    tags TEXT DEFAULT '',
+    author_id INTEGER NOT NULL,
+    visibility TEXT DEFAULT 'PRIVATE',
+    is_published BOOLEAN DEFAULT FALSE,
+    published_at DATETIME DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO articles_new (id, title, slug, slug_id, content, tags, author_id, visibility, is_published, published_at, created_at, updated_at)
+SELECT id, title, slug, slug_id, content, tags, author_id, visibility, is_published, published_at, created_at, updated_at FROM articles;
+
+DROP TABLE articles;
+
+ALTER TABLE articles_new RENAME TO articles;
+`,
+		Down: `
+CREATE TABLE articles_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    slug TEXT NOT NULL UNIQUE,
+    slug_id TEXT NOT NULL DEFAULT '',
+    content TEXT DEFAULT '',
+    tags TEXT DEFAULT '',
+    author_id INTEGER NOT NULL,
+    visibility TEXT DEFAULT 'PRIVATE',
+    is_published BOOLEAN DEFAULT FALSE,
+    published_at TEXT DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO articles_old (id, title, slug, slug_id, content, tags, author_id, visibility, is_published, published_at, created_at, updated_at)
+SELECT id, title, slug, slug_id, content, tags, author_id, visibility, is_published, published_at, created_at, updated_at FROM articles;
+
+DROP TABLE articles;
+
+ALTER TABLE articles_old RENAME TO articles;
+`,
+	},
+	{
+		Version: 4,
+		Name:    "add_articles_import_columns",
+		Up: `
+ALTER TABLE articles ADD COLUMN description TEXT NOT NULL DEFAULT '';
+ALTER TABLE articles ADD COLUMN source TEXT NOT NULL DEFAULT 'local';
+ALTER TABLE articles ADD COLUMN devto_id INTEGER DEFAULT NULL;
+ALTER TABLE articles ADD COLUMN deleted_at DATETIME DEFAULT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_articles_devto_id ON articles (devto_id);
+`,
+		Down: `
+DROP INDEX IF EXISTS idx_articles_devto_id;
+
+ALTER TABLE articles DROP COLUMN deleted_at;
+ALTER TABLE articles DROP COLUMN devto_id;
+ALTER TABLE articles DROP COLUMN source;
+ALTER TABLE articles DROP COLUMN description;
+`,
+	},
+	{
+		Version: 5,
+		Name:    "add_articles_crosspost_columns",
+		Up: `
+ALTER TABLE articles ADD COLUMN devto_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE articles ADD COLUMN crosspost_status TEXT NOT NULL DEFAULT '';
+ALTER TABLE articles ADD COLUMN crosspost_error TEXT NOT NULL DEFAULT '';
+ALTER TABLE articles ADD COLUMN crosspost_attempts INTEGER NOT NULL DEFAULT 0;
+`,
+		Down: `
+ALTER TABLE articles DROP COLUMN crosspost_attempts;
+ALTER TABLE articles DROP COLUMN crosspost_error;
+ALTER TABLE articles DROP COLUMN crosspost_status;
+ALTER TABLE articles DROP COLUMN devto_url;
+`,
+	},
+	{
+		// replies point to their parent comment. comments can be written by admins (users) or by readers logged in with github (external_users)
+		Version: 6,
+		Name:    "add_comments_threading",
+		Up: `
+ALTER TABLE comments ADD COLUMN parent_id INTEGER DEFAULT NULL;
+ALTER TABLE comments ADD COLUMN author_type TEXT NOT NULL DEFAULT 'user';
+
+CREATE INDEX IF NOT EXISTS idx_comments_article_id ON comments (article_id);
+CREATE INDEX IF NOT EXISTS idx_comments_parent_id ON comments (parent_id);
+`,
+		Down: `
+DROP INDEX IF EXISTS idx_comments_parent_id;
+DROP INDEX IF EXISTS idx_comments_article_id;
+
+ALTER TABLE comments DROP COLUMN author_type;
+ALTER TABLE comments DROP COLUMN parent_id;
+`,
+	},
+	{
+		// existing comments were already public, so they start approved. new comments get their status from the moderation rules
+		Version: 7,
+		Name:    "add_comments_moderation",
+		Up: `
+ALTER TABLE comments ADD COLUMN status TEXT NOT NULL DEFAULT 'approved';
+ALTER TABLE external_users ADD COLUMN banned_at DATETIME DEFAULT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_comments_status ON comments (status);
+`,
+		Down: `
+DROP INDEX IF EXISTS idx_comments_status;
+
+ALTER TABLE external_users DROP COLUMN banned_at;
+ALTER TABLE comments DROP COLUMN status;
+`,
+	},
+	{
+		// the comma separated tags column becomes a tags table and an article_tags relation, so articles can be found by tag
+		// through an index. the existing tags are split, trimmed and lowercased, keeping their order
+		Version: 8,
+		Name:    "normalize_articles_tags",
+		Up: `
+CREATE TABLE IF NOT EXISTS tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS article_tags (
+    article_id INTEGER NOT NULL,
+    tag_id INTEGER NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (article_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_article_tags_tag_id ON article_tags (tag_id);
+
+WITH RECURSIVE split(article_id, tag, rest, position) AS (
+    SELECT id, '', tags || ',', -1 FROM articles WHERE tags IS NOT NULL AND tags != ''
+    UNION ALL
+    SELECT article_id, lower(trim(substr(rest, 1, instr(rest, ',') - 1))), substr(rest, instr(rest, ',') + 1), position + 1
+    FROM split WHERE rest != ''
+)
+INSERT OR IGNORE INTO tags (name) SELECT DISTINCT tag FROM split WHERE tag != '';
+
+WITH RECURSIVE split(article_id, tag, rest, position) AS (
+    SELECT id, '', tags || ',', -1 FROM articles WHERE tags IS NOT NULL AND tags != ''
+    UNION ALL
+    SELECT article_id, lower(trim(substr(rest, 1, instr(rest, ',') - 1))), substr(rest, instr(rest, ',') + 1), position + 1
+    FROM split WHERE rest != ''
+)
+INSERT OR IGNORE INTO article_tags (article_id, tag_id, position)
+SELECT split.article_id, tags.id, split.position FROM split JOIN tags ON tags.name = split.tag WHERE split.tag != '';
+
+ALTER TABLE articles DROP COLUMN tags;
+`,
+		Down: `
+ALTER TABLE articles ADD COLUMN tags TEXT DEFAULT '';
+
+UPDATE articles SET tags = COALESCE((
+    SELECT group_concat(name, ',') FROM (
+        SELECT t.name FROM article_tags at JOIN tags t ON t.id = at.tag_id WHERE at.article_id = articles.id ORDER BY at.position
+    )
+), '');
+
+DROP INDEX IF EXISTS idx_article_tags_tag_id;
+DROP TABLE IF EXISTS article_tags;
+DROP TABLE IF EXISTS tags;
+`,
+	},
+	{
+		// full text index of the articles, kept in sync by triggers. the rowid of each row is the id of its article.
+		// tags live in their own table, so their triggers refresh the tags column of the article they belong to
+		Version: 9,
+		Name:    "add_articles_search",
+		Up: `
+CREATE VIRTUAL TABLE IF NOT EXISTS articles_fts USING fts5 (
+    title,
+    description,
+    tags,
+    content,
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+INSERT INTO articles_fts (rowid, title, description, tags, content)
+SELECT articles.id, articles.title, COALESCE(articles.description, ''), (
+        SELECT COALESCE(group_concat(name, ' '), '') FROM (
+            SELECT tags.name FROM article_tags JOIN tags ON tags.id = article_tags.tag_id WHERE article_tags.article_id = articles.id ORDER BY article_tags.position
+        )
+    ), COALESCE(articles.content, '')
+FROM articles;
+
+CREATE TRIGGER IF NOT EXISTS articles_fts_insert AFTER INSERT ON articles BEGIN
+    INSERT INTO articles_fts (rowid, title, description, tags, content)
+    VALUES (new.id, new.title, COALESCE(new.description, ''), (
+        SELECT COALESCE(group_concat(name, ' '), '') FROM (
+            SELECT tags.name FROM article_tags JOIN tags ON tags.id = article_tags.tag_id WHERE article_tags.article_id = new.id ORDER BY article_tags.position
+        )
+    ), COALESCE(new.content, ''));
+END;
+
+CREATE TRIGGER IF NOT EXISTS articles_fts_update AFTER UPDATE OF title, description, content ON articles BEGIN
+    UPDATE articles_fts SET title = new.title, description = COALESCE(new.description, ''), content = COALESCE(new.content, '')
+    WHERE rowid = new.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS articles_fts_delete AFTER DELETE ON articles BEGIN
+    DELETE FROM articles_fts WHERE rowid = old.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS article_tags_fts_insert AFTER INSERT ON article_tags BEGIN
+    UPDATE articles_fts SET tags = (
+        SELECT COALESCE(group_concat(name, ' '), '') FROM (
+            SELECT tags.name FROM article_tags JOIN tags ON tags.id = article_tags.tag_id WHERE article_tags.article_id = new.article_id ORDER BY article_tags.position
+        )
+    ) WHERE rowid = new.article_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS article_tags_fts_delete AFTER DELETE ON article_tags BEGIN
+    UPDATE articles_fts SET tags = (
+        SELECT COALESCE(group_concat(name, ' '), '') FROM (
+            SELECT tags.name FROM article_tags JOIN tags ON tags.id = article_tags.tag_id WHERE article_tags.article_id = old.article_id ORDER BY article_tags.position
+        )
+    ) WHERE rowid = old.article_id;
+END;
+`,
+		Down: `
+DROP TRIGGER IF EXISTS article_tags_fts_delete;
+DROP TRIGGER IF EXISTS article_tags_fts_insert;
+DROP TRIGGER IF EXISTS articles_fts_delete;
+DROP TRIGGER IF EXISTS articles_fts_update;
+DROP TRIGGER IF EXISTS articles_fts_insert;
+DROP TABLE IF EXISTS articles_fts;
+`,
+	},
+	{
+		// links sharing a draft with reviewers. the token is signed with the id and the expiry, so only these are stored
+		// and a link stops working once revoked or expired
+		Version: 10,
+		Name:    "add_article_previews",
+		Up: `
+CREATE TABLE IF NOT EXISTS article_previews (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    article_id INTEGER NOT NULL,
+    expires_at DATETIME NOT NULL,
+    revoked_at DATETIME DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_article_previews_article_id ON article_previews (article_id);
+`,
+		Down: `
+DROP INDEX IF EXISTS idx_article_previews_article_id;
+DROP TABLE IF EXISTS article_previews;
+`,
+	},
+	{
+		// drafts can be scheduled to be published by the publisher job. it's cleared once they're published
+		Version: 11,
+		Name:    "add_articles_publish_at",
+		Up: `
+ALTER TABLE articles ADD COLUMN publish_at DATETIME DEFAULT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_articles_publish_at ON articles (publish_at);
+`,
+		Down: `
+DROP INDEX IF EXISTS idx_articles_publish_at;
+
+ALTER TABLE articles DROP COLUMN publish_at;
+`,
+	},
+	{
+		// every save of an article keeps its state, so edits can be compared and undone.
+		// the articles written until now start with their current state
+		Version: 12,
+		Name:    "add_article_revisions",
+		Up: `
+CREATE TABLE IF NOT EXISTS article_revisions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    article_id INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    content TEXT NOT NULL DEFAULT '',
+    tags TEXT NOT NULL DEFAULT '',
+    author_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_article_revisions_article_id ON article_revisions (article_id);
+
+INSERT INTO article_revisions (article_id, title, content, tags, author_id, created_at)
+SELECT articles.id, articles.title, COALESCE(articles.content, ''), COALESCE((
+    SELECT group_concat(name, ',') FROM (
+        SELECT tags.name FROM article_tags JOIN tags ON tags.id = article_tags.tag_id WHERE article_tags.article_id = articles.id ORDER BY article_tags.position
+    )
+), ''), articles.author_id, COALESCE(articles.updated_at, articles.created_at)
+FROM articles WHERE articles.source = 'local';
+`,
+		Down: `
+DROP INDEX IF EXISTS idx_article_revisions_article_id;
+DROP TABLE IF EXISTS article_revisions;
+`,
+	},
+	{
+		// deleted comments go to the trash like articles, and both are purged once they're older than the retention
+		Version: 13,
+		Name:    "add_comments_deleted_at",
+		Up: `
+ALTER TABLE comments ADD COLUMN deleted_at DATETIME DEFAULT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_comments_deleted_at ON comments (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_articles_deleted_at ON articles (deleted_at);
+`,
+		Down: `
+DROP INDEX IF EXISTS idx_articles_deleted_at;
+DROP INDEX IF EXISTS idx_comments_deleted_at;
+
+ALTER TABLE comments DROP COLUMN deleted_at;
+`,
+	},
+	{
+		// the relations between the tables become foreign keys, enforced on every connection. what belongs to an article
+		// or a comment is deleted with it, and rows left behind by deletes made before are dropped with the rebuild.
+		// articles and revisions whose author was deleted are handed to the first admin, or the first user when there's
+		// no admin. with no users at all the foreign key check fails and nothing is applied.
+		// comment authors can be users or external users, so their author_id stays without a key.
+		// the search triggers read the tables being rebuilt, so they're created again once they all are
+		Version: 14,
+		Name:    "add_foreign_keys",
+		Up: `
+DROP TRIGGER IF EXISTS article_tags_fts_delete;
+DROP TRIGGER IF EXISTS article_tags_fts_insert;
+DROP TRIGGER IF EXISTS articles_fts_delete;
+DROP TRIGGER IF EXISTS articles_fts_update;
+DROP TRIGGER IF EXISTS articles_fts_insert;
+
+CREATE TABLE articles_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    slug TEXT NOT NULL UNIQUE,
+    slug_id TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    content TEXT DEFAULT '',
+    author_id INTEGER NOT NULL REFERENCES users (id),
+    visibility TEXT DEFAULT 'PRIVATE',
+    is_published BOOLEAN DEFAULT FALSE,
+    published_at DATETIME DEFAULT NULL,
+    publish_at DATETIME DEFAULT NULL,
+    source TEXT NOT NULL DEFAULT 'local',
+    devto_id INTEGER DEFAULT NULL,
+    devto_url TEXT NOT NULL DEFAULT '',
+    crosspost_status TEXT NOT NULL DEFAULT '',
+    crosspost_error TEXT NOT NULL DEFAULT '',
+    crosspost_attempts INTEGER NOT NULL DEFAULT 0,
+    deleted_at DATETIME DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO articles_new (id, title, slug, slug_id, description, content, author_id, visibility, is_published, published_at, publish_at, source, devto_id, devto_url, crosspost_status, crosspost_error, crosspost_attempts, deleted_at, created_at, updated_at)
+SELECT id, title, slug, slug_id, description, content,
+    CASE WHEN author_id IN (SELECT id FROM users) THEN author_id ELSE COALESCE((SELECT id FROM users ORDER BY is_admin DESC, id LIMIT 1), author_id) END,
+    visibility, is_published, published_at, publish_at, source, devto_id, devto_url, crosspost_status, crosspost_error, crosspost_attempts, deleted_at, created_at, updated_at
+FROM articles;
+
+DELETE FROM sqlite_sequence WHERE name = 'articles_new';
+UPDATE sqlite_sequence SET name = 'articles_new' WHERE name = 'articles';
+
+DROP TABLE articles;
+
+ALTER TABLE articles_new RENAME TO articles;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_articles_devto_id ON articles (devto_id);
+CREATE INDEX IF NOT EXISTS idx_articles_publish_at ON articles (publish_at);
+CREATE INDEX IF NOT EXISTS idx_articles_deleted_at ON articles (deleted_at);
+
+CREATE TABLE comments_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    content TEXT DEFAULT '',
+    article_id INTEGER NOT NULL REFERENCES articles (id) ON DELETE CASCADE,
+    parent_id INTEGER DEFAULT NULL REFERENCES comments (id) ON DELETE CASCADE,
+    author_id INTEGER NOT NULL,
+    author_type TEXT NOT NULL DEFAULT 'user',
+    status TEXT NOT NULL DEFAULT 'approved',
+    deleted_at DATETIME DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+WITH RECURSIVE kept(id) AS (
+    SELECT id FROM comments WHERE parent_id IS NULL AND article_id IN (SELECT id FROM articles)
+    UNION ALL
+    SELECT comments.id FROM comments JOIN kept ON comments.parent_id = kept.id
+)
+INSERT INTO comments_new (id, content, article_id, parent_id, author_id, author_type, status, deleted_at, created_at, updated_at)
+SELECT id, content, article_id, parent_id, author_id, author_type, status, deleted_at, created_at, updated_at FROM comments WHERE id IN (SELECT id FROM kept);
+
+DELETE FROM sqlite_sequence WHERE name = 'comments_new';
+UPDATE sqlite_sequence SET name = 'comments_new' WHERE name = 'comments';
+
+DROP TABLE comments;
+
+ALTER TABLE comments_new RENAME TO comments;
+
+CREATE INDEX IF NOT EXISTS idx_comments_article_id ON comments (article_id);
+CREATE INDEX IF NOT EXISTS idx_comments_parent_id ON comments (parent_id);
+CREATE INDEX IF NOT EXISTS idx_comments_status ON comments (status);
+CREATE INDEX IF NOT EXISTS idx_comments_deleted_at ON comments (deleted_at);
+
+CREATE TABLE article_tags_new (
+    article_id INTEGER NOT NULL REFERENCES articles (id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (article_id, tag_id)
+);
+
+INSERT INTO article_tags_new (article_id, tag_id, position)
+SELECT article_id, tag_id, position FROM article_tags WHERE article_id IN (SELECT id FROM articles) AND tag_id IN (SELECT id FROM tags);
+
+DROP TABLE article_tags;
+
+ALTER TABLE article_tags_new RENAME TO article_tags;
+
+CREATE INDEX IF NOT EXISTS idx_article_tags_tag_id ON article_tags (tag_id);
+
+CREATE TABLE article_revisions_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    article_id INTEGER NOT NULL REFERENCES articles (id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    content TEXT NOT NULL DEFAULT '',
+    tags TEXT NOT NULL DEFAULT '',
+    author_id INTEGER NOT NULL REFERENCES users (id),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO article_revisions_new (id, article_id, title, content, tags, author_id, created_at)
+SELECT id, article_id, title, content, tags,
+    CASE WHEN author_id IN (SELECT id FROM users) THEN author_id ELSE COALESCE((SELECT id FROM users ORDER BY is_admin DESC, id LIMIT 1), author_id) END,
+    created_at
+FROM article_revisions WHERE article_id IN (SELECT id FROM articles);
+
+DELETE FROM sqlite_sequence WHERE name = 'article_revisions_new';
+UPDATE sqlite_sequence SET name = 'article_revisions_new' WHERE name = 'article_revisions';
+
+DROP TABLE article_revisions;
+
+ALTER TABLE article_revisions_new RENAME TO article_revisions;
+
+CREATE INDEX IF NOT EXISTS idx_article_revisions_article_id ON article_revisions (article_id);
+
+CREATE TABLE article_previews_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    article_id INTEGER NOT NULL REFERENCES articles (id) ON DELETE CASCADE,
+    expires_at DATETIME NOT NULL,
+    revoked_at DATETIME DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO article_previews_new (id, article_id, expires_at, revoked_at, created_at)
+SELECT id, article_id, expires_at, revoked_at, created_at FROM article_previews WHERE article_id IN (SELECT id FROM articles);
+
+DELETE FROM sqlite_sequence WHERE name = 'article_previews_new';
+UPDATE sqlite_sequence SET name = 'article_previews_new' WHERE name = 'article_previews';
+
+DROP TABLE article_previews;
+
+ALTER TABLE article_previews_new RENAME TO article_previews;
+
+CREATE INDEX IF NOT EXISTS idx_article_previews_article_id ON article_previews (article_id);
+
+CREATE TRIGGER IF NOT EXISTS articles_fts_insert AFTER INSERT ON articles BEGIN
+    INSERT INTO articles_fts (rowid, title, description, tags, content)
+    VALUES (new.id, new.title, COALESCE(new.description, ''), (
+        SELECT COALESCE(group_concat(name, ' '), '') FROM (
+            SELECT tags.name FROM article_tags JOIN tags ON tags.id = article_tags.tag_id WHERE article_tags.article_id = new.id ORDER BY article_tags.position
+        )
+    ), COALESCE(new.content, ''));
+END;
+
+CREATE TRIGGER IF NOT EXISTS articles_fts_update AFTER UPDATE OF title, description, content ON articles BEGIN
+    UPDATE articles_fts SET title = new.title, description = COALESCE(new.description, ''), content = COALESCE(new.content, '')
+    WHERE rowid = new.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS articles_fts_delete AFTER DELETE ON articles BEGIN
+    DELETE FROM articles_fts WHERE rowid = old.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS article_tags_fts_insert AFTER INSERT ON article_tags BEGIN
+    UPDATE articles_fts SET tags = (
+        SELECT COALESCE(group_concat(name, ' '), '') FROM (
+            SELECT tags.name FROM article_tags JOIN tags ON tags.id = article_tags.tag_id WHERE article_tags.article_id = new.article_id ORDER BY article_tags.position
+        )
+    ) WHERE rowid = new.article_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS article_tags_fts_delete AFTER DELETE ON article_tags BEGIN
+    UPDATE articles_fts SET tags = (
+        SELECT COALESCE(group_concat(name, ' '), '') FROM (
+            SELECT tags.name FROM article_tags JOIN tags ON tags.id = article_tags.tag_id WHERE article_tags.article_id = old.article_id ORDER BY article_tags.position
+        )
+    ) WHERE rowid = old.article_id;
+END;
+`,
+		Down: `
+DROP TRIGGER IF EXISTS article_tags_fts_delete;
+DROP TRIGGER IF EXISTS article_tags_fts_insert;
+DROP TRIGGER IF EXISTS articles_fts_delete;
+DROP TRIGGER IF EXISTS articles_fts_update;
+DROP TRIGGER IF EXISTS articles_fts_insert;
+
+CREATE TABLE articles_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    slug TEXT NOT NULL UNIQUE,
+    slug_id TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    content TEXT DEFAULT '',
+    author_id INTEGER NOT NULL,
+    visibility TEXT DEFAULT 'PRIVATE',
+    is_published BOOLEAN DEFAULT FALSE,
+    published_at DATETIME DEFAULT NULL,
+    publish_at DATETIME DEFAULT NULL,
+    source TEXT NOT NULL DEFAULT 'local',
+    devto_id INTEGER DEFAULT NULL,
+    devto_url TEXT NOT NULL DEFAULT '',
+    crosspost_status TEXT NOT NULL DEFAULT '',
+    crosspost_error TEXT NOT NULL DEFAULT '',
+    crosspost_attempts INTEGER NOT NULL DEFAULT 0,
+    deleted_at DATETIME DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO articles_new (id, title, slug, slug_id, description, content, author_id, visibility, is_published, published_at, publish_at, source, devto_id, devto_url, crosspost_status, crosspost_error, crosspost_attempts, deleted_at, created_at, updated_at)
+SELECT id, title, slug, slug_id, description, content, author_id, visibility, is_published, published_at, publish_at, source, devto_id, devto_url, crosspost_status, crosspost_error, crosspost_attempts, deleted_at, created_at, updated_at FROM articles;
+
+DELETE FROM sqlite_sequence WHERE name = 'articles_new';
+UPDATE sqlite_sequence SET name = 'articles_new' WHERE name = 'articles';
+
+DROP TABLE articles;
+
+ALTER TABLE articles_new RENAME TO articles;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_articles_devto_id ON articles (devto_id);
+CREATE INDEX IF NOT EXISTS idx_articles_publish_at ON articles (publish_at);
+CREATE INDEX IF NOT EXISTS idx_articles_deleted_at ON articles (deleted_at);
+
+CREATE TABLE comments_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    content TEXT DEFAULT '',
+    article_id INTEGER NOT NULL,
+    parent_id INTEGER DEFAULT NULL,
+    author_id INTEGER NOT NULL,
+    author_type TEXT NOT NULL DEFAULT 'user',
+    status TEXT NOT NULL DEFAULT 'approved',
+    deleted_at DATETIME DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO comments_new (id, content, article_id, parent_id, author_id, author_type, status, deleted_at, created_at, updated_at)
+SELECT id, content, article_id, parent_id, author_id, author_type, status, deleted_at, created_at, updated_at FROM comments;
+
+DELETE FROM sqlite_sequence WHERE name = 'comments_new';
+UPDATE sqlite_sequence SET name = 'comments_new' WHERE name = 'comments';
+
+DROP TABLE comments;
+
+ALTER TABLE comments_new RENAME TO comments;
+
+CREATE INDEX IF NOT EXISTS idx_comments_article_id ON comments (article_id);
+CREATE INDEX IF NOT EXISTS idx_comments_parent_id ON comments (parent_id);
+CREATE INDEX IF NOT EXISTS idx_comments_status ON comments (status);
+CREATE INDEX IF NOT EXISTS idx_comments_deleted_at ON comments (deleted_at);
+
+CREATE TABLE article_tags_new (
+    article_id INTEGER NOT NULL,
+    tag_id INTEGER NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (article_id, tag_id)
+);
+
+INSERT INTO article_tags_new (article_id, tag_id, position)
+SELECT article_id, tag_id, position FROM article_tags;
+
+DROP TABLE article_tags;
+
+ALTER TABLE article_tags_new RENAME TO article_tags;
+
+CREATE INDEX IF NOT EXISTS idx_article_tags_tag_id ON article_tags (tag_id);
+
+CREATE TABLE article_revisions_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    article_id INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    content TEXT NOT NULL DEFAULT '',
+    tags TEXT NOT NULL DEFAULT '',
+    author_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO article_revisions_new (id, article_id, title, content, tags, author_id, created_at)
+SELECT id, article_id, title, content, tags, author_id, created_at FROM article_revisions;
+
+DELETE FROM sqlite_sequence WHERE name = 'article_revisions_new';
+UPDATE sqlite_sequence SET name = 'article_revisions_new' WHERE name = 'article_revisions';
+
+DROP TABLE article_revisions;
+
+ALTER TABLE article_revisions_new RENAME TO article_revisions;
+
+CREATE INDEX IF NOT EXISTS idx_article_revisions_article_id ON article_revisions (article_id);
+
+CREATE TABLE article_previews_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    article_id INTEGER NOT NULL,
+    expires_at DATETIME NOT NULL,
+    revoked_at DATETIME DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO article_previews_new (id, article_id, expires_at, revoked_at, created_at)
+SELECT id, article_id, expires_at, revoked_at, created_at FROM article_previews;
+
+DELETE FROM sqlite_sequence WHERE name = 'article_previews_new';
+UPDATE sqlite_sequence SET name = 'article_previews_new' WHERE name = 'article_previews';
+
+DROP TABLE article_previews;
+
+ALTER TABLE article_previews_new RENAME TO article_previews;
+
+CREATE INDEX IF NOT EXISTS idx_article_previews_article_id ON article_previews (article_id);
+
+CREATE TRIGGER IF NOT EXISTS articles_fts_insert AFTER INSERT ON articles BEGIN
+    INSERT INTO articles_fts (rowid, title, description, tags, content)
+    VALUES (new.id, new.title, COALESCE(new.description, ''), (
+        SELECT COALESCE(group_concat(name, ' '), '') FROM (
+            SELECT tags.name FROM article_tags JOIN tags ON tags.id = article_tags.tag_id WHERE article_tags.article_id = new.id ORDER BY article_tags.position
+        )
+    ), COALESCE(new.content, ''));
+END;
+
+CREATE TRIGGER IF NOT EXISTS articles_fts_update AFTER UPDATE OF title, description, content ON articles BEGIN
+    UPDATE articles_fts SET title = new.title, description = COALESCE(new.description, ''), content = COALESCE(new.content, '')
+    WHERE rowid = new.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS articles_fts_delete AFTER DELETE ON articles BEGIN
+    DELETE FROM articles_fts WHERE rowid = old.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS article_tags_fts_insert AFTER INSERT ON article_tags BEGIN
+    UPDATE articles_fts SET tags = (
+        SELECT COALESCE(group_concat(name, ' '), '') FROM (
+            SELECT tags.name FROM article_tags JOIN tags ON tags.id = article_tags.tag_id WHERE article_tags.article_id = new.article_id ORDER BY article_tags.position
+        )
+    ) WHERE rowid = new.article_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS article_tags_fts_delete AFTER DELETE ON article_tags BEGIN
+    UPDATE articles_fts SET tags = (
+        SELECT COALESCE(group_concat(name, ' '), '') FROM (
+            SELECT tags.name FROM article_tags JOIN tags ON tags.id = article_tags.tag_id WHERE article_tags.article_id = old.article_id ORDER BY article_tags.position
+        )
+    ) WHERE rowid = old.article_id;
+END;
+`,
+	},
+}
@@ -0,0 +1,247 @@
+package migrations
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeArticlesTags(t *testing.T) {
+	db := newTestDB(t)
+
+	if err := Up(db); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	// back to the comma separated column, to fill it the way older versions did
+	steps := len(migrations) - 7
+
+	if err := Down(db, steps); err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+
+	db.MustExec("INSERT INTO users (name, username, password) VALUES ('Admin', 'admin', 'secret')")
+	db.MustExec("INSERT INTO articles (title, slug, author_id, tags) VALUES ('First', 'first', 1, 'Go, sql,go,,web')")
+	db.MustExec("INSERT INTO articles (title, slug, author_id, tags) VALUES ('Second', 'second', 1, 'web')")
+	db.MustExec("INSERT INTO articles (title, slug, author_id, tags) VALUES ('Third', 'third', 1, '')")
+
+	if err := Up(db); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	var tags []string
+
+	if err := db.Select(&tags, "SELECT name FROM tags ORDER BY name"); err != nil {
+		t.Fatalf("error listing the tags: %v", err)
+	}
+
+	if len(tags) != 3 || tags[0] != "go" || tags[1] != "sql" || tags[2] != "web" {
+		t.Errorf("tags = %v, want [go sql web]", tags)
+	}
+
+	var firstTags []string
+
+	if err := db.Select(&firstTags, "SELECT t.name FROM article_tags at JOIN tags t ON t.id = at.tag_id WHERE at.article_id = 1 ORDER BY at.position"); err != nil {
+		t.Fatalf("error listing the article tags: %v", err)
+	}
+
+	if len(firstTags) != 3 || firstTags[0] != "go" || firstTags[1] != "sql" || firstTags[2] != "web" {
+		t.Errorf("first article tags = %v, want [go sql web] in their original order", firstTags)
+	}
+
+	var webCount int
+
+	if err := db.Get(&webCount, "SELECT COUNT(*) FROM article_tags at JOIN tags t ON t.id = at.tag_id WHERE t.name = 'web'"); err != nil {
+		t.Fatalf("error counting the web articles: %v", err)
+	}
+
+	if webCount != 2 {
+		t.Errorf("web articles = %d, want 2", webCount)
+	}
+
+	if err := Down(db, steps); err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+
+	var restored []string
+
+	if err := db.Select(&restored, "SELECT tags FROM articles ORDER BY id"); err != nil {
+		t.Fatalf("error listing the restored tags: %v", err)
+	}
+
+	if len(restored) != 3 || restored[0] != "go,sql,web" || restored[1] != "web" || restored[2] != "" {
+		t.Errorf("restored tags = %q, want [go,sql,web web \"\"]", restored)
+	}
+}
+
+func TestBackfillArticleRevisions(t *testing.T) {
+	db := newTestDB(t)
+
+	if err := Up(db); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	// articles written before revisions existed
+	if err := Down(db, len(migrations)-11); err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+
+	db.MustExec("INSERT INTO users (name, username, password) VALUES ('Admin', 'admin', 'secret')")
+	db.MustExec("INSERT INTO articles (title, slug, content, author_id) VALUES ('Native', 'native', 'mine', 1)")
+	db.MustExec("INSERT INTO articles (title, slug, content, author_id, source) VALUES ('Imported', 'imported', 'theirs', 1, 'devto')")
+	db.MustExec("INSERT INTO tags (name) VALUES ('go'), ('web')")
+	db.MustExec("INSERT INTO article_tags (article_id, tag_id, position) VALUES (1, 2, 0), (1, 1, 1)")
+
+	if err := Up(db); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	var revisions []struct {
+		ArticleID int    `db:"article_id"`
+		Title     string `db:"title"`
+		Content   string `db:"content"`
+		Tags      string `db:"tags"`
+		AuthorID  int    `db:"author_id"`
+	}
+
+	if err := db.Select(&revisions, "SELECT article_id, title, content, tags, author_id FROM article_revisions"); err != nil {
+		t.Fatalf("error listing the revisions: %v", err)
+	}
+
+	if len(revisions) != 1 {
+		t.Fatalf("got %d revisions, want one for the native article only", len(revisions))
+	}
+
+	if r := revisions[0]; r.ArticleID != 1 || r.Title != "Native" || r.Content != "mine" || r.Tags != "web,go" || r.AuthorID != 1 {
+		t.Errorf("revision = %+v, want the current state of the native article", r)
+	}
+}
+
+func TestAddForeignKeys(t *testing.T) {
+	db := newTestDB(t)
+
+	if err := Up(db); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	// rows written before the keys existed, some of them left behind by deleted articles and comments
+	if err := Down(db, len(migrations)-13); err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+
+	db.MustExec("INSERT INTO users (name, username, password) VALUES ('Admin', 'admin', 'secret')")
+	db.MustExec("INSERT INTO articles (id, title, slug, content, author_id) VALUES (1, 'Kept', 'kept', 'go', 1), (2, 'Deleted', 'deleted', '', 1)")
+	db.MustExec("INSERT INTO tags (id, name) VALUES (1, 'go')")
+	db.MustExec("INSERT INTO article_tags (article_id, tag_id) VALUES (1, 1), (2, 1), (3, 1)")
+	db.MustExec("INSERT INTO article_previews (article_id, expires_at) VALUES (1, CURRENT_TIMESTAMP), (3, CURRENT_TIMESTAMP)")
+	db.MustExec("INSERT INTO comments (id, article_id, parent_id, author_id) VALUES (1, 1, NULL, 1), (2, 1, 1, 1), (3, 1, 9, 1), (4, 1, 3, 1), (5, 3, NULL, 1)")
+	db.MustExec("DELETE FROM article_revisions")
+	db.MustExec("INSERT INTO article_revisions (article_id, title, author_id) VALUES (1, 'Kept', 1), (3, 'Gone', 1)")
+	db.MustExec("DELETE FROM articles WHERE id = 2")
+
+	if err := Up(db); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	counts := map[string]int{"article_tags": 1, "article_previews": 1, "article_revisions": 1, "comments": 2}
+
+	for table, want := range counts {
+		var got int
+
+		if err := db.Get(&got, "SELECT COUNT(*) FROM "+table); err != nil {
+			t.Fatalf("error counting %s: %v", table, err)
+		}
+
+		if got != want {
+			t.Errorf("%s = %d rows, want %d", table, got, want)
+		}
+	}
+
+	var matches int
+
+	if err := db.Get(&matches, "SELECT COUNT(*) FROM articles_fts WHERE articles_fts MATCH 'go'"); err != nil || matches != 1 {
+		t.Errorf("search matches = %d, %v, want the search triggers back", matches, err)
+	}
+
+	if _, err := db.Exec("INSERT INTO comments (article_id, author_id) VALUES (42, 1)"); err == nil {
+		t.Errorf("expected a comment of a missing article to be refused")
+	}
+
+	db.MustExec("DELETE FROM articles WHERE id = 1")
+
+	for table := range counts {
+		var got int
+
+		if err := db.Get(&got, "SELECT COUNT(*) FROM "+table); err != nil || got != 0 {
+			t.Errorf("%s = %d rows, %v, want them deleted with the article", table, got, err)
+		}
+	}
+
+	// the ids of deleted rows aren't given again
+	db.MustExec("INSERT INTO articles (title, slug, author_id) VALUES ('New', 'new', 1)")
+
+	var id int
+
+	if err := db.Get(&id, "SELECT id FROM articles WHERE slug = 'new'"); err != nil || id != 3 {
+		t.Errorf("new article id = %d, %v, want 3", id, err)
+	}
+}
+
+func TestAddForeignKeysWithDeletedAuthors(t *testing.T) {
+	db := newTestDB(t)
+
+	if err := Up(db); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	if err := Down(db, len(migrations)-13); err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+
+	// the user was deleted before the keys existed, leaving their article and its revision behind
+	db.MustExec("INSERT INTO users (id, name, username, password) VALUES (1, 'Writer', 'writer', 'secret')")
+	db.MustExec("INSERT INTO users (id, name, username, password, is_admin) VALUES (2, 'Admin', 'admin', 'secret', 1)")
+	db.MustExec("INSERT INTO users (id, name, username, password) VALUES (3, 'Gone', 'gone', 'secret')")
+	db.MustExec("INSERT INTO articles (id, title, slug, author_id) VALUES (1, 'Kept', 'kept', 1), (2, 'Orphan', 'orphan', 3)")
+	db.MustExec("DELETE FROM article_revisions")
+	db.MustExec("INSERT INTO article_revisions (article_id, title, author_id) VALUES (1, 'Kept', 1), (2, 'Orphan', 3)")
+	db.MustExec("DELETE FROM users WHERE id = 3")
+
+	if err := Up(db); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	for _, table := range []string{"articles", "article_revisions"} {
+		var authors []int
+
+		if err := db.Select(&authors, "SELECT author_id FROM "+table+" ORDER BY id"); err != nil {
+			t.Fatalf("error listing the %s authors: %v", table, err)
+		}
+
+		if !reflect.DeepEqual(authors, []int{1, 2}) {
+			t.Errorf("%s authors = %v, want the orphan handed to the admin", table, authors)
+		}
+	}
+}
+
+func TestAddForeignKeysWithoutUsers(t *testing.T) {
+	db := newTestDB(t)
+
+	if err := Up(db); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	if err := Down(db, len(migrations)-13); err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+
+	db.MustExec("INSERT INTO articles (title, slug, author_id) VALUES ('Orphan', 'orphan', 1)")
+
+	if err := Up(db); !errors.Is(err, ErrForeignKeyCheck) {
+		t.Fatalf("Up() error = %v, want %v", err, ErrForeignKeyCheck)
+	}
+
+	if got := appliedCount(t, db); got != len(migrations)-1 {
+		t.Errorf("applied = %d, want %d with the failed migration rolled back", got, len(migrations)-1)
+	}
+}
@@ -0,0 +1,314 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/samluiz/blog/common/logger"
+)
+
+var LOGGER = logger.New(os.Stdout, logger.DebugLevel, "[MIGRATIONS]")
+
+const createSchemaMigrationsStatement = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+`
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+type appliedMigration struct {
+	Version   int       `db:"version"`
+	Name      string    `db:"name"`
+	AppliedAt time.Time `db:"applied_at"`
+}
+
+var (
+	ErrUnknownCommand   = errors.New("unknown migrate command. usage: migrate [up|down [steps]|status]")
+	ErrInvalidSteps     = errors.New("steps must be a positive number")
+	ErrUnknownMigration = errors.New("database has a migration applied that is unknown to this build")
+	ErrForeignKeyCheck  = errors.New("migration left rows pointing to rows that don't exist")
+)
+
+// applies every pending migration, each one in its own transaction
+func Up(db *sqlx.DB) error {
+	applied, err := appliedVersions(db)
+
+	if err != nil {
+		return err
+	}
+
+	pending := 0
+
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+
+		LOGGER.Info("applying migration %04d_%s", m.Version, m.Name)
+
+		// the version is recorded before running the migration. another instance booting at the same time
+		// holds the write lock until it commits, so this either waits and then sees the row, or applies it alone
+		ok, err := runInTransaction(db, m.Up, func(tx *sqlx.Tx) (sql.Result, error) {
+			return tx.Exec("INSERT OR IGNORE INTO schema_migrations (version, name) VALUES (?, ?)", m.Version, m.Name)
+		})
+
+		if err != nil {
+			return fmt.Errorf("error applying migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+
+		if !ok {
+			LOGGER.Info("migration %04d_%s was already applied by another instance", m.Version, m.Name)
+			continue
+		}
+
+		pending++
+	}
+
+	if pending == 0 {
+		LOGGER.Info("database schema is up to date")
+	}
+
+	return nil
+}
+
+// reverts the last n applied migrations, newest first
+func Down(db *sqlx.DB, steps int) error {
+	if steps < 1 {
+		return ErrInvalidSteps
+	}
+
+	applied, err := appliedVersions(db)
+
+	if err != nil {
+		return err
+	}
+
+	for i := len(migrations) - 1; i >= 0 && steps > 0; i-- {
+		m := migrations[i]
+
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+
+		LOGGER.Info("reverting migration %04d_%s", m.Version, m.Name)
+
+		ok, err := runInTransaction(db, m.Down, func(tx *sqlx.Tx) (sql.Result, error) {
+			return tx.Exec("DELETE FROM schema_migrations WHERE version = ?", m.Version)
+		})
+
+		if err != nil {
+			return fmt.Errorf("error reverting migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+
+		if !ok {
+			LOGGER.Info("migration %04d_%s was already reverted by another instance", m.Version, m.Name)
+		}
+
+		steps--
+	}
+
+	return nil
+}
+
+func Status(db *sqlx.DB) ([]MigrationStatus, error) {
+	applied, err := appliedVersions(db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, 0, len(migrations))
+
+	for _, m := range migrations {
+		s := MigrationStatus{Version: m.Version, Name: m.Name}
+
+		if a, ok := applied[m.Version]; ok {
+			s.Applied = true
+			s.AppliedAt = a.AppliedAt
+			delete(applied, m.Version)
+		}
+
+		status = append(status, s)
+	}
+
+	if len(applied) > 0 {
+		return status, ErrUnknownMigration
+	}
+
+	return status, nil
+}
+
+// entrypoint for the "migrate" subcommand. args are everything after "migrate"
+func Run(db *sqlx.DB, args []string) error {
+	if len(args) == 0 {
+		return ErrUnknownCommand
+	}
+
+	switch args[0] {
+	case "up":
+		return Up(db)
+	case "down":
+		steps := 1
+
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil {
+				return ErrInvalidSteps
+			}
+			steps = n
+		}
+
+		return Down(db, steps)
+	case "status":
+		status, err := Status(db)
+
+		for _, s := range status {
+			if s.Applied {
+				fmt.Printf("%04d_%s\tapplied at %s\n", s.Version, s.Name, s.AppliedAt.Format(time.RFC3339))
+			} else {
+				fmt.Printf("%04d_%s\tpending\n", s.Version, s.Name)
+			}
+		}
+
+		return err
+	default:
+		return ErrUnknownCommand
+	}
+}
+
+func appliedVersions(db *sqlx.DB) (map[int]appliedMigration, error) {
+	_, err := db.Exec(createSchemaMigrationsStatement)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []appliedMigration
+
+	err = db.Select(&rows, "SELECT version, name, applied_at FROM schema_migrations ORDER BY version")
+
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]appliedMigration, len(rows))
+
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+
+	return applied, nil
+}
+
+// runs the claim statement first, taking the database write lock, and the migration script only if the claim changed a row.
+// returns false when there was nothing to claim, meaning another process already did the work.
+//
+// foreign keys are switched off on the connection while the script runs, so tables can be rebuilt without the drop of
+// the old one cascading to the rows pointing to it, and are checked as a whole before committing.
+//
+// the script runs as a single multi-statement Exec. this was verified with the modernc sqlite driver (file and :memory:)
+// but never against libsql/Turso, so run "migrate up" against a Turso copy of the database before releasing a new migration
+func runInTransaction(db *sqlx.DB, statement string, claim func(tx *sqlx.Tx) (sql.Result, error)) (bool, error) {
+	ctx := context.Background()
+
+	conn, err := db.Connx(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	defer conn.Close()
+
+	var foreignKeys bool
+
+	if err := conn.GetContext(ctx, &foreignKeys, "PRAGMA foreign_keys"); err != nil {
+		return false, err
+	}
+
+	// the pragma is ignored inside a transaction, so it's changed before beginning it
+	if foreignKeys {
+		if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+			return false, err
+		}
+
+		defer conn.ExecContext(ctx, "PRAGMA foreign_keys = ON")
+	}
+
+	tx, err := conn.BeginTxx(ctx, nil)
+
+	if err != nil {
+		return false, err
+	}
+
+	result, err := claim(tx)
+
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	claimed, err := result.RowsAffected()
+
+	if err != nil || claimed == 0 {
+		tx.Rollback()
+		return false, err
+	}
+
+	if _, err = tx.Exec(statement); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	if err := checkForeignKeys(tx); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func checkForeignKeys(tx *sqlx.Tx) error {
+	rows, err := tx.Query("PRAGMA foreign_key_check")
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	if rows.Next() {
+		var table string
+		var rowid sql.NullInt64
+		var parent string
+		var fk int
+
+		if err := rows.Scan(&table, &rowid, &parent, &fk); err != nil {
+			return err
+		}
+
+		return fmt.Errorf("%w: %s row %d points to a missing %s row", ErrForeignKeyCheck, table, rowid.Int64, parent)
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,297 @@
+package migrations
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "modernc.org/sqlite"
+)
+
+// same setup as the "memory" database driver: a single connection kept open, so every query sees the same database
+func newTestDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	db, err := sqlx.Open("sqlite", ":memory:?_pragma=foreign_keys(1)")
+
+	if err != nil {
+		t.Fatalf("error opening the database: %v", err)
+	}
+
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(0)
+
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func appliedCount(t *testing.T, db *sqlx.DB) int {
+	t.Helper()
+
+	status, err := Status(db)
+
+	if err != nil {
+		t.Fatalf("error getting the status: %v", err)
+	}
+
+	applied := 0
+
+	for _, s := range status {
+		if s.Applied {
+			applied++
+		}
+	}
+
+	return applied
+}
+
+func TestUpAppliesEveryMigration(t *testing.T) {
+	db := newTestDB(t)
+
+	if err := Up(db); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	if got := appliedCount(t, db); got != len(migrations) {
+		t.Errorf("applied = %d, want %d", got, len(migrations))
+	}
+
+	// running it again must be a no-op
+	if err := Up(db); err != nil {
+		t.Fatalf("second Up() error = %v", err)
+	}
+
+	if got := appliedCount(t, db); got != len(migrations) {
+		t.Errorf("applied after second Up() = %d, want %d", got, len(migrations))
+	}
+}
+
+func TestDown(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps int
+		want  int
+		err   error
+	}{
+		{name: "one step", steps: 1, want: len(migrations) - 1},
+		{name: "two steps", steps: 2, want: len(migrations) - 2},
+		{name: "every migration", steps: len(migrations), want: 0},
+		{name: "more steps than migrations", steps: len(migrations) + 3, want: 0},
+		{name: "zero steps", steps: 0, want: len(migrations), err: ErrInvalidSteps},
+		{name: "negative steps", steps: -1, want: len(migrations), err: ErrInvalidSteps},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+
+			if err := Up(db); err != nil {
+				t.Fatalf("Up() error = %v", err)
+			}
+
+			if err := Down(db, tt.steps); !errors.Is(err, tt.err) {
+				t.Fatalf("Down(%d) error = %v, want %v", tt.steps, err, tt.err)
+			}
+
+			if got := appliedCount(t, db); got != tt.want {
+				t.Errorf("applied = %d, want %d", got, tt.want)
+			}
+
+			// the down scripts must leave the schema in a state the up scripts can be applied to again
+			if err := Up(db); err != nil {
+				t.Fatalf("Up() after Down() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	db := newTestDB(t)
+
+	if err := Up(db); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	if err := Down(db, 1); err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+
+	status, err := Status(db)
+
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+
+	if len(status) != len(migrations) {
+		t.Fatalf("len(status) = %d, want %d", len(status), len(migrations))
+	}
+
+	for i, s := range status {
+		if s.Version != migrations[i].Version || s.Name != migrations[i].Name {
+			t.Errorf("status[%d] = %04d_%s, want %04d_%s", i, s.Version, s.Name, migrations[i].Version, migrations[i].Name)
+		}
+
+		wantApplied := i < len(migrations)-1
+
+		if s.Applied != wantApplied {
+			t.Errorf("status[%d].Applied = %v, want %v", i, s.Applied, wantApplied)
+		}
+
+		if s.Applied && s.AppliedAt.IsZero() {
+			t.Errorf("status[%d].AppliedAt is zero", i)
+		}
+	}
+}
+
+func TestStatusWithUnknownMigration(t *testing.T) {
+	db := newTestDB(t)
+
+	if err := Up(db); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	db.MustExec("INSERT INTO schema_migrations (version, name) VALUES (?, ?)", 9999, "from_a_newer_build")
+
+	status, err := Status(db)
+
+	if !errors.Is(err, ErrUnknownMigration) {
+		t.Fatalf("Status() error = %v, want %v", err, ErrUnknownMigration)
+	}
+
+	if len(status) != len(migrations) {
+		t.Errorf("len(status) = %d, want %d", len(status), len(migrations))
+	}
+}
+
+// simulates another instance applying the migration between reading schema_migrations and running it
+func TestMigrationAlreadyClaimed(t *testing.T) {
+	db := newTestDB(t)
+
+	if err := Up(db); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	m := migrations[len(migrations)-1]
+
+	ok, err := runInTransaction(db, m.Up, func(tx *sqlx.Tx) (sql.Result, error) {
+		return tx.Exec("INSERT OR IGNORE INTO schema_migrations (version, name) VALUES (?, ?)", m.Version, m.Name)
+	})
+
+	if err != nil {
+		t.Fatalf("runInTransaction() error = %v, the migration script must not run again", err)
+	}
+
+	if ok {
+		t.Errorf("runInTransaction() = true, want false for an already applied migration")
+	}
+}
+
+// a migration leaving rows that point nowhere is rolled back, and the connection enforces foreign keys again after it
+func TestMigrationBreakingForeignKeys(t *testing.T) {
+	db := newTestDB(t)
+
+	if err := Up(db); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	ok, err := runInTransaction(db, "INSERT INTO comments (article_id, author_id) VALUES (42, 1)", func(tx *sqlx.Tx) (sql.Result, error) {
+		return tx.Exec("INSERT OR IGNORE INTO schema_migrations (version, name) VALUES (?, ?)", 1000, "orphan_comment")
+	})
+
+	if ok || !errors.Is(err, ErrForeignKeyCheck) {
+		t.Fatalf("runInTransaction() = %v, %v, want %v", ok, err, ErrForeignKeyCheck)
+	}
+
+	var comments int
+
+	if err := db.Get(&comments, "SELECT COUNT(*) FROM comments"); err != nil || comments != 0 {
+		t.Errorf("comments = %d, %v, want the migration rolled back", comments, err)
+	}
+
+	var foreignKeys bool
+
+	if err := db.Get(&foreignKeys, "PRAGMA foreign_keys"); err != nil || !foreignKeys {
+		t.Errorf("foreign_keys = %v, %v, want them enabled again", foreignKeys, err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		err  error
+	}{
+		{name: "up", args: []string{"up"}},
+		{name: "status", args: []string{"status"}},
+		{name: "down", args: []string{"down"}},
+		{name: "down with steps", args: []string{"down", "2"}},
+		{name: "down with invalid steps", args: []string{"down", "two"}, err: ErrInvalidSteps},
+		{name: "no command", args: []string{}, err: ErrUnknownCommand},
+		{name: "unknown command", args: []string{"redo"}, err: ErrUnknownCommand},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+
+			if err := Up(db); err != nil {
+				t.Fatalf("Up() error = %v", err)
+			}
+
+			if err := Run(db, tt.args); !errors.Is(err, tt.err) {
+				t.Errorf("Run(%v) error = %v, want %v", tt.args, err, tt.err)
+			}
+		})
+	}
+}
+
+// another instance is in the middle of applying the first migration when this one boots.
+// Up must wait for its write lock and then skip the migration instead of running it twice
+func TestUpWhileAnotherInstanceIsMigrating(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blog.db")
+
+	open := func() *sqlx.DB {
+		db, err := sqlx.Open("sqlite", path+"?_pragma=busy_timeout(5000)")
+
+		if err != nil {
+			t.Fatalf("error opening the database: %v", err)
+		}
+
+		t.Cleanup(func() { db.Close() })
+
+		return db
+	}
+
+	other, db := open(), open()
+	m := migrations[0]
+
+	other.MustExec(createSchemaMigrationsStatement)
+
+	tx := other.MustBegin()
+	tx.MustExec("INSERT OR IGNORE INTO schema_migrations (version, name) VALUES (?, ?)", m.Version, m.Name)
+
+	done := make(chan error)
+
+	go func() { done <- Up(db) }()
+
+	time.Sleep(200 * time.Millisecond)
+
+	tx.MustExec(m.Up)
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("error committing the other instance migration: %v", err)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	if got := appliedCount(t, db); got != len(migrations) {
+		t.Errorf("applied = %d, want %d", got, len(migrations))
+	}
+}
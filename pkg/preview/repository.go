@@ -0,0 +1,86 @@
+package preview
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/samluiz/blog/common/constraint"
+	"github.com/samluiz/blog/pkg/types"
+)
+
+type Repository interface {
+	CreatePreview(articleId int, expiresAt time.Time) (*types.ArticlePreview, error)
+	FindPreviewById(id int) (*types.ArticlePreview, error)
+	FindPreviewsByArticleId(articleId int) ([]*types.ArticlePreview, error)
+	RevokePreview(id int, articleId int, revokedAt time.Time) error
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{db}
+}
+
+func (r *repository) CreatePreview(articleId int, expiresAt time.Time) (*types.ArticlePreview, error) {
+	res, err := r.db.Exec("INSERT INTO article_previews (article_id, expires_at) VALUES (?, ?)", articleId, expiresAt)
+
+	if constraint.IsForeignKey(err) {
+		return nil, types.ErrArticleNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := res.LastInsertId()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return r.FindPreviewById(int(id))
+}
+
+func (r *repository) FindPreviewById(id int) (*types.ArticlePreview, error) {
+	var preview types.ArticlePreview
+	err := r.db.Get(&preview, "SELECT * FROM article_previews WHERE id = ?", id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, types.ErrPreviewNotFound
+		}
+		return nil, err
+	}
+	return &preview, nil
+}
+
+// newest first, revoked and expired ones included so the admin knows which links were shared
+func (r *repository) FindPreviewsByArticleId(articleId int) ([]*types.ArticlePreview, error) {
+	var previews []*types.ArticlePreview
+	err := r.db.Select(&previews, "SELECT * FROM article_previews WHERE article_id = ? ORDER BY created_at DESC, id DESC", articleId)
+	if err != nil {
+		return nil, err
+	}
+	return previews, nil
+}
+
+// the preview must belong to the article. revoking it again keeps the first revocation
+func (r *repository) RevokePreview(id int, articleId int, revokedAt time.Time) error {
+	if _, err := r.db.Exec("UPDATE article_previews SET revoked_at = COALESCE(revoked_at, ?) WHERE id = ? AND article_id = ?", revokedAt, id, articleId); err != nil {
+		return err
+	}
+
+	preview, err := r.FindPreviewById(id)
+
+	if err != nil {
+		return err
+	}
+
+	if preview.ArticleID != articleId {
+		return types.ErrPreviewNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package preview
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samluiz/blog/pkg/types"
+)
+
+type Service interface {
+	// creates a preview of the article expiring after the configured ttl, with the token of its link
+	CreatePreview(articleId int) (*types.ArticlePreview, string, error)
+	// the preview the token was signed for, as long as it wasn't revoked and didn't expire
+	ResolvePreview(token string) (*types.ArticlePreview, error)
+	FindPreviewsByArticleId(articleId int) ([]*types.ArticlePreview, error)
+	RevokePreview(id int, articleId int) error
+	// the token of the link of the preview. it's signed again instead of stored, so it always gives the same token
+	Token(preview *types.ArticlePreview) string
+}
+
+type service struct {
+	repo   Repository
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+func NewService(repo Repository, secret []byte, ttl time.Duration) Service {
+	return &service{repo, secret, ttl, time.Now}
+}
+
+func (s *service) CreatePreview(articleId int) (*types.ArticlePreview, string, error) {
+	// the token only keeps whole seconds, so the stored expiry does too
+	expiresAt := s.now().UTC().Add(s.ttl).Truncate(time.Second)
+
+	preview, err := s.repo.CreatePreview(articleId, expiresAt)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return preview, s.Token(preview), nil
+}
+
+func (s *service) ResolvePreview(token string) (*types.ArticlePreview, error) {
+	id, expiresAt, err := s.verify(token)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// checked before going to the database, since a valid signature is enough to trust the expiry
+	if !s.now().Before(expiresAt) {
+		return nil, types.ErrPreviewExpired
+	}
+
+	preview, err := s.repo.FindPreviewById(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if preview.ExpiresAt.Unix() != expiresAt.Unix() {
+		return nil, types.ErrInvalidPreviewToken
+	}
+
+	if preview.IsRevoked() {
+		return nil, types.ErrPreviewRevoked
+	}
+
+	return preview, nil
+}
+
+func (s *service) FindPreviewsByArticleId(articleId int) ([]*types.ArticlePreview, error) {
+	return s.repo.FindPreviewsByArticleId(articleId)
+}
+
+func (s *service) RevokePreview(id int, articleId int) error {
+	return s.repo.RevokePreview(id, articleId, s.now().UTC())
+}
+
+// base64 of "<id>.<expiry in unix seconds>", a dot and base64 of its hmac
+func (s *service) Token(preview *types.ArticlePreview) string {
+	payload := strconv.Itoa(preview.ID) + "." + strconv.FormatInt(preview.ExpiresAt.Unix(), 10)
+
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(s.sign(payload))
+}
+
+func (s *service) verify(token string) (int, time.Time, error) {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+
+	if !ok {
+		return 0, time.Time{}, types.ErrInvalidPreviewToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+
+	if err != nil {
+		return 0, time.Time{}, types.ErrInvalidPreviewToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+
+	if err != nil || !hmac.Equal(signature, s.sign(string(payload))) {
+		return 0, time.Time{}, types.ErrInvalidPreviewToken
+	}
+
+	rawId, rawExpiry, ok := strings.Cut(string(payload), ".")
+
+	if !ok {
+		return 0, time.Time{}, types.ErrInvalidPreviewToken
+	}
+
+	id, idErr := strconv.Atoi(rawId)
+	expiry, expiryErr := strconv.ParseInt(rawExpiry, 10, 64)
+
+	if idErr != nil || expiryErr != nil {
+		return 0, time.Time{}, types.ErrInvalidPreviewToken
+	}
+
+	return id, time.Unix(expiry, 0), nil
+}
+
+func (s *service) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
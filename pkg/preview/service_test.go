@@ -0,0 +1,141 @@
+package preview
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samluiz/blog/internal/testdb"
+	"github.com/samluiz/blog/pkg/types"
+)
+
+// service with a clock the tests can move
+func newTestService(t *testing.T, secret string) (*service, *time.Time) {
+	t.Helper()
+
+	db, authorId := testdb.OpenWithAdmin(t)
+
+	// the tests link articles 1 to 7
+	for id := 1; id <= 7; id++ {
+		db.MustExec("INSERT INTO articles (id, title, slug, author_id) VALUES (?, ?, ?, ?)", id, "Draft", fmt.Sprintf("draft-%d", id), authorId)
+	}
+
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	s := NewService(NewRepository(db), []byte(secret), time.Hour).(*service)
+	s.now = func() time.Time { return now }
+
+	return s, &now
+}
+
+func TestResolvePreview(t *testing.T) {
+	s, now := newTestService(t, "secret")
+
+	preview, token, err := s.CreatePreview(7)
+
+	if err != nil {
+		t.Fatalf("CreatePreview() error = %v", err)
+	}
+
+	if want := now.Add(time.Hour); !preview.ExpiresAt.Equal(want) {
+		t.Errorf("ExpiresAt = %v, want %v", preview.ExpiresAt, want)
+	}
+
+	if token != s.Token(preview) {
+		t.Errorf("expected Token() to give back the token of the link")
+	}
+
+	resolved, err := s.ResolvePreview(token)
+
+	if err != nil {
+		t.Fatalf("ResolvePreview() error = %v", err)
+	}
+
+	if resolved.ID != preview.ID || resolved.ArticleID != 7 {
+		t.Errorf("ResolvePreview() = %+v, want the preview of article 7", resolved)
+	}
+
+	*now = now.Add(time.Hour)
+
+	if _, err := s.ResolvePreview(token); !errors.Is(err, types.ErrPreviewExpired) {
+		t.Errorf("ResolvePreview() after the ttl error = %v, want %v", err, types.ErrPreviewExpired)
+	}
+}
+
+func TestResolvePreviewRejectsForgedTokens(t *testing.T) {
+	s, _ := newTestService(t, "secret")
+
+	preview, token, err := s.CreatePreview(1)
+
+	if err != nil {
+		t.Fatalf("CreatePreview() error = %v", err)
+	}
+
+	other, _ := newTestService(t, "another secret")
+
+	payload, signature, _ := strings.Cut(token, ".")
+
+	// same id with a later expiry, signed with the wrong secret
+	extended := *preview
+	extended.ExpiresAt = preview.ExpiresAt.Add(24 * time.Hour)
+	forged, _, _ := strings.Cut(other.Token(&extended), ".")
+
+	tokens := map[string]string{
+		"empty":             "",
+		"without signature": payload,
+		"other secret":      other.Token(preview),
+		"changed payload":   forged + "." + signature,
+		"changed signature": payload + "." + signature[:len(signature)-2] + "AA",
+		"not base64":        "%%%.%%%",
+	}
+
+	for name, token := range tokens {
+		t.Run(name, func(t *testing.T) {
+			if _, err := s.ResolvePreview(token); !errors.Is(err, types.ErrInvalidPreviewToken) {
+				t.Errorf("ResolvePreview() error = %v, want %v", err, types.ErrInvalidPreviewToken)
+			}
+		})
+	}
+}
+
+func TestRevokePreview(t *testing.T) {
+	s, _ := newTestService(t, "secret")
+
+	preview, token, err := s.CreatePreview(3)
+
+	if err != nil {
+		t.Fatalf("CreatePreview() error = %v", err)
+	}
+
+	if err := s.RevokePreview(preview.ID, 4); !errors.Is(err, types.ErrPreviewNotFound) {
+		t.Errorf("RevokePreview() of another article error = %v, want %v", err, types.ErrPreviewNotFound)
+	}
+
+	if _, err := s.ResolvePreview(token); err != nil {
+		t.Fatalf("expected the preview to still work, got %v", err)
+	}
+
+	if err := s.RevokePreview(preview.ID, 3); err != nil {
+		t.Fatalf("RevokePreview() error = %v", err)
+	}
+
+	if _, err := s.ResolvePreview(token); !errors.Is(err, types.ErrPreviewRevoked) {
+		t.Errorf("ResolvePreview() after revoking error = %v, want %v", err, types.ErrPreviewRevoked)
+	}
+
+	previews, err := s.FindPreviewsByArticleId(3)
+
+	if err != nil || len(previews) != 1 || !previews[0].IsRevoked() {
+		t.Errorf("FindPreviewsByArticleId() = %v, %v, want the revoked preview", previews, err)
+	}
+}
+
+func TestCreatePreviewOfMissingArticle(t *testing.T) {
+	s, _ := newTestService(t, "secret")
+
+	if _, _, err := s.CreatePreview(42); !errors.Is(err, types.ErrArticleNotFound) {
+		t.Errorf("CreatePreview() error = %v, want %v", err, types.ErrArticleNotFound)
+	}
+}
@@ -1,26 +1,100 @@
 package types
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
+
+// tables the comment author can come from. admins are users, readers logged in with github are external users
+const (
+	AUTHOR_USER     = "user"
+	AUTHOR_EXTERNAL = "external"
+)
+
+// moderation status of a comment. only approved comments are shown to readers
+const (
+	COMMENT_PENDING  = "pending"
+	COMMENT_APPROVED = "approved"
+	COMMENT_REJECTED = "rejected"
+	COMMENT_SPAM     = "spam"
+)
+
+// actions the admin can apply to many comments at once in the moderation queue
+const (
+	MODERATE_APPROVE = "approve"
+	MODERATE_REJECT  = "reject"
+	MODERATE_SPAM    = "spam"
+	MODERATE_BAN     = "ban"
+)
 
 type Comment struct {
-	ID        int    `db:"id"`
-	Content   string `db:"content"`
-	ArticleID int    `db:"article_id"`
-	AuthorID  int    `db:"author_id"`
-	CreatedAt string `db:"created_at"`
-	UpdatedAt string `db:"updated_at"`
+	ID         int        `db:"id"`
+	Content    string     `db:"content"`
+	ArticleID  int        `db:"article_id"`
+	ParentID   *int       `db:"parent_id"`
+	AuthorID   int        `db:"author_id"`
+	AuthorType string     `db:"author_type"`
+	Status     string     `db:"status"`
+	CreatedAt  time.Time  `db:"created_at"`
+	UpdatedAt  time.Time  `db:"updated_at"`
+	DeletedAt  *time.Time `db:"deleted_at"`
+
+	AuthorUsername string `db:"author_username"`
+	AuthorAvatar   string `db:"author_avatar"`
+	AuthorBanned   bool   `db:"author_banned"`
+	ArticleTitle   string `db:"article_title"`
+	ArticleSlug    string `db:"article_slug"`
+}
+
+func (c *Comment) IsApproved() bool {
+	return c.Status == COMMENT_APPROVED
+}
+
+// someone changing a comment. authors can change their own comments and admins can change all of them
+type CommentActor struct {
+	ID      int
+	Type    string
+	IsAdmin bool
+}
+
+func (c *Comment) CanBeChangedBy(actor CommentActor) bool {
+	if actor.IsAdmin {
+		return true
+	}
+
+	return actor.ID != 0 && c.AuthorID == actor.ID && c.AuthorType == actor.Type
+}
+
+func (c *Comment) IsEdited() bool {
+	return c.UpdatedAt.Sub(c.CreatedAt) > time.Second
+}
+
+// a comment with its replies, in the order they were written
+type CommentThread struct {
+	*Comment
+	Replies []*CommentThread
 }
 
 type CreateCommentInput struct {
-	Content   string `db:"content"`
-	ArticleID int    `db:"article_id"`
-	AuthorID  int    `db:"author_id"`
+	Content    string `db:"content"`
+	ArticleID  int    `db:"article_id"`
+	ParentID   *int   `db:"parent_id"`
+	AuthorID   int    `db:"author_id"`
+	AuthorType string `db:"author_type"`
+	Status     string `db:"status"`
 }
 
 type UpdateCommentInput struct {
 	Content string `db:"content"`
+	Status  string `db:"status"`
 }
 
 var (
-	ErrCommentNotFound = errors.New("comment not found")
+	ErrCommentNotFound      = errors.New("comment not found")
+	ErrInvalidParentComment = errors.New("replies must belong to the same article as the comment they answer")
+	ErrInvalidAuthorType    = errors.New("comment author must be a user or an external user")
+	ErrInvalidCommentStatus = errors.New("comment status must be pending, approved, rejected or spam")
+	ErrInvalidModeration    = errors.New("moderation action must be approve, reject, spam or ban")
+	ErrUserBanned           = errors.New("user is banned from commenting")
+	ErrParentCommentTrashed = errors.New("the comment this one answers is in the trash")
 )
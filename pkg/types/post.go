@@ -10,34 +10,65 @@ const (
 	PUBLIC  = "PUBLIC"
 )
 
+const (
+	SOURCE_LOCAL = "local"
+	SOURCE_DEVTO = "devto"
+)
+
+const (
+	CROSSPOST_PENDING = "pending"
+	CROSSPOST_SYNCED  = "synced"
+	CROSSPOST_FAILED  = "failed"
+)
+
 type Article struct {
-	ID          int       `db:"id"`
-	Title       string    `db:"title"`
-	Slug        string    `db:"slug"`
-	SlugID      string    `db:"slug_id"`
-	Content     string    `db:"content"`
-	Tags        []string  `db:"tags"`
-	AuthorID    int       `db:"author_id"`
-	Visibility  string    `db:"visibility"`
-	IsPublished bool      `db:"is_published"`
-	PublishedAt time.Time `db:"published_at"`
-	CreatedAt   time.Time `db:"created_at"`
-	UpdatedAt   time.Time `db:"updated_at"`
+	ID          int        `db:"id"`
+	Title       string     `db:"title"`
+	Slug        string     `db:"slug"`
+	SlugID      string     `db:"slug_id"`
+	Description string     `db:"description"`
+	Content     string     `db:"content"`
+	Tags        []string   `db:"tags"`
+	AuthorID    int        `db:"author_id"`
+	Visibility  string     `db:"visibility"`
+	IsPublished bool       `db:"is_published"`
+	PublishedAt *time.Time `db:"published_at"`
+	PublishAt   *time.Time `db:"publish_at"`
+	Source      string     `db:"source"`
+	DevToID     *int       `db:"devto_id"`
+	DevToURL    string     `db:"devto_url"`
+	DeletedAt   *time.Time `db:"deleted_at"`
+	CreatedAt   time.Time  `db:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at"`
+
+	CrossPostStatus   string `db:"crosspost_status"`
+	CrossPostError    string `db:"crosspost_error"`
+	CrossPostAttempts int    `db:"crosspost_attempts"`
 }
 
 type GetArticleOutput struct {
-	ID          int       `db:"id"`
-	Title       string    `db:"title"`
-	Slug        string    `db:"slug"`
-	SlugID      string    `db:"slug_id"`
-	Content     string    `db:"content"`
-	Tags        string    `db:"tags"`
-	AuthorID    int       `db:"author_id"`
-	Visibility  string    `db:"visibility"`
-	IsPublished bool      `db:"is_published"`
-	PublishedAt time.Time `db:"published_at"`
-	CreatedAt   time.Time `db:"created_at"`
-	UpdatedAt   time.Time `db:"updated_at"`
+	ID          int        `db:"id"`
+	Title       string     `db:"title"`
+	Slug        string     `db:"slug"`
+	SlugID      string     `db:"slug_id"`
+	Description string     `db:"description"`
+	Content     string     `db:"content"`
+	Tags        string     `db:"tags"`
+	AuthorID    int        `db:"author_id"`
+	Visibility  string     `db:"visibility"`
+	IsPublished bool       `db:"is_published"`
+	PublishedAt *time.Time `db:"published_at"`
+	PublishAt   *time.Time `db:"publish_at"`
+	Source      string     `db:"source"`
+	DevToID     *int       `db:"devto_id"`
+	DevToURL    string     `db:"devto_url"`
+	DeletedAt   *time.Time `db:"deleted_at"`
+	CreatedAt   time.Time  `db:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at"`
+
+	CrossPostStatus   string `db:"crosspost_status"`
+	CrossPostError    string `db:"crosspost_error"`
+	CrossPostAttempts int    `db:"crosspost_attempts"`
 }
 
 type CreateArticleInput struct {
@@ -52,6 +83,33 @@ type UpdateArticleInput struct {
 	Title   string   `db:"title"`
 	Content string   `db:"content"`
 	Tags    []string `db:"tags"`
+	// the user saving the article, recorded in its revision
+	EditorID int `db:"author_id"`
+}
+
+// an article that lives on dev.to and is copied into the local database by the sync job
+type ImportArticleInput struct {
+	DevToID     int       `db:"devto_id"`
+	Title       string    `db:"title"`
+	Slug        string    `db:"slug"`
+	Description string    `db:"description"`
+	Content     string    `db:"content"`
+	Tags        []string  `db:"tags"`
+	AuthorID    int       `db:"author_id"`
+	PublishedAt time.Time `db:"published_at"`
+}
+
+// the matched words of the highlights are wrapped in these, so they can be marked after the rest is escaped
+const (
+	SEARCH_MATCH_START = "\x02"
+	SEARCH_MATCH_END   = "\x03"
+)
+
+// a published article matching a search, with its title and an excerpt of its content highlighted
+type ArticleSearchResult struct {
+	GetArticleOutput
+	TitleHighlight string `db:"title_highlight"`
+	Snippet        string `db:"snippet"`
 }
 
 type PublishArticleInput struct {
@@ -59,5 +117,10 @@ type PublishArticleInput struct {
 }
 
 var (
-	ErrArticleNotFound = errors.New("article not found")
+	ErrArticleNotFound  = errors.New("article not found")
+	ErrInvalidOrderBy   = errors.New("articles can't be ordered by this field")
+	ErrNotPublished     = errors.New("article is not published")
+	ErrImportedArticle  = errors.New("article is imported from dev.to and can only be changed there")
+	ErrAlreadyPublished = errors.New("article is already published")
+	ErrScheduleInPast   = errors.New("articles can only be scheduled to be published in the future")
 )
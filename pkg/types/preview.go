@@ -0,0 +1,30 @@
+package types
+
+import (
+	"errors"
+	"time"
+)
+
+// a link sharing an unpublished article with reviewers, who don't need to log in to read it
+type ArticlePreview struct {
+	ID        int        `db:"id"`
+	ArticleID int        `db:"article_id"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+func (p *ArticlePreview) IsExpired(now time.Time) bool {
+	return !now.Before(p.ExpiresAt)
+}
+
+func (p *ArticlePreview) IsRevoked() bool {
+	return p.RevokedAt != nil
+}
+
+var (
+	ErrPreviewNotFound     = errors.New("preview link not found")
+	ErrInvalidPreviewToken = errors.New("preview token is invalid")
+	ErrPreviewExpired      = errors.New("preview link has expired")
+	ErrPreviewRevoked      = errors.New("preview link was revoked")
+)
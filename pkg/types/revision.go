@@ -0,0 +1,20 @@
+package types
+
+import (
+	"errors"
+	"time"
+)
+
+// the state of a native article after one of its saves. restoring a revision saves it again as a new one
+type ArticleRevision struct {
+	ID             int       `db:"id"`
+	ArticleID      int       `db:"article_id"`
+	Title          string    `db:"title"`
+	Content        string    `db:"content"`
+	Tags           string    `db:"tags"`
+	AuthorID       int       `db:"author_id"`
+	AuthorUsername string    `db:"author_username"`
+	CreatedAt      time.Time `db:"created_at"`
+}
+
+var ErrRevisionNotFound = errors.New("revision not found")
@@ -2,6 +2,7 @@ package user
 
 import (
 	"database/sql"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/samluiz/blog/pkg/types"
@@ -9,6 +10,9 @@ import (
 
 type Repository interface {
 	UserExistsById(id int) error
+	ExternalUserExistsById(id int) error
+	IsExternalUserBanned(id int) (bool, error)
+	BanExternalUsers(ids []int) (int, error)
 	FindUserById(id int) (*types.GetUserOutput, error)
 	FindUserByUsername(username string) (*types.GetUserOutput, error)
 	FindExternalUserByUsername(username string, provider string) (*types.GetExternalUserOutput, error)
@@ -27,18 +31,71 @@ func NewRepository(db *sqlx.DB) Repository {
 
 func (r *repository) UserExistsById(id int) error {
 	var count int
-	err := r.db.Get(&count, "SELECT COUNT(*) FROM users WHERE username = ?", id)
+	err := r.db.Get(&count, "SELECT COUNT(*) FROM users WHERE id = ?", id)
+
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return types.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (r *repository) ExternalUserExistsById(id int) error {
+	var count int
+	err := r.db.Get(&count, "SELECT COUNT(*) FROM external_users WHERE id = ?", id)
 
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil
-		}
 		return err
 	}
 
+	if count == 0 {
+		return types.ErrUserNotFound
+	}
+
 	return nil
 }
 
+func (r *repository) IsExternalUserBanned(id int) (bool, error) {
+	var banned bool
+	err := r.db.Get(&banned, "SELECT banned_at IS NOT NULL FROM external_users WHERE id = ?", id)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, types.ErrUserNotFound
+		}
+		return false, err
+	}
+
+	return banned, nil
+}
+
+// bans the external users that aren't banned yet and returns how many were banned
+func (r *repository) BanExternalUsers(ids []int) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	query, args, err := sqlx.In("UPDATE external_users SET banned_at = ? WHERE banned_at IS NULL AND id IN (?)", time.Now(), ids)
+
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := r.db.Exec(r.db.Rebind(query), args...)
+
+	if err != nil {
+		return 0, err
+	}
+
+	banned, err := res.RowsAffected()
+
+	return int(banned), err
+}
+
 func (r *repository) FindUserById(id int) (*types.GetUserOutput, error) {
 	var user types.GetUserOutput
 	err := r.db.Get(&user, "SELECT id, username, password, is_admin, avatar, created_at, updated_at FROM users WHERE id = ?", id)
@@ -0,0 +1,27 @@
+// youtube videos of the articles are rendered as links, so nothing is loaded from youtube until the reader plays one.
+// playing swaps the link for a player from youtube-nocookie, which doesn't set cookies before the video starts
+document.addEventListener("click", (event) => {
+  const link = event.target.closest("figure.embed-youtube a");
+
+  if (!link || event.ctrlKey || event.metaKey || event.shiftKey) {
+    return;
+  }
+
+  const figure = link.closest("figure");
+  const id = figure.dataset.youtubeId;
+
+  if (!/^[A-Za-z0-9_-]{11}$/.test(id || "")) {
+    return;
+  }
+
+  event.preventDefault();
+
+  const player = document.createElement("iframe");
+  player.src = "https://www.youtube-nocookie.com/embed/" + id + "?autoplay=1";
+  player.title = "YouTube video player";
+  player.allow = "accelerometer; autoplay; clipboard-write; encrypted-media; gyroscope; picture-in-picture";
+  player.allowFullscreen = true;
+  player.referrerPolicy = "strict-origin-when-cross-origin";
+
+  figure.replaceChildren(player);
+});